@@ -2,8 +2,8 @@ package jsonrpc2
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"math/rand"
 	"net/http"
 	"time"
@@ -16,25 +16,57 @@ func init() {
 
 type Client interface {
 	Send(method string, params Params, result interface{}) error
+	SendContext(ctx context.Context, method string, params Params, result interface{}) error
 }
 
-func NewClient(discovery Discovery) Client {
+type ClientOption func(*client)
 
-	return &client{
+func WithTimeout(timeout time.Duration) ClientOption {
+
+	return func(c *client) {
+
+		c.httpClient.Timeout = timeout
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+
+	return func(c *client) {
+
+		c.httpClient = httpClient
+	}
+}
+
+func NewClient(discovery Discovery, options ...ClientOption) Client {
+
+	c := client{
 		balancer: newBalancer(discovery),
 		httpClient: &http.Client{
 			Timeout: time.Second,
 		},
 	}
+
+	for _, option := range options {
+
+		option(&c)
+	}
+
+	return &c
 }
 
 type client struct {
 	balancer   *balancer
 	httpClient *http.Client
+	dialer     Dialer
 }
 
 func (c *client) Send(method string, params Params, result interface{}) error {
 
+	return c.SendContext(context.Background(), method, params, result)
+}
+
+func (c *client) SendContext(ctx context.Context, method string, params Params, result interface{}) error {
+
 	data, _ := json.Marshal(Request{
 		Jsonrpc:   "2.0",
 		RequestID: rand.Int(),
@@ -42,7 +74,27 @@ func (c *client) Send(method string, params Params, result interface{}) error {
 		Params:    params,
 	})
 
-	var lastError error
+	var (
+		lastError error
+		decoded   bool
+	)
+
+	if fn, ok := result.(ElementFunc); ok {
+
+		result = ElementFunc(func(element json.RawMessage) error {
+
+			decoded = true
+
+			return fn(element)
+		})
+	}
+
+	pinned, _ := ctx.Value(upstreamKey{}).(*string)
+
+	if pinned != nil && *pinned != "" {
+
+		return c.sendContext(ctx, *pinned, data, result)
+	}
 
 	if c.balancer.len() == 0 {
 
@@ -58,14 +110,27 @@ func (c *client) Send(method string, params Params, result interface{}) error {
 			return err
 		}
 
-		lastError = c.send(url, data, result)
+		lastError = c.sendContext(ctx, url, data, result)
 
 		if lastError == nil {
 
+			if pinned != nil {
+
+				*pinned = url
+			}
+
 			return nil
 		}
 
-		if _, ok := lastError.(*LogicError); ok {
+		if ctx.Err() != nil || decoded {
+
+			return lastError
+		}
+
+		// Only transport errors fail over, an error the server answered
+		// would be answered by the next upstream too.
+		switch lastError.(type) {
+		case *LogicError, *Error:
 
 			return lastError
 		}
@@ -74,15 +139,77 @@ func (c *client) Send(method string, params Params, result interface{}) error {
 	return lastError
 }
 
+type upstreamKey struct{}
+
+// pinUpstream returns a context whose calls through the balancing client all
+// go to the upstream that answered the first of them, without failover.
+func pinUpstream(ctx context.Context) context.Context {
+
+	return context.WithValue(ctx, upstreamKey{}, new(string))
+}
+
 func (c *client) send(url string, data []byte, result interface{}) error {
 
-	response, err := c.httpClient.Post(url, "application/x-www-form-urlencoded", bytes.NewReader(data))
+	return c.sendContext(context.Background(), url, data, result)
+}
+
+func (c *client) sendContext(ctx context.Context, url string, data []byte, result interface{}) error {
+
+	dial := c.dialer
+
+	if dial == nil {
+
+		dial = HTTPDialer(c.httpClient)
+	}
+
+	codec, err := dial(ctx, url)
+
+	if err != nil {
+
+		return err
+	}
+
+	defer codec.Close()
+
+	if err := codec.WriteMessage(data); err != nil {
+
+		return err
+	}
+
+	if fn, ok := result.(ElementFunc); ok {
+
+		if r, ok := codec.(streamReader); ok {
+
+			body, err := r.readStream()
+
+			if err != nil {
+
+				return err
+			}
+
+			return decodeStream(body, fn)
+		}
+	}
+
+	message, err := codec.ReadMessage()
 
 	if err != nil {
 
 		return err
 	}
 
+	return decodeResponse(message, result)
+}
+
+// decodeResponse decodes the result of a response into result, or returns
+// the error of the response.
+func decodeResponse(message []byte, result interface{}) error {
+
+	if fn, ok := result.(ElementFunc); ok {
+
+		return decodeStream(bytes.NewReader(message), fn)
+	}
+
 	r := Response{
 		Result: struct{}{},
 	}
@@ -92,7 +219,7 @@ func (c *client) send(url string, data []byte, result interface{}) error {
 		r.Result = result
 	}
 
-	if err := json.NewDecoder(response.Body).Decode(&r); err != nil {
+	if err := json.NewDecoder(bytes.NewReader(message)).Decode(&r); err != nil {
 
 		return err
 	}
@@ -102,15 +229,16 @@ func (c *client) send(url string, data []byte, result interface{}) error {
 		return nil
 	}
 
-	if r.Error.Code == LogicErr {
+	return callError(r.Error)
+}
 
-		return &LogicError{message: r.Error.Message}
-	}
+// callError returns the error of a call failed with e.
+func callError(e *Error) error {
 
-	return errorFmt(r.Error.Code, r.Error.Message)
-}
+	if e.Code == LogicErr {
 
-func errorFmt(code int16, message string) error {
+		return &LogicError{message: e.Message}
+	}
 
-	return fmt.Errorf("%d:%s", code, message)
+	return e
 }
@@ -1,11 +1,14 @@
 package jsonrpc2
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -88,7 +91,11 @@ func TestClient_sendError(t *testing.T) {
 
 	if err := client.send(testServer.URL, []byte{}, nil); assert.Error(t, err) {
 
-		assert.Equal(t, errorFmt(InternalError, Errors[InternalError]).Error(), err.Error())
+		if e, ok := err.(*Error); assert.True(t, ok) {
+
+			assert.True(t, InternalError == e.Code)
+			assert.Equal(t, Errors[InternalError], e.Message)
+		}
 	}
 
 	if err := client.send("http://dev.null", []byte{}, nil); assert.Error(t, err) {
@@ -196,10 +203,98 @@ func TestClientLastError(t *testing.T) {
 
 	if err := client.Send("", &EmptyParams{}, nil); assert.Error(t, err) {
 
-		assert.Equal(t, errorFmt(InvalidParams, Errors[InvalidParams]).Error(), err.Error())
+		if e, ok := err.(*Error); assert.True(t, ok) {
+
+			assert.True(t, InvalidParams == e.Code)
+			assert.Equal(t, Errors[InvalidParams], e.Message)
+		}
+	}
+}
+
+func TestClientErrorCode(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		json.NewEncoder(w).Encode(&Response{
+			Jsonrpc:   "2.0",
+			RequestID: 42,
+			Error: &Error{
+				Code:    RateLimited,
+				Message: Errors[RateLimited],
+				Data:    "1s",
+			},
+		})
+	}))
+
+	defer testServer.Close()
+
+	client := NewClient(&testDiscovery{addresses: []string{testServer.URL}})
+
+	if err := client.Send("", &EmptyParams{}, nil); assert.Error(t, err) {
+
+		if e, ok := err.(*Error); assert.True(t, ok) {
+
+			assert.True(t, RateLimited == e.Code)
+			assert.Equal(t, "1s", e.Data)
+		}
+	}
+}
+
+func TestClientSendContext(t *testing.T) {
+
+	var requests int32
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		atomic.AddInt32(&requests, 1)
+
+		ioutil.ReadAll(r.Body)
+
+		<-r.Context().Done()
+	}))
+
+	defer testServer.Close()
+
+	client := NewClient(&testDiscovery{addresses: []string{testServer.URL, testServer.URL}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+	defer cancel()
+
+	if err := client.SendContext(ctx, "", &EmptyParams{}, nil); assert.Error(t, err) {
+
+		assert.Contains(t, err.Error(), "context deadline exceeded")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
 	}
 }
 
+func TestClientNoFailoverOnError(t *testing.T) {
+
+	var requests int32
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		atomic.AddInt32(&requests, 1)
+
+		json.NewEncoder(w).Encode(&Response{
+			Jsonrpc:   "2.0",
+			RequestID: 42,
+			Error:     NewError(RateLimited, "1s"),
+		})
+	}))
+
+	defer testServer.Close()
+
+	client := NewClient(&testDiscovery{addresses: []string{testServer.URL, testServer.URL, testServer.URL}})
+
+	if err, ok := client.Send("", &EmptyParams{}, nil).(*Error); assert.True(t, ok) {
+
+		assert.True(t, RateLimited == err.Code)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
 func TestClientErrorNoLiveUpstreams(t *testing.T) {
 
 	client := NewClient(&testDiscovery{})
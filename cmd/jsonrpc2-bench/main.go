@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage:
+
+	jsonrpc2-bench [flags] scenario.json
+
+The scenario file describes the run, flags override its values:
+
+	{
+		"urls": ["http://127.0.0.1:8080"],
+		"duration": "30s",
+		"requests": 0,
+		"rate": 200,
+		"concurrency": 16,
+		"timeout": "1s",
+		"calls": [
+			{"method": "Catalog.Find", "params": {"name": "x", "limit": 10}, "weight": 3},
+			{"method": "Catalog.Count"}
+		]
+	}
+
+Calls are picked at random in proportion to their weight (1 by default).
+Without a rate every worker sends the next request as soon as the previous
+one returns. Latencies are reported in milliseconds. The exit code is 1 if
+any request failed.
+
+Flags:
+
+`
+
+func main() {
+
+	var (
+		urls        = flag.String("url", "", "comma-separated server URLs")
+		runFor      = flag.Duration("duration", 0, "how long to run")
+		requests    = flag.Int64("requests", 0, "number of requests to send")
+		rate        = flag.Float64("rate", 0, "target requests per second, 0 for as fast as possible")
+		concurrency = flag.Int("concurrency", 0, "number of requests in flight")
+		timeout     = flag.Duration("timeout", 0, "request timeout")
+		asJSON      = flag.Bool("json", false, "print the report as JSON")
+	)
+
+	flag.Usage = func() {
+
+		fmt.Fprint(os.Stderr, usage)
+
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+
+		flag.Usage()
+
+		os.Exit(2)
+	}
+
+	s, err := loadScenario(flag.Arg(0))
+
+	if err != nil {
+
+		fmt.Fprintf(os.Stderr, "jsonrpc2-bench: %v\n", err)
+
+		os.Exit(1)
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+
+		switch f.Name {
+		case "url":
+
+			s.URLs = strings.Split(*urls, ",")
+
+		case "duration":
+
+			s.Duration = duration(*runFor)
+
+		case "requests":
+
+			s.Requests = *requests
+
+		case "rate":
+
+			s.Rate = *rate
+
+		case "concurrency":
+
+			s.Concurrency = *concurrency
+
+		case "timeout":
+
+			s.Timeout = duration(*timeout)
+		}
+	})
+
+	if err := s.validate(); err != nil {
+
+		fmt.Fprintf(os.Stderr, "jsonrpc2-bench: %v\n", err)
+
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	defer stop()
+
+	var (
+		st      = newStats()
+		elapsed = run(ctx, newClient(s), s, st)
+		r       = st.report(elapsed)
+	)
+
+	if *asJSON {
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(r)
+
+	} else {
+
+		printReport(os.Stdout, r)
+	}
+
+	if r.Errors != 0 {
+
+		os.Exit(1)
+	}
+}
+
+func newClient(s *scenario) jsonrpc2.Client {
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = s.Concurrency
+
+	return jsonrpc2.NewClient(jsonrpc2.StaticDiscovery(s.URLs), jsonrpc2.WithHTTPClient(&http.Client{
+		Timeout:   time.Duration(s.Timeout),
+		Transport: transport,
+	}))
+}
+
+func printReport(w io.Writer, r *report) {
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	defer tw.Flush()
+
+	fmt.Fprintf(tw, "requests\t%d\n", r.Requests)
+	fmt.Fprintf(tw, "errors\t%d\n", r.Errors)
+	fmt.Fprintf(tw, "duration\t%.2fs\n", r.Duration)
+	fmt.Fprintf(tw, "throughput\t%.1f req/s\n", r.Throughput)
+	fmt.Fprintf(tw, "latency\t%s\n", formatLatency(r.Latency))
+
+	if len(r.Histogram) != 0 {
+
+		var most int64
+
+		for _, b := range r.Histogram {
+
+			if b.Count > most {
+
+				most = b.Count
+			}
+		}
+
+		fmt.Fprintf(tw, "\nhistogram\n")
+
+		for _, b := range r.Histogram {
+
+			fmt.Fprintf(tw, "  <= %gms\t%s\t%d\n", b.LE, strings.Repeat("#", int(40*b.Count/most)), b.Count)
+		}
+	}
+
+	if len(r.Codes) != 0 {
+
+		fmt.Fprintf(tw, "\nerrors by code\n")
+
+		for _, code := range sortedKeys(r.Codes) {
+
+			fmt.Fprintf(tw, "  %s\t%s\t%d\n", code, errorMessage(code), r.Codes[code])
+		}
+	}
+
+	methods := make(map[string]int64, len(r.Methods))
+
+	for method, m := range r.Methods {
+
+		methods[method] = m.Requests
+	}
+
+	fmt.Fprintf(tw, "\nmethods\n")
+
+	for _, method := range sortedKeys(methods) {
+
+		m := r.Methods[method]
+
+		fmt.Fprintf(tw, "  %s\t%d req\t%d errors\t%s\n", method, m.Requests, m.Errors, formatLatency(m.Latency))
+	}
+}
+
+func formatLatency(l latency) string {
+
+	return fmt.Sprintf("min %gms  mean %gms  p50 %gms  p90 %gms  p99 %gms  p99.9 %gms  max %gms", l.Min, l.Mean, l.P50, l.P90, l.P99, l.P999, l.Max)
+}
+
+func errorMessage(code string) string {
+
+	var c int16
+
+	if _, err := fmt.Sscan(code, &c); err != nil {
+
+		return ""
+	}
+
+	if c == jsonrpc2.LogicErr {
+
+		return "Logic error"
+	}
+
+	return jsonrpc2.Errors[c]
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// run sends the calls of the scenario until the duration elapses, the
+// number of requests is reached or ctx is done. With a rate the requests are
+// started at even intervals, limited by the concurrency; without one every
+// worker sends the next request as soon as the previous one returns.
+func run(ctx context.Context, client jsonrpc2.Client, s *scenario, st *stats) time.Duration {
+
+	if s.Duration > 0 {
+
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.Duration))
+
+		defer cancel()
+	}
+
+	var (
+		issued int64
+		tokens chan struct{}
+		start  = time.Now()
+		wg     = &sync.WaitGroup{}
+	)
+
+	if s.Rate > 0 {
+
+		tokens = make(chan struct{})
+
+		go pace(ctx, s.Rate, tokens)
+	}
+
+	for i := 0; i < s.Concurrency; i++ {
+
+		wg.Add(1)
+
+		go func(seed int64) {
+
+			defer wg.Done()
+
+			rnd := rand.New(rand.NewSource(seed))
+
+			for {
+
+				if tokens != nil {
+
+					select {
+					case <-ctx.Done():
+
+						return
+
+					case <-tokens:
+					}
+
+				} else if ctx.Err() != nil {
+
+					return
+				}
+
+				if s.Requests > 0 && atomic.AddInt64(&issued, 1) > s.Requests {
+
+					return
+				}
+
+				c := s.pick(rnd)
+
+				// Calls in flight when the run ends are not cancelled, so
+				// they are not counted as errors.
+				var (
+					result    json.RawMessage
+					callStart = time.Now()
+					err       = client.SendContext(context.Background(), c.Method, c.Params, &result)
+				)
+
+				st.record(c.Method, time.Since(callStart), err)
+			}
+
+		}(time.Now().UnixNano() + int64(i))
+	}
+
+	wg.Wait()
+
+	return time.Since(start)
+}
+
+// pace sends rate tokens per second to tokens until ctx is done.
+func pace(ctx context.Context, rate float64, tokens chan<- struct{}) {
+
+	interval := time.Duration(float64(time.Second) / rate)
+
+	if interval < time.Millisecond {
+
+		interval = time.Millisecond
+	}
+
+	var (
+		sent   int64
+		start  = time.Now()
+		ticker = time.NewTicker(interval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+
+		due := int64(time.Since(start).Seconds()*rate) + 1
+
+		for ; sent < due; sent++ {
+
+			select {
+			case <-ctx.Done():
+
+				return
+
+			case tokens <- struct{}{}:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+
+			return
+
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type SumParams struct {
+	A, B int
+}
+
+func (p *SumParams) IsValid() bool {
+
+	return true
+}
+
+type testMath struct{}
+
+func (m *testMath) Sum(params *SumParams) (int, error) {
+
+	return params.A + params.B, nil
+}
+
+func (m *testMath) Count(_ *jsonrpc2.EmptyParams) (int, error) {
+
+	return 1, nil
+}
+
+func testScenario(url string) *scenario {
+
+	return &scenario{
+		URLs:        []string{url},
+		Concurrency: 4,
+		Calls: []call{
+			{Method: "Math.Sum", Params: jsonrpc2.RawParams(`{"A": 1, "B": 2}`)},
+			{Method: "Math.Count"},
+			{Method: "Math.Unknown"},
+		},
+	}
+}
+
+func TestRunRequests(t *testing.T) {
+
+	s := server.New()
+	s.RegisterObject("Math", &testMath{})
+
+	ts := httptest.NewServer(s)
+
+	defer ts.Close()
+
+	sc := testScenario(ts.URL)
+	sc.Requests = 300
+
+	if !assert.NoError(t, sc.validate()) {
+
+		return
+	}
+
+	st := newStats()
+
+	run(context.Background(), newClient(sc), sc, st)
+
+	r := st.report(time.Second)
+
+	if assert.Equal(t, int64(300), r.Requests) && assert.Len(t, r.Methods, 3) {
+
+		assert.Equal(t, r.Methods["Math.Unknown"].Requests, r.Errors)
+		assert.Equal(t, map[string]int64{"-32601": r.Errors}, r.Codes)
+		assert.Equal(t, int64(0), r.Methods["Math.Sum"].Errors)
+	}
+}
+
+func TestRunRate(t *testing.T) {
+
+	s := server.New()
+	s.RegisterObject("Math", &testMath{})
+
+	ts := httptest.NewServer(s)
+
+	defer ts.Close()
+
+	sc := testScenario(ts.URL)
+	sc.Duration = duration(500 * time.Millisecond)
+	sc.Rate = 100
+
+	if !assert.NoError(t, sc.validate()) {
+
+		return
+	}
+
+	st := newStats()
+
+	elapsed := run(context.Background(), newClient(sc), sc, st)
+
+	assert.InDelta(t, 500*time.Millisecond, elapsed, float64(200*time.Millisecond))
+	assert.InDelta(t, 50, st.report(elapsed).Requests, 10)
+}
+
+func TestRunUnavailable(t *testing.T) {
+
+	ts := httptest.NewServer(nil)
+	ts.Close()
+
+	sc := testScenario(ts.URL)
+	sc.Requests = 10
+
+	if !assert.NoError(t, sc.validate()) {
+
+		return
+	}
+
+	st := newStats()
+
+	run(context.Background(), newClient(sc), sc, st)
+
+	assert.Equal(t, map[string]int64{"transport": 10}, st.report(time.Second).Codes)
+}
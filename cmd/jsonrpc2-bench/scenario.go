@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"io/ioutil"
+	"math/rand"
+	"time"
+)
+
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+
+	if err != nil {
+
+		return err
+	}
+
+	*d = duration(v)
+
+	return nil
+}
+
+type call struct {
+	Method string             `json:"method"`
+	Params jsonrpc2.RawParams `json:"params"`
+	Weight int                `json:"weight"`
+}
+
+// scenario describes a benchmark run. Flags given on the command line
+// override the values read from the file.
+type scenario struct {
+	URLs        []string `json:"urls"`
+	Duration    duration `json:"duration"`
+	Requests    int64    `json:"requests"`
+	Rate        float64  `json:"rate"`
+	Concurrency int      `json:"concurrency"`
+	Timeout     duration `json:"timeout"`
+	Calls       []call   `json:"calls"`
+	weights     int
+}
+
+func loadScenario(path string) (*scenario, error) {
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	var s scenario
+
+	if err := json.Unmarshal(data, &s); err != nil {
+
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return &s, nil
+}
+
+func (s *scenario) validate() error {
+
+	if len(s.URLs) == 0 {
+
+		return fmt.Errorf("no urls")
+	}
+
+	if len(s.Calls) == 0 {
+
+		return fmt.Errorf("no calls")
+	}
+
+	if s.Duration <= 0 && s.Requests <= 0 {
+
+		return fmt.Errorf("either duration or requests is required")
+	}
+
+	if s.Concurrency <= 0 {
+
+		s.Concurrency = 1
+	}
+
+	if s.Timeout <= 0 {
+
+		s.Timeout = duration(time.Second)
+	}
+
+	s.weights = 0
+
+	for i := range s.Calls {
+
+		if s.Calls[i].Method == "" {
+
+			return fmt.Errorf("call %d: method is required", i+1)
+		}
+
+		if s.Calls[i].Weight < 0 {
+
+			return fmt.Errorf("call %d: negative weight", i+1)
+		}
+
+		if s.Calls[i].Weight == 0 {
+
+			s.Calls[i].Weight = 1
+		}
+
+		s.weights += s.Calls[i].Weight
+	}
+
+	return nil
+}
+
+// pick returns a call at random, in proportion to the weights.
+func (s *scenario) pick(rnd *rand.Rand) *call {
+
+	n := rnd.Intn(s.weights)
+
+	for i := range s.Calls {
+
+		if n < s.Calls[i].Weight {
+
+			return &s.Calls[i]
+		}
+
+		n -= s.Calls[i].Weight
+	}
+
+	return &s.Calls[len(s.Calls)-1]
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadScenario(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "scenario.json")
+
+	if !assert.NoError(t, ioutil.WriteFile(file, []byte(`{
+		"urls": ["http://127.0.0.1:8080"],
+		"duration": "10s",
+		"rate": 100,
+		"calls": [
+			{"method": "Math.Sum", "params": {"a": 1, "b": 2}, "weight": 3},
+			{"method": "Math.Count"}
+		]
+	}`), 0644)) {
+
+		return
+	}
+
+	s, err := loadScenario(file)
+
+	if !assert.NoError(t, err) || !assert.NoError(t, s.validate()) {
+
+		return
+	}
+
+	assert.Equal(t, duration(10*time.Second), s.Duration)
+	assert.Equal(t, duration(time.Second), s.Timeout)
+	assert.Equal(t, 1, s.Concurrency)
+	assert.Equal(t, 100.0, s.Rate)
+	assert.Equal(t, 4, s.weights)
+
+	if data, err := s.Calls[0].Params.MarshalJSON(); assert.NoError(t, err) {
+
+		assert.JSONEq(t, `{"a": 1, "b": 2}`, string(data))
+	}
+
+	if data, err := s.Calls[1].Params.MarshalJSON(); assert.NoError(t, err) {
+
+		assert.Equal(t, "{}", string(data))
+	}
+
+	if !assert.NoError(t, ioutil.WriteFile(file, []byte(`{"duration": "10 seconds"}`), 0644)) {
+
+		return
+	}
+
+	_, err = loadScenario(file)
+
+	assert.Error(t, err)
+}
+
+func TestScenarioValidate(t *testing.T) {
+
+	for _, s := range []*scenario{
+		{Duration: duration(time.Second), Calls: []call{{Method: "a"}}},
+		{URLs: []string{"u"}, Duration: duration(time.Second)},
+		{URLs: []string{"u"}, Calls: []call{{Method: "a"}}},
+		{URLs: []string{"u"}, Requests: 1, Calls: []call{{}}},
+		{URLs: []string{"u"}, Requests: 1, Calls: []call{{Method: "a", Weight: -1}}},
+	} {
+
+		assert.Error(t, s.validate())
+	}
+}
+
+func TestScenarioPick(t *testing.T) {
+
+	s := &scenario{
+		URLs:     []string{"u"},
+		Requests: 1,
+		Calls: []call{
+			{Method: "a", Weight: 3},
+			{Method: "b"},
+		},
+	}
+
+	if !assert.NoError(t, s.validate()) {
+
+		return
+	}
+
+	var (
+		counts = make(map[string]int)
+		rnd    = rand.New(rand.NewSource(1))
+	)
+
+	for i := 0; i < 4000; i++ {
+
+		counts[s.pick(rnd).Method]++
+	}
+
+	assert.InDelta(t, 3000, counts["a"], 150)
+	assert.InDelta(t, 1000, counts["b"], 150)
+}
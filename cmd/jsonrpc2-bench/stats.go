@@ -0,0 +1,351 @@
+package main
+
+import (
+	"github.com/kshvakov/jsonrpc2"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// subBuckets is the number of histogram buckets per power of two, which
+// keeps the error of the reported percentiles under 1/subBuckets.
+const subBuckets = 16
+
+// histogram counts latencies in microseconds in log-linear buckets.
+type histogram struct {
+	counts []int64
+	count  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+func bucketOf(us int64) int {
+
+	if us < subBuckets {
+
+		return int(us)
+	}
+
+	e := bits.Len64(uint64(us)) - 5
+
+	return (e+1)*subBuckets + int(us>>uint(e)) - subBuckets
+}
+
+func bucketBounds(b int) (int64, int64) {
+
+	if b < subBuckets {
+
+		return int64(b), int64(b) + 1
+	}
+
+	e := uint(b/subBuckets - 1)
+
+	lower := int64(b%subBuckets+subBuckets) << e
+
+	return lower, lower + 1<<e
+}
+
+func (h *histogram) record(latency time.Duration) {
+
+	us := latency.Microseconds()
+
+	if us < 0 {
+
+		us = 0
+	}
+
+	b := bucketOf(us)
+
+	for len(h.counts) <= b {
+
+		h.counts = append(h.counts, 0)
+	}
+
+	if h.count == 0 || us < h.min {
+
+		h.min = us
+	}
+
+	if us > h.max {
+
+		h.max = us
+	}
+
+	h.counts[b]++
+	h.count++
+	h.sum += us
+}
+
+// percentile returns the upper bound of the bucket holding the p-th
+// percentile, capped at the maximum.
+func (h *histogram) percentile(p float64) int64 {
+
+	if h.count == 0 {
+
+		return 0
+	}
+
+	var (
+		rank = int64(math.Ceil(p / 100 * float64(h.count)))
+		seen int64
+	)
+
+	for b, count := range h.counts {
+
+		if seen += count; seen >= rank && count != 0 {
+
+			_, upper := bucketBounds(b)
+
+			if upper-1 > h.max {
+
+				return h.max
+			}
+
+			return upper - 1
+		}
+	}
+
+	return h.max
+}
+
+type latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	P999 float64 `json:"p999"`
+	Max  float64 `json:"max"`
+}
+
+type bin struct {
+	LE    float64 `json:"le"`
+	Count int64   `json:"count"`
+}
+
+func ms(us int64) float64 {
+
+	return float64(us) / 1000
+}
+
+func (h *histogram) latency() latency {
+
+	if h.count == 0 {
+
+		return latency{}
+	}
+
+	return latency{
+		Min:  ms(h.min),
+		Mean: ms(h.sum / h.count),
+		P50:  ms(h.percentile(50)),
+		P90:  ms(h.percentile(90)),
+		P99:  ms(h.percentile(99)),
+		P999: ms(h.percentile(99.9)),
+		Max:  ms(h.max),
+	}
+}
+
+// bins merges the buckets into powers of two milliseconds, starting at
+// 0.125ms, for printing and comparing runs.
+func (h *histogram) bins() []bin {
+
+	var bins []bin
+
+	for b, count := range h.counts {
+
+		if count == 0 {
+
+			continue
+		}
+
+		_, upper := bucketBounds(b)
+
+		le := 125.0
+
+		for float64(upper-1) > le {
+
+			le *= 2
+		}
+
+		if len(bins) != 0 && bins[len(bins)-1].LE == le/1000 {
+
+			bins[len(bins)-1].Count += count
+
+			continue
+		}
+
+		bins = append(bins, bin{LE: le / 1000, Count: count})
+	}
+
+	return bins
+}
+
+type methodStats struct {
+	requests int64
+	errors   map[string]int64
+	latency  *histogram
+}
+
+func newMethodStats() *methodStats {
+
+	return &methodStats{
+		errors:  make(map[string]int64),
+		latency: &histogram{},
+	}
+}
+
+func (m *methodStats) record(latency time.Duration, err error) {
+
+	m.requests++
+	m.latency.record(latency)
+
+	if err != nil {
+
+		m.errors[errorCode(err)]++
+	}
+}
+
+// errorCode is the key errors are counted by: the JSON-RPC error code, or a
+// name for errors which did not come from the server.
+func errorCode(err error) string {
+
+	switch e := err.(type) {
+	case *jsonrpc2.Error:
+
+		return strconv.Itoa(int(e.Code))
+
+	case *jsonrpc2.LogicError:
+
+		return strconv.Itoa(jsonrpc2.LogicErr)
+
+	case *jsonrpc2.ErrorNoLiveUpstreams:
+
+		return "no_live_upstreams"
+	}
+
+	return "transport"
+}
+
+func newStats() *stats {
+
+	return &stats{
+		mutex:   &sync.Mutex{},
+		total:   newMethodStats(),
+		methods: make(map[string]*methodStats),
+	}
+}
+
+type stats struct {
+	mutex   *sync.Mutex
+	total   *methodStats
+	methods map[string]*methodStats
+}
+
+func (s *stats) record(method string, latency time.Duration, err error) {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	m, found := s.methods[method]
+
+	if !found {
+
+		m = newMethodStats()
+
+		s.methods[method] = m
+	}
+
+	m.record(latency, err)
+
+	s.total.record(latency, err)
+}
+
+type methodReport struct {
+	Requests int64            `json:"requests"`
+	Errors   int64            `json:"errors"`
+	Codes    map[string]int64 `json:"errors_by_code,omitempty"`
+	Latency  latency          `json:"latency_ms"`
+}
+
+type report struct {
+	Duration   float64                  `json:"duration_s"`
+	Throughput float64                  `json:"throughput_rps"`
+	Requests   int64                    `json:"requests"`
+	Errors     int64                    `json:"errors"`
+	Codes      map[string]int64         `json:"errors_by_code,omitempty"`
+	Latency    latency                  `json:"latency_ms"`
+	Histogram  []bin                    `json:"histogram_ms"`
+	Methods    map[string]*methodReport `json:"methods"`
+}
+
+func (m *methodStats) report() *methodReport {
+
+	r := &methodReport{
+		Requests: m.requests,
+		Latency:  m.latency.latency(),
+	}
+
+	if len(m.errors) != 0 {
+
+		r.Codes = make(map[string]int64, len(m.errors))
+
+		for code, count := range m.errors {
+
+			r.Codes[code] = count
+			r.Errors += count
+		}
+	}
+
+	return r
+}
+
+func (s *stats) report(elapsed time.Duration) *report {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	total := s.total.report()
+
+	r := &report{
+		Duration:  elapsed.Seconds(),
+		Requests:  total.Requests,
+		Errors:    total.Errors,
+		Codes:     total.Codes,
+		Latency:   total.Latency,
+		Histogram: s.total.latency.bins(),
+		Methods:   make(map[string]*methodReport, len(s.methods)),
+	}
+
+	if elapsed > 0 {
+
+		r.Throughput = float64(r.Requests) / elapsed.Seconds()
+	}
+
+	for method, m := range s.methods {
+
+		r.Methods[method] = m.report()
+	}
+
+	return r
+}
+
+func sortedKeys(m map[string]int64) []string {
+
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
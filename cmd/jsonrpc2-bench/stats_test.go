@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+
+	for _, us := range []int64{0, 1, 15, 16, 17, 31, 32, 33, 100, 1000, 123456, 60000000} {
+
+		lower, upper := bucketBounds(bucketOf(us))
+
+		assert.True(t, lower <= us && us < upper, us)
+		assert.True(t, float64(upper-lower) <= float64(lower)/subBuckets+1, us)
+	}
+
+	for b := 0; b < 200; b++ {
+
+		lower, upper := bucketBounds(b)
+
+		assert.Equal(t, b, bucketOf(lower))
+		assert.Equal(t, b, bucketOf(upper-1))
+	}
+}
+
+func TestHistogramLatency(t *testing.T) {
+
+	h := &histogram{}
+
+	assert.Equal(t, latency{}, h.latency())
+
+	for i := 1; i <= 1000; i++ {
+
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+
+	l := h.latency()
+
+	assert.Equal(t, 1.0, l.Min)
+	assert.Equal(t, 1000.0, l.Max)
+	assert.InDelta(t, 500.5, l.Mean, 0.01)
+	assert.InEpsilon(t, 500, l.P50, 1.0/subBuckets)
+	assert.InEpsilon(t, 900, l.P90, 1.0/subBuckets)
+	assert.InEpsilon(t, 990, l.P99, 1.0/subBuckets)
+	assert.Equal(t, 1000.0, l.P999)
+
+	var total int64
+
+	for i, b := range h.bins() {
+
+		if i != 0 {
+
+			assert.Equal(t, h.bins()[i-1].LE*2, b.LE)
+		}
+
+		total += b.Count
+	}
+
+	assert.Equal(t, int64(1000), total)
+	if last := h.bins()[len(h.bins())-1]; assert.Equal(t, 1024.0, last.LE) {
+
+		assert.InDelta(t, 489, last.Count, 489.0/subBuckets)
+	}
+}
+
+func TestStatsReport(t *testing.T) {
+
+	st := newStats()
+	st.record("Math.Sum", time.Millisecond, nil)
+	st.record("Math.Sum", 2*time.Millisecond, jsonrpc2.NewError(jsonrpc2.InvalidParams, ""))
+	st.record("Math.Div", 3*time.Millisecond, &jsonrpc2.LogicError{})
+	st.record("Math.Div", 4*time.Millisecond, errors.New("connection refused"))
+
+	r := st.report(2 * time.Second)
+
+	assert.Equal(t, int64(4), r.Requests)
+	assert.Equal(t, int64(3), r.Errors)
+	assert.Equal(t, 2.0, r.Throughput)
+	assert.Equal(t, map[string]int64{"-32602": 1, "-32001": 1, "transport": 1}, r.Codes)
+
+	if assert.Len(t, r.Methods, 2) {
+
+		assert.Equal(t, int64(2), r.Methods["Math.Div"].Requests)
+		assert.Equal(t, int64(2), r.Methods["Math.Div"].Errors)
+		assert.Equal(t, 4.0, r.Methods["Math.Div"].Latency.Max)
+		assert.Equal(t, map[string]int64{"-32602": 1}, r.Methods["Math.Sum"].Codes)
+	}
+
+	assert.Equal(t, "no_live_upstreams", errorCode(&jsonrpc2.ErrorNoLiveUpstreams{}))
+	assert.Equal(t, "Invalid params", errorMessage("-32602"))
+	assert.Equal(t, "", errorMessage("transport"))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+func newAccessLog(w io.Writer, router *router) *accessLog {
+
+	return &accessLog{
+		w:      w,
+		router: router,
+		mutex:  &sync.Mutex{},
+		now:    time.Now,
+	}
+}
+
+// accessLog writes a line for every call with the caller, the method, the
+// upstream it was routed to, the error code (0 for success) and the time it
+// took.
+type accessLog struct {
+	w      io.Writer
+	router *router
+	mutex  *sync.Mutex
+	now    func() time.Time
+}
+
+func (l *accessLog) Middleware(next server.HandlerFunc) server.HandlerFunc {
+
+	return func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+		start := l.now()
+
+		result, err := next(ctx, request)
+
+		var (
+			remote    = "-"
+			principal = "-"
+			upstream  = "-"
+			code      int16
+		)
+
+		if r := server.HTTPRequest(ctx); r != nil {
+
+			remote = r.RemoteAddr
+		}
+
+		if p := server.PrincipalFromContext(ctx); p != nil {
+
+			principal = p.Name
+		}
+
+		if u, found := l.router.route(request.Method); found {
+
+			upstream = u.name
+		}
+
+		switch e := err.(type) {
+		case nil:
+
+		case *jsonrpc2.Error:
+
+			code = e.Code
+
+		default:
+
+			code = jsonrpc2.LogicErr
+		}
+
+		l.mutex.Lock()
+
+		fmt.Fprintf(l.w, "%s remote=%s principal=%s method=%q upstream=%s id=%d code=%d duration=%s\n",
+			start.UTC().Format(time.RFC3339), remote, principal, request.Method, upstream, request.RequestID, code, l.now().Sub(start))
+
+		l.mutex.Unlock()
+
+		return result, err
+	}
+}
+
+func (l *accessLog) authenticationFailed(r *http.Request, err error) {
+
+	l.mutex.Lock()
+
+	defer l.mutex.Unlock()
+
+	fmt.Fprintf(l.w, "%s remote=%s code=%d error=%q\n", l.now().UTC().Format(time.RFC3339), r.RemoteAddr, jsonrpc2.Unauthorized, err.Error())
+}
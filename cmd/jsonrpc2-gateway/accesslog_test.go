@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAccessLog(t *testing.T) {
+
+	var (
+		buf    bytes.Buffer
+		now    = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		r, _   = newRouter(map[string]*route{"Catalog": {URLs: []string{"http://a"}}}, time.Second)
+		l      = newAccessLog(&buf, r)
+		called int
+	)
+
+	l.now = func() time.Time {
+
+		called++
+
+		return now.Add(time.Duration(called-1) * time.Millisecond)
+	}
+
+	for _, err := range []error{nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, ""), errors.New("logic")} {
+
+		handler := l.Middleware(func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+			return nil, err
+		})
+
+		handler(context.Background(), &jsonrpc2.ServerRequest{Request: jsonrpc2.Request{RequestID: 7, Method: "Catalog.Find"}})
+	}
+
+	l.authenticationFailed(&http.Request{RemoteAddr: "10.0.0.1:1234"}, errors.New("invalid token"))
+
+	assert.Equal(t, `2024-01-02T03:04:05Z remote=- principal=- method="Catalog.Find" upstream=Catalog id=7 code=0 duration=1ms
+2024-01-02T03:04:05Z remote=- principal=- method="Catalog.Find" upstream=Catalog id=7 code=-32602 duration=1ms
+2024-01-02T03:04:05Z remote=- principal=- method="Catalog.Find" upstream=Catalog id=7 code=-32001 duration=1ms
+2024-01-02T03:04:05Z remote=10.0.0.1:1234 code=-32004 error="invalid token"
+`, buf.String())
+}
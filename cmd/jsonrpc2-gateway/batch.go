@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+type messageServer interface {
+	gateway
+	HTTPContext(r *http.Request) context.Context
+	HandleMessage(ctx context.Context, message json.RawMessage) json.RawMessage
+}
+
+// batchGateway handles the calls of a request concurrently. Each call passes
+// the middlewares on its own, the calls routed to the same upstream are then
+// forwarded to it as one batch.
+type batchGateway struct {
+	messageServer
+	concurrency int
+}
+
+func (g *batchGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != "POST" {
+
+		g.messageServer.ServeHTTP(w, r)
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	var (
+		message json.RawMessage
+		entries []json.RawMessage
+		batch   bool
+	)
+
+	if err == nil {
+
+		err = json.NewDecoder(bytes.NewReader(data)).Decode(&message)
+	}
+
+	if err == nil {
+
+		if batch = isBatch(message); batch {
+
+			err = json.Unmarshal(message, &entries)
+
+		} else {
+
+			entries = []json.RawMessage{message}
+		}
+	}
+
+	// Invalid requests are answered by the server.
+	if err != nil || len(entries) == 0 {
+
+		g.messageServer.ServeHTTP(w, r)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	responses := g.handle(g.HTTPContext(r), entries)
+
+	if !batch {
+
+		w.Write(append(responses[0], '\n'))
+
+		return
+	}
+
+	var answered []json.RawMessage
+
+	for i, response := range responses {
+
+		if !isNotification(entries[i]) {
+
+			answered = append(answered, response)
+		}
+	}
+
+	if len(answered) == 0 {
+
+		return
+	}
+
+	data, _ = json.Marshal(answered)
+
+	w.Write(append(data, '\n'))
+}
+
+// handle handles the calls concurrently and returns their responses in the
+// same order.
+func (g *batchGateway) handle(ctx context.Context, entries []json.RawMessage) []json.RawMessage {
+
+	var (
+		wg        = &sync.WaitGroup{}
+		responses = make([]json.RawMessage, len(entries))
+		batch     = &routedBatch{
+			ctx:         ctx,
+			concurrency: g.concurrency,
+			mutex:       &sync.Mutex{},
+			pending:     len(entries),
+			calls:       make(map[*upstream][]*routedCall),
+		}
+	)
+
+	for i, message := range entries {
+
+		entry := &batchEntry{
+			batch:        batch,
+			notification: isNotification(message),
+		}
+
+		if isBatch(message) {
+
+			responses[i], _ = json.Marshal(&jsonrpc2.Response{
+				Jsonrpc: "2.0",
+				Error:   jsonrpc2.NewError(jsonrpc2.InvalidRequest, ""),
+			})
+
+			batch.settle(entry, nil, nil)
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int, message json.RawMessage) {
+
+			defer wg.Done()
+
+			responses[i] = g.HandleMessage(context.WithValue(ctx, batchEntryKey{}, entry), message)
+
+			batch.settle(entry, nil, nil)
+
+		}(i, message)
+	}
+
+	wg.Wait()
+
+	return responses
+}
+
+type batchEntryKey struct{}
+
+// batchEntry is a call of a request, it is settled once it was routed or
+// answered without an upstream.
+type batchEntry struct {
+	batch        *routedBatch
+	notification bool
+	settled      bool
+}
+
+// routedCall is a call forwarded to an upstream, done is closed once it was
+// answered.
+type routedCall struct {
+	method       string
+	params       json.RawMessage
+	notification bool
+	result       json.RawMessage
+	err          error
+	done         chan struct{}
+}
+
+// routedBatch collects the calls of a request routed to the upstreams. Once
+// every call is settled each upstream is sent its calls as one batch.
+type routedBatch struct {
+	ctx         context.Context
+	concurrency int
+	mutex       *sync.Mutex
+	pending     int
+	calls       map[*upstream][]*routedCall
+}
+
+// settle settles an entry, with the call routed to u unless call is nil.
+func (b *routedBatch) settle(entry *batchEntry, u *upstream, call *routedCall) {
+
+	b.mutex.Lock()
+
+	if entry.settled {
+
+		b.mutex.Unlock()
+
+		return
+	}
+
+	entry.settled = true
+
+	if call != nil {
+
+		b.calls[u] = append(b.calls[u], call)
+	}
+
+	b.pending--
+
+	ready := b.pending == 0
+
+	b.mutex.Unlock()
+
+	if ready {
+
+		b.send()
+	}
+}
+
+// send sends the calls to their upstreams, at most concurrency batches at a
+// time.
+func (b *routedBatch) send() {
+
+	n := b.concurrency
+
+	if n < 1 {
+
+		n = 1
+	}
+
+	var (
+		wg    = &sync.WaitGroup{}
+		slots = make(chan struct{}, n)
+	)
+
+	for u, calls := range b.calls {
+
+		slots <- struct{}{}
+
+		wg.Add(1)
+
+		go func(u *upstream, calls []*routedCall) {
+
+			defer func() {
+
+				<-slots
+
+				wg.Done()
+			}()
+
+			u.send(b.ctx, calls)
+
+		}(u, calls)
+	}
+
+	wg.Wait()
+}
+
+type upstreamRequest struct {
+	Jsonrpc string             `json:"jsonrpc"`
+	ID      int                `json:"id,omitempty"`
+	Method  string             `json:"method"`
+	Params  jsonrpc2.RawParams `json:"params"`
+}
+
+type upstreamResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpc2.Error `json:"error"`
+}
+
+// send sends calls to the upstream as one batch, the notifications without
+// an id. The servers of the upstream are tried in turn until one answers,
+// the calls fail with a server error if none does.
+func (u *upstream) send(ctx context.Context, calls []*routedCall) {
+
+	var (
+		requests  = make([]upstreamRequest, len(calls))
+		responses []upstreamResponse
+	)
+
+	for i, call := range calls {
+
+		requests[i] = upstreamRequest{
+			Jsonrpc: "2.0",
+			Method:  call.method,
+			Params:  jsonrpc2.RawParams(call.params),
+		}
+
+		if !call.notification {
+
+			requests[i].ID = i + 1
+		}
+	}
+
+	data, _ := json.Marshal(requests)
+
+	message, err := u.post(ctx, data)
+
+	if err == nil && len(bytes.TrimSpace(message)) != 0 {
+
+		if err = json.Unmarshal(message, &responses); err != nil {
+
+			var response upstreamResponse
+
+			// An upstream rejecting the whole batch answers with one error.
+			if json.Unmarshal(message, &response) == nil && response.Error != nil {
+
+				for i := range calls {
+
+					responses = append(responses, upstreamResponse{ID: i + 1, Error: response.Error})
+				}
+
+				err = nil
+			}
+		}
+	}
+
+	answered := make([]bool, len(calls))
+
+	for _, response := range responses {
+
+		if i := response.ID - 1; i >= 0 && i < len(calls) && !calls[i].notification && !answered[i] {
+
+			answered[i] = true
+
+			calls[i].result = response.Result
+
+			if response.Error != nil {
+
+				calls[i].err = response.Error
+			}
+
+			close(calls[i].done)
+		}
+	}
+
+	for i, call := range calls {
+
+		if call.notification || answered[i] {
+
+			continue
+		}
+
+		call.err = jsonrpc2.NewError(jsonrpc2.ServerError, "upstream "+u.name+" unavailable")
+
+		if err == nil {
+
+			call.err = jsonrpc2.NewError(jsonrpc2.ServerError, "upstream "+u.name+" did not answer")
+		}
+
+		close(call.done)
+	}
+}
+
+// post posts a message to the servers of the upstream in turn, starting with
+// the next one, until one answers.
+func (u *upstream) post(ctx context.Context, message json.RawMessage) (json.RawMessage, error) {
+
+	urls, err := u.discovery.Get()
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	if len(urls) == 0 {
+
+		return nil, &jsonrpc2.ErrorNoLiveUpstreams{}
+	}
+
+	next := int(atomic.AddUint32(u.next, 1))
+
+	for i := 0; i < len(urls); i++ {
+
+		var codec jsonrpc2.Codec
+
+		if codec, err = u.dial(ctx, urls[(next+i)%len(urls)]); err != nil {
+
+			continue
+		}
+
+		if err = codec.WriteMessage(message); err == nil {
+
+			var response json.RawMessage
+
+			if response, err = codec.ReadMessage(); err == nil {
+
+				codec.Close()
+
+				return response, nil
+			}
+		}
+
+		codec.Close()
+
+		if ctx.Err() != nil {
+
+			return nil, ctx.Err()
+		}
+	}
+
+	return nil, err
+}
+
+func isBatch(message json.RawMessage) bool {
+
+	message = bytes.TrimLeft(message, " \t\r\n")
+
+	return len(message) != 0 && message[0] == '['
+}
+
+// isNotification reports whether message is a request without an id, or
+// with a null one.
+func isNotification(message json.RawMessage) bool {
+
+	var request struct {
+		ID json.RawMessage `json:"id"`
+	}
+
+	if err := json.Unmarshal(message, &request); err != nil {
+
+		return false
+	}
+
+	return len(request.ID) == 0 || string(request.ID) == "null"
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testNotes struct {
+	notes chan string
+}
+
+func (n *testNotes) Add(params *NameParams) (interface{}, error) {
+
+	n.notes <- params.Name
+
+	return nil, nil
+}
+
+func TestGatewayBatch(t *testing.T) {
+
+	var (
+		mutex   = &sync.Mutex{}
+		bodies  = make(map[string][]string)
+		notes   = &testNotes{notes: make(chan string, 1)}
+		counted = func(name string, obj interface{}) *httptest.Server {
+
+			s := server.New()
+			s.RegisterObject(name, obj)
+
+			return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+				data, _ := ioutil.ReadAll(r.Body)
+
+				mutex.Lock()
+				bodies[name] = append(bodies[name], string(data))
+				mutex.Unlock()
+
+				r.Body = ioutil.NopCloser(strings.NewReader(string(data)))
+
+				s.ServeHTTP(w, r)
+			}))
+		}
+		catalog = counted("Catalog", &testCatalog{})
+		orders  = counted("Orders", &testOrders{})
+		notesTS = counted("Notes", notes)
+	)
+
+	defer catalog.Close()
+	defer orders.Close()
+	defer notesTS.Close()
+
+	ts, _ := testGateway(t, &config{
+		Routes: map[string]*route{
+			"Catalog": {URLs: []string{catalog.URL}},
+			"Orders":  {URLs: []string{orders.URL}},
+			"Notes":   {URLs: []string{notesTS.URL}},
+		},
+	})
+
+	defer ts.Close()
+
+	assert.JSONEq(t, `[
+		{"jsonrpc": "2.0", "id": 1, "result": "catalog:a"},
+		{"jsonrpc": "2.0", "id": 1, "result": "orders:b"},
+		{"jsonrpc": "2.0", "id": 3, "result": "catalog:c"},
+		{"jsonrpc": "2.0", "id": 4, "error": {"code": -32601, "message": "Method not found"}}
+	]`, testPost(t, ts.URL, `[
+		{"jsonrpc": "2.0", "id": 1, "method": "Catalog.Find", "params": {"name": "a"}},
+		{"jsonrpc": "2.0", "id": 1, "method": "Orders.Create", "params": {"name": "b"}},
+		{"jsonrpc": "2.0", "method": "Notes.Add", "params": {"name": "n"}},
+		{"jsonrpc": "2.0", "id": 3, "method": "Catalog.Find", "params": {"name": "c"}},
+		{"jsonrpc": "2.0", "id": 4, "method": "Unknown", "params": {}}
+	]`, ""))
+
+	assert.Equal(t, "n", <-notes.notes)
+
+	mutex.Lock()
+
+	if assert.Len(t, bodies["Catalog"], 1) {
+
+		var batch []json.RawMessage
+
+		if assert.NoError(t, json.Unmarshal([]byte(bodies["Catalog"][0]), &batch)) {
+
+			assert.Len(t, batch, 2)
+		}
+	}
+
+	assert.Len(t, bodies["Orders"], 1)
+
+	if assert.Len(t, bodies["Notes"], 1) {
+
+		var batch []map[string]json.RawMessage
+
+		if assert.NoError(t, json.Unmarshal([]byte(bodies["Notes"][0]), &batch)) && assert.Len(t, batch, 1) {
+
+			_, found := batch[0]["id"]
+
+			assert.False(t, found)
+		}
+	}
+
+	mutex.Unlock()
+
+	assert.Equal(t, "", testPost(t, ts.URL, `[{"jsonrpc": "2.0", "method": "Notes.Add", "params": {"name": "m"}}]`, ""))
+	assert.Equal(t, "m", <-notes.notes)
+}
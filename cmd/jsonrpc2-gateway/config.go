@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2/server"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+
+	if err != nil {
+
+		return err
+	}
+
+	*d = duration(v)
+
+	return nil
+}
+
+// route is an upstream pool. The URLs are either listed or read from a
+// discovery file, one per line, which is re-read every second.
+type route struct {
+	URLs      []string `json:"urls"`
+	Discovery string   `json:"discovery"`
+	Timeout   duration `json:"timeout"`
+}
+
+type authConfig struct {
+	Tokens  map[string]*server.Principal `json:"tokens"`
+	Require map[string][]string          `json:"require"`
+}
+
+type rate struct {
+	PerSecond float64 `json:"per_second"`
+	Burst     int     `json:"burst"`
+}
+
+type rateLimitConfig struct {
+	Identity string          `json:"identity"`
+	Rate     rate            `json:"rate"`
+	Methods  map[string]rate `json:"methods"`
+}
+
+type config struct {
+	Listen           string            `json:"listen"`
+	Timeout          duration          `json:"timeout"`
+	BatchConcurrency int               `json:"batch_concurrency"`
+	MaxConcurrency   int               `json:"max_concurrency"`
+	Routes           map[string]*route `json:"routes"`
+	Auth             *authConfig       `json:"auth"`
+	RateLimit        *rateLimitConfig  `json:"rate_limit"`
+	AccessLog        string            `json:"access_log"`
+}
+
+func loadConfig(path string) (*config, error) {
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	c := config{
+		Listen:           ":8080",
+		Timeout:          duration(10 * time.Second),
+		BatchConcurrency: 16,
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if len(c.Routes) == 0 {
+
+		return nil, fmt.Errorf("%s: no routes", path)
+	}
+
+	for pattern, r := range c.Routes {
+
+		if len(r.URLs) == 0 && r.Discovery == "" {
+
+			return nil, fmt.Errorf("%s: route %s has neither urls nor discovery", path, pattern)
+		}
+	}
+
+	return &c, nil
+}
+
+func (c *rateLimitConfig) identity() (server.Identity, error) {
+
+	switch {
+	case c.Identity == "" || c.Identity == "ip":
+
+		return server.IPIdentity, nil
+
+	case c.Identity == "jwt":
+
+		return server.JWTSubjectIdentity, nil
+
+	case c.Identity == "principal":
+
+		return principalIdentity, nil
+
+	case strings.HasPrefix(c.Identity, "header:"):
+
+		return server.HeaderIdentity(strings.TrimPrefix(c.Identity, "header:")), nil
+	}
+
+	return nil, fmt.Errorf("unknown rate limit identity %q, expected ip, jwt, principal or header:Name", c.Identity)
+}
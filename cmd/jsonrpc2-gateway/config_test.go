@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+
+	var (
+		dir      = t.TempDir()
+		file     = filepath.Join(dir, "gateway.json")
+		upstream = filepath.Join(dir, "orders")
+	)
+
+	if !assert.NoError(t, ioutil.WriteFile(upstream, []byte("# orders\nhttp://10.0.0.1\n\nhttp://10.0.0.2\n"), 0644)) {
+
+		return
+	}
+
+	if !assert.NoError(t, ioutil.WriteFile(file, []byte(`{
+		"routes": {
+			"Catalog": {"urls": ["http://10.0.0.3"], "timeout": "30s"},
+			"Orders": {"discovery": "`+upstream+`"}
+		},
+		"auth": {
+			"tokens": {"secret": {"name": "billing", "roles": ["admin"]}},
+			"require": {"Orders": ["admin"]}
+		},
+		"rate_limit": {"identity": "header:X-Api-Key", "rate": {"per_second": 10, "burst": 20}}
+	}`), 0644)) {
+
+		return
+	}
+
+	c, err := loadConfig(file)
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	assert.Equal(t, ":8080", c.Listen)
+	assert.Equal(t, duration(10*time.Second), c.Timeout)
+	assert.Equal(t, 16, c.BatchConcurrency)
+	assert.Equal(t, duration(30*time.Second), c.Routes["Catalog"].Timeout)
+	assert.Equal(t, "billing", c.Auth.Tokens["secret"].Name)
+	assert.Equal(t, []string{"admin"}, c.Auth.Tokens["secret"].Roles)
+	assert.Equal(t, rate{PerSecond: 10, Burst: 20}, c.RateLimit.Rate)
+
+	if addresses, err := jsonrpc2.FileDiscovery(c.Routes["Orders"].Discovery).Get(); assert.NoError(t, err) {
+
+		assert.Equal(t, []string{"http://10.0.0.1", "http://10.0.0.2"}, addresses)
+	}
+
+	for _, data := range []string{
+		`{}`,
+		`{"routes": {"Catalog": {}}}`,
+		`{"routes": {"Catalog": {"urls": ["http://a"]}}, "timeout": 10}`,
+	} {
+
+		if assert.NoError(t, ioutil.WriteFile(file, []byte(data), 0644)) {
+
+			_, err := loadConfig(file)
+
+			assert.Error(t, err, data)
+		}
+	}
+}
+
+func TestRateLimitIdentity(t *testing.T) {
+
+	for _, identity := range []string{"", "ip", "jwt", "principal", "header:X-Api-Key"} {
+
+		fn, err := (&rateLimitConfig{Identity: identity}).identity()
+
+		if assert.NoError(t, err, identity) {
+
+			assert.Equal(t, "", fn(context.Background(), &jsonrpc2.ServerRequest{}))
+		}
+	}
+
+	_, err := (&rateLimitConfig{Identity: "cookie"}).identity()
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type gateway interface {
+	http.Handler
+	Shutdown(ctx context.Context) error
+	ReadyHandler() http.Handler
+}
+
+// newGateway builds a server without handlers of its own: every call passes
+// the access log, authorization and rate limiting middlewares and is then
+// forwarded by the router. The calls of a batch routed to the same upstream
+// are forwarded as one batch and the responses merged in the order of the
+// batch.
+func newGateway(c *config, logWriter io.Writer) (gateway, error) {
+
+	router, err := newRouter(c.Routes, time.Duration(c.Timeout))
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	s := server.New()
+
+	if c.MaxConcurrency > 0 {
+
+		s.SetMaxConcurrency(c.MaxConcurrency)
+	}
+
+	var (
+		log         *accessLog
+		middlewares []server.Middleware
+	)
+
+	if logWriter != nil {
+
+		log = newAccessLog(logWriter, router)
+
+		middlewares = append(middlewares, log.Middleware)
+	}
+
+	if c.Auth != nil {
+
+		s.SetAuthenticator(tokenAuthenticator(c.Auth.Tokens, log))
+
+		authorizer := server.NewAuthorizer()
+
+		for pattern, rolesOrScopes := range c.Auth.Require {
+
+			authorizer.Require(pattern, rolesOrScopes...)
+		}
+
+		middlewares = append(middlewares, authorizer.Middleware)
+	}
+
+	if c.RateLimit != nil {
+
+		identity, err := c.RateLimit.identity()
+
+		if err != nil {
+
+			return nil, err
+		}
+
+		limiter := server.NewRateLimiter(identity, server.Rate(c.RateLimit.Rate))
+
+		for method, r := range c.RateLimit.Methods {
+
+			limiter.SetMethodRate(method, server.Rate(r))
+		}
+
+		middlewares = append(middlewares, limiter.Middleware)
+	}
+
+	s.Use(append(middlewares, router.Middleware)...)
+
+	return &batchGateway{messageServer: s, concurrency: c.BatchConcurrency}, nil
+}
+
+func tokenAuthenticator(tokens map[string]*server.Principal, log *accessLog) server.Authenticator {
+
+	return server.AuthenticatorFunc(func(r *http.Request) (*server.Principal, error) {
+
+		principal, err := server.BearerAuthenticator(func(token string) (*server.Principal, error) {
+
+			if principal, found := tokens[token]; found {
+
+				return principal, nil
+			}
+
+			return nil, fmt.Errorf("invalid token")
+
+		}).Authenticate(r)
+
+		if err != nil && log != nil {
+
+			log.authenticationFailed(r, err)
+		}
+
+		return principal, err
+	})
+}
+
+func principalIdentity(ctx context.Context, _ *jsonrpc2.ServerRequest) string {
+
+	if principal := server.PrincipalFromContext(ctx); principal != nil {
+
+		return principal.Name
+	}
+
+	return ""
+}
+
+type upstream struct {
+	name      string
+	discovery jsonrpc2.Discovery
+	dial      jsonrpc2.Dialer
+	next      *uint32
+}
+
+// router maps method names, RegisterObject namespaces or "*" to upstream
+// pools, each with its own discovery and balancer.
+type router struct {
+	upstreams map[string]*upstream
+}
+
+func newRouter(routes map[string]*route, timeout time.Duration) (*router, error) {
+
+	r := &router{
+		upstreams: make(map[string]*upstream, len(routes)),
+	}
+
+	for pattern, route := range routes {
+
+		var discovery jsonrpc2.Discovery = jsonrpc2.StaticDiscovery(route.URLs)
+
+		if route.Discovery != "" {
+
+			if _, err := jsonrpc2.FileDiscovery(route.Discovery).Get(); err != nil {
+
+				return nil, fmt.Errorf("route %s: %v", pattern, err)
+			}
+
+			discovery = jsonrpc2.FileDiscovery(route.Discovery)
+		}
+
+		t := timeout
+
+		if route.Timeout > 0 {
+
+			t = time.Duration(route.Timeout)
+		}
+
+		r.upstreams[pattern] = &upstream{
+			name:      pattern,
+			discovery: discovery,
+			dial:      jsonrpc2.HTTPDialer(&http.Client{Timeout: t}),
+			next:      new(uint32),
+		}
+	}
+
+	return r, nil
+}
+
+func (r *router) route(method string) (*upstream, bool) {
+
+	for name := method; ; {
+
+		if u, found := r.upstreams[name]; found {
+
+			return u, true
+		}
+
+		i := strings.LastIndex(name, ".")
+
+		if i == -1 {
+
+			break
+		}
+
+		name = name[:i]
+	}
+
+	u, found := r.upstreams["*"]
+
+	return u, found
+}
+
+// Middleware forwards the call to its upstream. Errors returned by the
+// upstream are passed on unchanged; an unreachable upstream is reported as a
+// server error. Methods without a route are left to the server, which
+// answers them with method not found. A call of a request handled by the
+// gateway waits for the other calls of the request, so that the calls of an
+// upstream are sent together; notifications are forwarded without an id and
+// not waited for.
+func (r *router) Middleware(next server.HandlerFunc) server.HandlerFunc {
+
+	return func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+		u, found := r.route(request.Method)
+
+		if !found {
+
+			return next(ctx, request)
+		}
+
+		entry, _ := ctx.Value(batchEntryKey{}).(*batchEntry)
+
+		call := &routedCall{
+			method:       request.Method,
+			params:       request.Params,
+			notification: entry != nil && entry.notification,
+			done:         make(chan struct{}),
+		}
+
+		if entry == nil {
+
+			u.send(ctx, []*routedCall{call})
+
+		} else {
+
+			entry.batch.settle(entry, u, call)
+		}
+
+		if call.notification {
+
+			return nil, nil
+		}
+
+		select {
+		case <-call.done:
+
+		case <-ctx.Done():
+
+			return nil, ctx.Err()
+		}
+
+		if call.err != nil {
+
+			return nil, call.err
+		}
+
+		if call.result == nil {
+
+			return json.RawMessage("null"), nil
+		}
+
+		return call.result, nil
+	}
+}
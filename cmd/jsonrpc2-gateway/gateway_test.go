@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type NameParams struct {
+	Name string `json:"name"`
+}
+
+func (p *NameParams) IsValid() bool {
+
+	return p.Name != ""
+}
+
+type testCatalog struct{}
+
+func (c *testCatalog) Find(params *NameParams) (string, error) {
+
+	time.Sleep(50 * time.Millisecond)
+
+	return "catalog:" + params.Name, nil
+}
+
+func (c *testCatalog) Fail(params *NameParams) (interface{}, error) {
+
+	return nil, errors.New("not found")
+}
+
+type testOrders struct{}
+
+func (o *testOrders) Create(params *NameParams) (string, error) {
+
+	time.Sleep(50 * time.Millisecond)
+
+	return "orders:" + params.Name, nil
+}
+
+func testUpstream(name string, obj interface{}) *httptest.Server {
+
+	s := server.New()
+	s.RegisterObject(name, obj)
+
+	return httptest.NewServer(s)
+}
+
+func testPost(t *testing.T, url, body, token string) string {
+
+	request, _ := http.NewRequest("POST", url, strings.NewReader(body))
+
+	if token != "" {
+
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+
+	if !assert.NoError(t, err) {
+
+		return ""
+	}
+
+	defer response.Body.Close()
+
+	data, _ := ioutil.ReadAll(response.Body)
+
+	return strings.TrimSpace(string(data))
+}
+
+func testGateway(t *testing.T, c *config) (*httptest.Server, *bytes.Buffer) {
+
+	var accessLog bytes.Buffer
+
+	if c.Timeout == 0 {
+
+		c.Timeout = duration(time.Second)
+	}
+
+	if c.BatchConcurrency == 0 {
+
+		c.BatchConcurrency = 16
+	}
+
+	g, err := newGateway(c, &accessLog)
+
+	if !assert.NoError(t, err) {
+
+		t.FailNow()
+	}
+
+	return httptest.NewServer(g), &accessLog
+}
+
+func TestGatewayRouting(t *testing.T) {
+
+	var (
+		catalog = testUpstream("Catalog", &testCatalog{})
+		orders  = testUpstream("Orders", &testOrders{})
+		down    = testUpstream("Down", &testOrders{})
+	)
+
+	defer catalog.Close()
+	defer orders.Close()
+
+	down.Close()
+
+	ts, accessLog := testGateway(t, &config{
+		Routes: map[string]*route{
+			"Catalog":       {URLs: []string{catalog.URL}},
+			"Orders.Create": {URLs: []string{orders.URL}},
+			"Down":          {URLs: []string{down.URL}},
+		},
+	})
+
+	defer ts.Close()
+
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "result": "catalog:x"}`, testPost(t, ts.URL, `{"jsonrpc": "2.0", "id": 1, "method": "Catalog.Find", "params": {"name": "x"}}`, ""))
+
+	start := time.Now()
+
+	assert.JSONEq(t, `[
+		{"jsonrpc": "2.0", "id": 1, "result": "catalog:a"},
+		{"jsonrpc": "2.0", "id": 2, "result": "orders:b"},
+		{"jsonrpc": "2.0", "id": 3, "result": "catalog:c"},
+		{"jsonrpc": "2.0", "id": 4, "error": {"code": -32601, "message": "Method not found"}},
+		{"jsonrpc": "2.0", "id": 5, "error": {"code": -32601, "message": "Method not found"}},
+		{"jsonrpc": "2.0", "id": 6, "result": "orders:d"},
+		{"jsonrpc": "2.0", "id": 7, "error": {"code": -32602, "message": "Invalid params"}},
+		{"jsonrpc": "2.0", "id": 8, "error": {"code": -32001, "message": "not found"}},
+		{"jsonrpc": "2.0", "id": 9, "error": {"code": -32000, "message": "Server error", "data": "upstream Down unavailable"}}
+	]`, testPost(t, ts.URL, `[
+		{"jsonrpc": "2.0", "id": 1, "method": "Catalog.Find", "params": {"name": "a"}},
+		{"jsonrpc": "2.0", "id": 2, "method": "Orders.Create", "params": {"name": "b"}},
+		{"jsonrpc": "2.0", "id": 3, "method": "Catalog.Find", "params": {"name": "c"}},
+		{"jsonrpc": "2.0", "id": 4, "method": "Orders.Delete", "params": {"name": "x"}},
+		{"jsonrpc": "2.0", "id": 5, "method": "Unknown", "params": {}},
+		{"jsonrpc": "2.0", "id": 6, "method": "Orders.Create", "params": {"name": "d"}},
+		{"jsonrpc": "2.0", "id": 7, "method": "Catalog.Find", "params": {}},
+		{"jsonrpc": "2.0", "id": 8, "method": "Catalog.Fail", "params": {"name": "x"}},
+		{"jsonrpc": "2.0", "id": 9, "method": "Down.Create", "params": {"name": "x"}}
+	]`, ""))
+
+	assert.True(t, time.Since(start) < 150*time.Millisecond, "the calls of a batch are forwarded concurrently")
+
+	log := accessLog.String()
+
+	assert.Equal(t, 10, strings.Count(log, "\n"))
+	assert.Contains(t, log, `method="Orders.Create" upstream=Orders.Create id=2 code=0`)
+	assert.Contains(t, log, `method="Unknown" upstream=- id=5 code=-32601`)
+	assert.Contains(t, log, `method="Down.Create" upstream=Down id=9 code=-32000`)
+	assert.Contains(t, log, "principal=- ")
+}
+
+func TestGatewayDefaultRoute(t *testing.T) {
+
+	catalog := testUpstream("Catalog", &testCatalog{})
+
+	defer catalog.Close()
+
+	ts, _ := testGateway(t, &config{
+		Routes: map[string]*route{
+			"*": {URLs: []string{catalog.URL}},
+		},
+	})
+
+	defer ts.Close()
+
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "result": "catalog:x"}`, testPost(t, ts.URL, `{"jsonrpc": "2.0", "id": 1, "method": "Catalog.Find", "params": {"name": "x"}}`, ""))
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 2, "error": {"code": -32601, "message": "Method not found"}}`, testPost(t, ts.URL, `{"jsonrpc": "2.0", "id": 2, "method": "Other.Find", "params": {}}`, ""))
+}
+
+func TestGatewayAuth(t *testing.T) {
+
+	var (
+		catalog = testUpstream("Catalog", &testCatalog{})
+		orders  = testUpstream("Orders", &testOrders{})
+	)
+
+	defer catalog.Close()
+	defer orders.Close()
+
+	ts, accessLog := testGateway(t, &config{
+		Routes: map[string]*route{
+			"Catalog": {URLs: []string{catalog.URL}},
+			"Orders":  {URLs: []string{orders.URL}},
+		},
+		Auth: &authConfig{
+			Tokens: map[string]*server.Principal{
+				"admin-token":  {Name: "admin", Roles: []string{"admin"}},
+				"reader-token": {Name: "reader"},
+			},
+			Require: map[string][]string{
+				"Orders": {"admin"},
+			},
+		},
+		RateLimit: &rateLimitConfig{
+			Identity: "principal",
+			Rate:     rate{PerSecond: 1, Burst: 1},
+		},
+	})
+
+	defer ts.Close()
+
+	const call = `{"jsonrpc": "2.0", "id": 1, "method": "Orders.Create", "params": {"name": "x"}}`
+
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "error": {"code": -32004, "message": "Unauthorized"}}`, testPost(t, ts.URL, call, ""))
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "error": {"code": -32004, "message": "Unauthorized", "data": "invalid token"}}`, testPost(t, ts.URL, call, "wrong"))
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "error": {"code": -32005, "message": "Forbidden"}}`, testPost(t, ts.URL, call, "reader-token"))
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "result": "orders:x"}`, testPost(t, ts.URL, call, "admin-token"))
+
+	var response jsonrpc2.Response
+
+	if assert.NoError(t, json.Unmarshal([]byte(testPost(t, ts.URL, call, "admin-token")), &response)) && assert.NotNil(t, response.Error) {
+
+		assert.Equal(t, int16(jsonrpc2.RateLimited), response.Error.Code)
+	}
+
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "result": "catalog:x"}`, testPost(t, ts.URL, `{"jsonrpc": "2.0", "id": 1, "method": "Catalog.Find", "params": {"name": "x"}}`, ""))
+
+	log := accessLog.String()
+
+	assert.Contains(t, log, `code=-32004 error="invalid token"`)
+	assert.Contains(t, log, `principal=admin method="Orders.Create" upstream=Orders id=1 code=0`)
+	assert.Contains(t, log, `principal=admin method="Orders.Create" upstream=Orders id=1 code=-32003`)
+}
+
+func TestRouter(t *testing.T) {
+
+	r, err := newRouter(map[string]*route{
+		"A":     {URLs: []string{"http://a"}},
+		"A.B":   {URLs: []string{"http://ab"}},
+		"A.B.C": {URLs: []string{"http://abc"}},
+	}, time.Second)
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	for method, expected := range map[string]string{
+		"A.Find":     "A",
+		"A.B.Find":   "A.B",
+		"A.B.C":      "A.B.C",
+		"A.B.C.Find": "A.B.C",
+		"AB.Find":    "",
+	} {
+
+		u, found := r.route(method)
+
+		if expected == "" {
+
+			assert.False(t, found, method)
+
+			continue
+		}
+
+		if assert.True(t, found, method) {
+
+			assert.Equal(t, expected, u.name, method)
+		}
+	}
+
+	_, err = newRouter(map[string]*route{
+		"A": {Discovery: "/nonexistent"},
+	}, time.Second)
+
+	assert.Error(t, err)
+}
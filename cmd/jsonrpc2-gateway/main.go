@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage:
+
+	jsonrpc2-gateway -config gateway.json [-listen addr]
+
+The gateway routes calls by method name, RegisterObject namespace or "*" to
+upstream pools:
+
+	{
+		"listen": ":8080",
+		"timeout": "10s",
+		"batch_concurrency": 16,
+		"max_concurrency": 0,
+		"routes": {
+			"Catalog": {"urls": ["http://10.0.0.1:8080", "http://10.0.0.2:8080"]},
+			"Orders.Create": {"discovery": "/etc/gateway/orders", "timeout": "30s"},
+			"*": {"urls": ["http://10.0.0.3:8080"]}
+		},
+		"auth": {
+			"tokens": {"secret": {"name": "billing", "roles": ["admin"]}},
+			"require": {"Orders": ["admin"], "*": []}
+		},
+		"rate_limit": {
+			"identity": "principal",
+			"rate": {"per_second": 100, "burst": 200},
+			"methods": {"Catalog.Find": {"per_second": 10, "burst": 10}}
+		},
+		"access_log": "stdout"
+	}
+
+The calls of a batch routed to the same upstream are forwarded to it as one
+batch, to batch_concurrency upstreams at a time, and the responses are merged
+in the order of the batch. Bearer tokens are checked at the edge, "require"
+lists the roles or scopes needed per method, namespace or "*" (an empty list
+requires any valid token). The rate limit identity is ip, jwt, principal or
+header:Name. The access log is stdout, stderr or a file. /healthz reports 503
+while the gateway shuts down on SIGINT or SIGTERM.
+
+Flags:
+
+`
+
+func main() {
+
+	var (
+		configFile = flag.String("config", "", "configuration file")
+		listen     = flag.String("listen", "", "address to listen on, overrides the configuration")
+	)
+
+	flag.Usage = func() {
+
+		fmt.Fprint(os.Stderr, usage)
+
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *configFile == "" {
+
+		flag.Usage()
+
+		os.Exit(2)
+	}
+
+	c, err := loadConfig(*configFile)
+
+	if err != nil {
+
+		log.Fatal(err)
+	}
+
+	if *listen != "" {
+
+		c.Listen = *listen
+	}
+
+	var accessLog io.Writer
+
+	switch c.AccessLog {
+	case "":
+
+	case "stdout":
+
+		accessLog = os.Stdout
+
+	case "stderr":
+
+		accessLog = os.Stderr
+
+	default:
+
+		file, err := os.OpenFile(c.AccessLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+
+		if err != nil {
+
+			log.Fatal(err)
+		}
+
+		defer file.Close()
+
+		accessLog = file
+	}
+
+	g, err := newGateway(c, accessLog)
+
+	if err != nil {
+
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", g)
+	mux.Handle("/healthz", g.ReadyHandler())
+
+	httpServer := &http.Server{
+		Addr:    c.Listen,
+		Handler: mux,
+	}
+
+	var (
+		done      = make(chan struct{})
+		ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	)
+
+	defer stop()
+
+	go func() {
+
+		defer close(done)
+
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeout))
+
+		defer cancel()
+
+		g.Shutdown(shutdownCtx)
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening on %s", c.Listen)
+
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+
+		log.Fatal(err)
+	}
+
+	<-done
+}
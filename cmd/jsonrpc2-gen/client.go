@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+var funcs = template.FuncMap{
+	"zero":     zeroValue,
+	"unexport": unexport,
+	"deref": func(typ string) string {
+
+		return strings.TrimPrefix(typ, "*")
+	},
+	"pointer": func(typ string) bool {
+
+		return strings.HasPrefix(typ, "*")
+	},
+	"quote": func(s string) string {
+
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	},
+}
+
+const typesTemplate = `{{define "types"}}{{range .Types}}
+{{if .Type}}type {{.Name}} {{.Type}}{{else}}type {{.Name}} struct {
+{{range .Fields}}	{{.Name}} {{.Type}} {{.Tag}}
+{{end}}}{{end}}
+{{if .Params}}
+func (p *{{.Name}}) IsValid() bool {
+
+	return true
+}
+{{end}}{{end}}{{end}}`
+
+var clientTemplate = template.Must(template.New("client").Funcs(funcs).Parse(typesTemplate + `// Code generated by jsonrpc2-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+{{range .ImportList}}	{{.}}
+{{end}})
+{{template "types" .}}
+{{if .Errors}}
+const (
+{{range .Errors}}	Err{{.Name}} int16 = {{.Code}}
+{{end}})
+{{range .Errors}}
+func Is{{.Name}}(err error) bool {
+
+	return isError(err, Err{{.Name}}, {{quote .Message}})
+}
+{{end}}
+func isError(err error, code int16, message string) bool {
+
+	switch e := err.(type) {
+	case *jsonrpc2.Error:
+
+		return e.Code == code
+
+	case *jsonrpc2.LogicError:
+
+		return code == jsonrpc2.LogicErr && e.Error() == message
+	}
+
+	return false
+}
+{{end}}
+func New{{.Name}}Client(rpc jsonrpc2.Client) *{{.Name}}Client {
+
+	return &{{.Name}}Client{
+		rpc: rpc,
+	}
+}
+
+type {{.Name}}Client struct {
+	rpc jsonrpc2.Client
+}
+{{range .Methods}}
+// {{.Name}} calls {{.RPC}}.{{if .Summary}}
+//
+// {{.Summary}}{{end}}
+func (c *{{$.Name}}Client) {{.Name}}(ctx context.Context{{if .Params}}, params {{.Params}}{{end}}) ({{.Result}}, error) {
+
+	var result {{deref .Result}}
+
+	if err := c.rpc.SendContext(ctx, {{quote .RPC}}, {{if .Params}}params{{else}}&jsonrpc2.EmptyParams{}{{end}}, &result); err != nil {
+
+		return {{zero .Result}}, err
+	}
+
+	return {{if pointer .Result}}&{{end}}result, nil
+}
+{{end}}`))
+
+func renderClient(svc *service) ([]byte, error) {
+
+	delete(svc.Imports, "context")
+	delete(svc.Imports, "github.com/kshvakov/jsonrpc2")
+
+	return render(clientTemplate, svc)
+}
+
+func render(t *template.Template, svc *service) ([]byte, error) {
+
+	var buf bytes.Buffer
+
+	if err := t.Execute(&buf, struct {
+		*service
+		ImportList []string
+	}{svc, svc.importList()}); err != nil {
+
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
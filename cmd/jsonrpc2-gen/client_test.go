@@ -0,0 +1,132 @@
+package main
+
+import (
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/cmd/jsonrpc2-gen/testdata/catalog"
+	"github.com/kshvakov/jsonrpc2/openrpc"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func testParse(t *testing.T, code []byte) bool {
+
+	_, err := parser.ParseFile(token.NewFileSet(), "client.go", code, 0)
+
+	return assert.NoError(t, err, string(code))
+}
+
+func TestClientFromSource(t *testing.T) {
+
+	svc, err := sourceService("testdata/catalog", "Catalog", "Catalog", "github.com/kshvakov/jsonrpc2/cmd/jsonrpc2-gen/testdata/catalog")
+
+	if !assert.NoError(t, err) || !assert.Len(t, svc.Methods, 3) {
+
+		return
+	}
+
+	svc.Package = "client"
+
+	code, err := renderClient(svc)
+
+	if assert.NoError(t, err) && testParse(t, code) {
+
+		for _, expected := range []string{
+			"package client",
+			`"github.com/kshvakov/jsonrpc2/cmd/jsonrpc2-gen/testdata/catalog"`,
+			"func NewCatalogClient(rpc jsonrpc2.Client) *CatalogClient {",
+			"// Find returns the items matching the name.",
+			"func (c *CatalogClient) Find(ctx context.Context, params *catalog.FindParams) (*catalog.FindResult, error) {",
+			`c.rpc.SendContext(ctx, "Catalog.Find", params, &result)`,
+			"func (c *CatalogClient) Count(ctx context.Context) (int, error) {",
+			"return time.Time{}, err",
+		} {
+
+			assert.Contains(t, string(code), expected)
+		}
+
+		assert.NotContains(t, string(code), "helper")
+		assert.NotContains(t, string(code), "Close")
+	}
+
+	if svc, err := sourceService("testdata/catalog", "Service", "Catalog", ""); assert.NoError(t, err) && assert.Len(t, svc.Methods, 2) {
+
+		assert.Equal(t, "*FindParams", svc.Methods[0].Params)
+		assert.Equal(t, "*FindResult", svc.Methods[0].Result)
+		assert.Equal(t, "Catalog.Count", svc.Methods[1].RPC)
+	}
+
+	_, err = sourceService("testdata/catalog", "Unknown", "Unknown", "")
+
+	assert.Error(t, err)
+}
+
+func testDocument() *openrpc.Document {
+
+	s := server.New()
+	s.RegisterObject("Catalog", &catalog.Catalog{})
+	s.Describe("Catalog.Find", server.MethodDoc{
+		Summary: "Find returns the items matching the name.",
+		Errors: []jsonrpc2.Error{
+			{Code: -32010, Message: "not found"},
+			{Code: jsonrpc2.LogicErr, Message: "invalid name"},
+		},
+	})
+
+	return s.Document(openrpc.Info{Title: "catalog", Version: "1.0.0"})
+}
+
+func TestClientFromOpenRPC(t *testing.T) {
+
+	svc := openRPCService(testDocument())
+
+	svc.Package = "client"
+
+	assert.Equal(t, "Catalog", svc.Name)
+
+	if assert.Len(t, svc.Errors, 2) {
+
+		assert.Equal(t, &serviceError{Name: "InvalidName", Code: jsonrpc2.LogicErr, Message: "invalid name"}, svc.Errors[0])
+		assert.Equal(t, &serviceError{Name: "NotFound", Code: -32010, Message: "not found"}, svc.Errors[1])
+	}
+
+	code, err := renderClient(svc)
+
+	if assert.NoError(t, err) && testParse(t, code) {
+
+		for _, expected := range []string{
+			"type FindParams struct {",
+			"Name  string   `json:\"name\"`",
+			"Tags  []string `json:\"tags,omitempty\"`",
+			"func (p *FindParams) IsValid() bool {",
+			"type FindResult struct {",
+			"Items []FindResultItemsItem `json:\"items\"`",
+			"Next  *FindResult           `json:\"next,omitempty\"`",
+			"Created time.Time `json:\"created\"`",
+			"ErrNotFound    int16 = -32010",
+			"func IsNotFound(err error) bool {",
+			"func (c *CatalogClient) Find(ctx context.Context, params *FindParams) (*FindResult, error) {",
+			"func (c *CatalogClient) Count(ctx context.Context) (int64, error) {",
+			"func (c *CatalogClient) Updated(ctx context.Context) (time.Time, error) {",
+		} {
+
+			assert.Contains(t, string(code), expected)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+
+	for name, expected := range map[string]string{
+		"user.get_by-id": "UserGetByID",
+		"Catalog":        "Catalog",
+		"not found":      "NotFound",
+		"2fa":            "X2fa",
+		"":               "X",
+	} {
+
+		assert.Equal(t, expected, goName(name))
+	}
+}
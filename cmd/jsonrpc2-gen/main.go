@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const usage = `Usage:
+
+	jsonrpc2-gen client (-openrpc file|url | -source dir -type name) -package name [-out file]
+	jsonrpc2-gen server -openrpc file|url -package name [-out dir]
+
+Commands:
+
+	client	generate a typed client from an OpenRPC document or a Go type
+	server	generate types, a service interface and its registration from an OpenRPC document
+`
+
+func main() {
+
+	if len(os.Args) < 2 {
+
+		fmt.Fprint(os.Stderr, usage)
+
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "client":
+
+		err = runClient(os.Args[2:])
+
+	case "server":
+
+		err = runServer(os.Args[2:])
+
+	default:
+
+		fmt.Fprint(os.Stderr, usage)
+
+		os.Exit(2)
+	}
+
+	if err != nil {
+
+		fmt.Fprintf(os.Stderr, "jsonrpc2-gen: %v\n", err)
+
+		os.Exit(1)
+	}
+}
+
+func runClient(args []string) error {
+
+	var (
+		flags      = flag.NewFlagSet("client", flag.ExitOnError)
+		spec       = flags.String("openrpc", "", "OpenRPC document file or URL of a server with rpc.discover enabled")
+		source     = flags.String("source", "", "directory of the Go package declaring the interface or the RegisterObject type")
+		typeName   = flags.String("type", "", "name of the interface or the RegisterObject type")
+		namespace  = flags.String("namespace", "", "name the type is registered with RegisterObject, defaults to -type")
+		importPath = flags.String("import", "", "import path of the -source package, if the client is generated into another package")
+		pkg        = flags.String("package", "", "package name of the generated code")
+		name       = flags.String("name", "", "client name, the generated type is <name>Client")
+		out        = flags.String("out", "", "output file, stdout by default")
+	)
+
+	flags.Parse(args)
+
+	if *pkg == "" {
+
+		return fmt.Errorf("-package is required")
+	}
+
+	var (
+		svc *service
+		err error
+	)
+
+	switch {
+	case *spec != "":
+
+		document, err := loadDocument(*spec)
+
+		if err != nil {
+
+			return err
+		}
+
+		svc = openRPCService(document)
+
+	case *source != "" && *typeName != "":
+
+		if *namespace == "" {
+
+			*namespace = *typeName
+		}
+
+		if svc, err = sourceService(*source, *typeName, *namespace, *importPath); err != nil {
+
+			return err
+		}
+
+	default:
+
+		return fmt.Errorf("either -openrpc or -source with -type is required")
+	}
+
+	svc.Package = *pkg
+
+	if *name != "" {
+
+		svc.Name = *name
+	}
+
+	code, err := renderClient(svc)
+
+	if err != nil {
+
+		return err
+	}
+
+	return write(*out, code)
+}
+
+func runServer(args []string) error {
+
+	var (
+		flags = flag.NewFlagSet("server", flag.ExitOnError)
+		spec  = flags.String("openrpc", "", "OpenRPC document file or URL of a server with rpc.discover enabled")
+		pkg   = flags.String("package", "", "package name of the generated code")
+		name  = flags.String("name", "", "service name, the generated interface is <name>Service")
+		out   = flags.String("out", ".", "output directory")
+	)
+
+	flags.Parse(args)
+
+	if *pkg == "" || *spec == "" {
+
+		return fmt.Errorf("-openrpc and -package are required")
+	}
+
+	document, err := loadDocument(*spec)
+
+	if err != nil {
+
+		return err
+	}
+
+	svc := openRPCService(document)
+	svc.Package = *pkg
+
+	if *name != "" {
+
+		svc.Name = *name
+	}
+
+	written, err := writeServer(svc, *out)
+
+	if err != nil {
+
+		return err
+	}
+
+	for _, file := range written {
+
+		fmt.Fprintln(os.Stderr, file)
+	}
+
+	return nil
+}
+
+func write(out string, code []byte) error {
+
+	if out == "" {
+
+		_, err := os.Stdout.Write(code)
+
+		return err
+	}
+
+	return ioutil.WriteFile(out, code, 0644)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/openrpc"
+	"github.com/kshvakov/jsonrpc2/schema"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+func loadDocument(spec string) (*openrpc.Document, error) {
+
+	var document openrpc.Document
+
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+
+		if err := jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{spec}, jsonrpc2.WithTimeout(10*time.Second)).Send("rpc.discover", &jsonrpc2.EmptyParams{}, &document); err != nil {
+
+			return nil, fmt.Errorf("rpc.discover: %v", err)
+		}
+
+		return &document, nil
+	}
+
+	data, err := ioutil.ReadFile(spec)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &document); err != nil {
+
+		return nil, fmt.Errorf("%s: %v", spec, err)
+	}
+
+	return &document, nil
+}
+
+func openRPCService(document *openrpc.Document) *service {
+
+	var (
+		svc = &service{
+			Name: goName(document.Info.Title),
+		}
+		namespace = commonNamespace(document.Methods)
+		types     = newTypeBuilder(svc)
+		errors    = make(map[int16]bool)
+	)
+
+	if namespace != "" {
+
+		svc.Name = goName(namespace)
+	}
+
+	for _, m := range document.Methods {
+
+		name := goName(strings.TrimPrefix(m.Name, namespace+"."))
+
+		if namespace == "" {
+
+			name = goName(m.Name)
+		}
+
+		method := &method{
+			Name:    name,
+			RPC:     m.Name,
+			Summary: m.Summary,
+			Result:  "interface{}",
+			Context: true,
+		}
+
+		if len(m.Params) != 0 {
+
+			decl := &typeDecl{
+				Name:   types.reserve(name + "Params"),
+				Params: true,
+			}
+
+			for _, param := range m.Params {
+
+				var definitions map[string]*schema.Schema
+
+				if param.Schema != nil {
+
+					definitions = param.Schema.Definitions
+				}
+
+				decl.Fields = append(decl.Fields, types.field(param.Name, param.Schema, param.Required, decl.Name, definitions))
+			}
+
+			svc.Types = append(svc.Types, decl)
+
+			method.Params = "*" + decl.Name
+		}
+
+		if m.Result != nil && m.Result.Schema != nil {
+
+			method.Result = types.goType(m.Result.Schema, name+"Result", m.Result.Schema.Definitions)
+
+			if types.isStruct(method.Result) {
+
+				method.Result = "*" + method.Result
+			}
+		}
+
+		for _, e := range m.Errors {
+
+			if errors[e.Code] {
+
+				continue
+			}
+
+			errors[e.Code] = true
+
+			svc.Errors = append(svc.Errors, &serviceError{
+				Code:    e.Code,
+				Message: e.Message,
+			})
+		}
+
+		svc.Methods = append(svc.Methods, method)
+	}
+
+	sort.Slice(svc.Errors, func(i, j int) bool {
+
+		return svc.Errors[i].Code > svc.Errors[j].Code
+	})
+
+	names := make(map[string]bool)
+
+	for _, e := range svc.Errors {
+
+		e.Name = goName(e.Message)
+
+		if e.Message == "" || names[e.Name] {
+
+			e.Name = fmt.Sprintf("Code%d", -int(e.Code))
+		}
+
+		names[e.Name] = true
+	}
+
+	return svc
+}
+
+func commonNamespace(methods []openrpc.Method) string {
+
+	var namespace string
+
+	for i, m := range methods {
+
+		dot := strings.LastIndex(m.Name, ".")
+
+		if dot == -1 || (i != 0 && m.Name[:dot] != namespace) {
+
+			return ""
+		}
+
+		namespace = m.Name[:dot]
+	}
+
+	return namespace
+}
+
+func newTypeBuilder(svc *service) *typeBuilder {
+
+	return &typeBuilder{
+		svc:     svc,
+		names:   make(map[string]bool),
+		structs: make(map[string]bool),
+		defined: make(map[*schema.Schema]string),
+	}
+}
+
+type typeBuilder struct {
+	svc     *service
+	names   map[string]bool
+	structs map[string]bool
+	defined map[*schema.Schema]string
+}
+
+func (b *typeBuilder) reserve(name string) string {
+
+	for i := 2; b.names[name]; i++ {
+
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+	}
+
+	b.names[name] = true
+
+	return name
+}
+
+func (b *typeBuilder) isStruct(typ string) bool {
+
+	return b.structs[typ]
+}
+
+func (b *typeBuilder) field(name string, s *schema.Schema, required bool, parent string, definitions map[string]*schema.Schema) *fieldDecl {
+
+	field := &fieldDecl{
+		Name: goName(name),
+		Type: b.goType(s, parent+goName(name), definitions),
+		Tag:  fmt.Sprintf("`json:\"%s\"`", name),
+	}
+
+	if !required {
+
+		field.Tag = fmt.Sprintf("`json:\"%s,omitempty\"`", name)
+	}
+
+	if b.isStruct(field.Type) {
+
+		field.Type = "*" + field.Type
+	}
+
+	return field
+}
+
+func (b *typeBuilder) goType(s *schema.Schema, hint string, definitions map[string]*schema.Schema) string {
+
+	if s == nil {
+
+		return "interface{}"
+	}
+
+	if s.Ref != "" {
+
+		name := strings.TrimPrefix(s.Ref, "#/$defs/")
+
+		definition, found := definitions[name]
+
+		if !found {
+
+			return "interface{}"
+		}
+
+		if typ, found := b.defined[definition]; found {
+
+			return typ
+		}
+
+		return b.named(definition, goName(name), definitions)
+	}
+
+	switch s.Type {
+	case "boolean":
+
+		return "bool"
+
+	case "integer":
+
+		return "int64"
+
+	case "number":
+
+		return "float64"
+
+	case "string":
+
+		switch s.Format {
+		case "date-time":
+
+			b.svc.addImport("time", "")
+
+			return "time.Time"
+
+		case "byte":
+
+			return "[]byte"
+		}
+
+		return "string"
+
+	case "array":
+
+		return "[]" + b.goType(s.Items, hint+"Item", definitions)
+
+	case "object":
+
+		if len(s.Properties) != 0 {
+
+			return b.named(s, hint, definitions)
+		}
+
+		if s.AdditionalProperties != nil {
+
+			return "map[string]" + b.goType(s.AdditionalProperties, hint+"Value", definitions)
+		}
+
+		return "map[string]interface{}"
+	}
+
+	return "interface{}"
+}
+
+func (b *typeBuilder) named(s *schema.Schema, name string, definitions map[string]*schema.Schema) string {
+
+	if s.Type != "object" || len(s.Properties) == 0 {
+
+		return b.goType(&schema.Schema{Type: s.Type, Format: s.Format, Items: s.Items, AdditionalProperties: s.AdditionalProperties}, name, definitions)
+	}
+
+	decl := &typeDecl{
+		Name: b.reserve(name),
+	}
+
+	b.defined[s] = decl.Name
+	b.structs[decl.Name] = true
+
+	b.svc.Types = append(b.svc.Types, decl)
+
+	required := make(map[string]bool, len(s.Required))
+
+	for _, name := range s.Required {
+
+		required[name] = true
+	}
+
+	properties := make([]string, 0, len(s.Properties))
+
+	for name := range s.Properties {
+
+		properties = append(properties, name)
+	}
+
+	sort.Strings(properties)
+
+	for _, property := range properties {
+
+		decl.Fields = append(decl.Fields, b.field(property, s.Properties[property], required[property], decl.Name, definitions))
+	}
+
+	return decl.Name
+}
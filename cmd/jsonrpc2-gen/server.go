@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var serverTemplate = template.Must(template.New("server").Funcs(funcs).Parse(typesTemplate + `// Code generated by jsonrpc2-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+{{range .ImportList}}	{{.}}
+{{end}})
+{{template "types" .}}
+{{if .Errors}}
+const (
+{{range .Errors}}	Err{{.Name}} int16 = {{.Code}}
+{{end}})
+{{range .Errors}}
+func {{.Name}}Error(data string) *jsonrpc2.Error {
+
+	return &jsonrpc2.Error{
+		Code:    Err{{.Name}},
+		Message: {{quote .Message}},
+		Data:    data,
+	}
+}
+{{end}}{{end}}
+type {{.Name}}Service interface {
+{{range .Methods}}	{{.Name}}(ctx context.Context{{if .Params}}, params {{.Params}}{{end}}) ({{.Result}}, error)
+{{end}}}
+
+type Registrar interface {
+	RegisterFunc(method string, fn interface{})
+}
+
+func Register{{.Name}}Service(s Registrar, impl {{.Name}}Service) {
+{{range .Methods}}{{if .Params}}
+	s.RegisterFunc({{quote .RPC}}, impl.{{.Name}})
+{{else}}
+	s.RegisterFunc({{quote .RPC}}, func(ctx context.Context, _ *jsonrpc2.EmptyParams) ({{.Result}}, error) {
+
+		return impl.{{.Name}}(ctx)
+	})
+{{end}}{{end}}}
+`))
+
+var implementationTemplate = template.Must(template.New("implementation").Funcs(funcs).Parse(`package {{.Package}}
+
+import (
+	"context"
+	"errors"
+{{range .ImportList}}	{{.}}
+{{end}})
+
+func New{{.Name}}Service() {{.Name}}Service {
+
+	return &{{unexport .Name}}Service{}
+}
+
+type {{unexport .Name}}Service struct{}
+{{range .Methods}}
+func (s *{{unexport $.Name}}Service) {{.Name}}(ctx context.Context{{if .Params}}, params {{.Params}}{{end}}) ({{.Result}}, error) {
+
+	return {{zero .Result}}, errors.New("{{.RPC}} is not implemented")
+}
+{{end}}`))
+
+func unexport(name string) string {
+
+	for i, r := range name {
+
+		if r < 'A' || r > 'Z' {
+
+			if i > 1 {
+
+				i--
+			}
+
+			return strings.ToLower(name[:i]) + name[i:]
+		}
+	}
+
+	return strings.ToLower(name)
+}
+
+// writeServer writes the generated types, the service interface and the
+// registration function to <name>_gen.go, and an implementation stub to
+// <name>.go unless that file already exists.
+func writeServer(svc *service, dir string) ([]string, error) {
+
+	delete(svc.Imports, "context")
+	delete(svc.Imports, "github.com/kshvakov/jsonrpc2")
+
+	var (
+		written []string
+		base    = filepath.Join(dir, strings.ToLower(svc.Name))
+	)
+
+	code, err := render(serverTemplate, svc)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	if err := write(base+"_gen.go", code); err != nil {
+
+		return nil, err
+	}
+
+	written = append(written, base+"_gen.go")
+
+	if _, err := os.Stat(base + ".go"); err == nil {
+
+		return written, nil
+	}
+
+	stub := *svc
+	stub.Imports = nil
+
+	for _, method := range svc.Methods {
+
+		if strings.HasPrefix(strings.TrimPrefix(method.Result, "[]"), "time.") {
+
+			stub.addImport("time", "")
+		}
+	}
+
+	if code, err = render(implementationTemplate, &stub); err != nil {
+
+		return nil, err
+	}
+
+	if err := write(base+".go", code); err != nil {
+
+		return nil, err
+	}
+
+	return append(written, base+".go"), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestServerFromOpenRPC(t *testing.T) {
+
+	dir := t.TempDir()
+
+	svc := openRPCService(testDocument())
+	svc.Package = "catalog"
+
+	written, err := writeServer(svc, dir)
+
+	if !assert.NoError(t, err) || !assert.Equal(t, []string{filepath.Join(dir, "catalog_gen.go"), filepath.Join(dir, "catalog.go")}, written) {
+
+		return
+	}
+
+	if code, err := ioutil.ReadFile(written[0]); assert.NoError(t, err) && testParse(t, code) {
+
+		for _, expected := range []string{
+			"// Code generated by jsonrpc2-gen. DO NOT EDIT.",
+			"package catalog",
+			"type FindParams struct {",
+			"ErrNotFound    int16 = -32010",
+			"func NotFoundError(data string) *jsonrpc2.Error {",
+			"type CatalogService interface {",
+			"Find(ctx context.Context, params *FindParams) (*FindResult, error)",
+			"Count(ctx context.Context) (int64, error)",
+			"func RegisterCatalogService(s Registrar, impl CatalogService) {",
+			`s.RegisterFunc("Catalog.Find", impl.Find)`,
+			`s.RegisterFunc("Catalog.Count", func(ctx context.Context, _ *jsonrpc2.EmptyParams) (int64, error) {`,
+		} {
+
+			assert.Contains(t, string(code), expected)
+		}
+	}
+
+	if code, err := ioutil.ReadFile(written[1]); assert.NoError(t, err) && testParse(t, code) {
+
+		for _, expected := range []string{
+			"func NewCatalogService() CatalogService {",
+			"func (s *catalogService) Find(ctx context.Context, params *FindParams) (*FindResult, error) {",
+			`return nil, errors.New("Catalog.Find is not implemented")`,
+			`return time.Time{}, errors.New("Catalog.Updated is not implemented")`,
+		} {
+
+			assert.Contains(t, string(code), expected)
+		}
+	}
+
+	if !assert.NoError(t, ioutil.WriteFile(written[1], []byte("package catalog\n"), 0644)) {
+
+		return
+	}
+
+	svc = openRPCService(testDocument())
+	svc.Package = "catalog"
+
+	if written, err := writeServer(svc, dir); assert.NoError(t, err) && assert.Len(t, written, 1) {
+
+		code, _ := ioutil.ReadFile(filepath.Join(dir, "catalog.go"))
+
+		assert.Equal(t, "package catalog\n", string(code))
+	}
+}
+
+func TestUnexport(t *testing.T) {
+
+	for name, expected := range map[string]string{
+		"Catalog":    "catalog",
+		"HTTPServer": "httpServer",
+		"RPC":        "rpc",
+		"ID":         "id",
+	} {
+
+		assert.Equal(t, expected, unexport(name))
+	}
+}
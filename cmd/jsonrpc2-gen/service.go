@@ -0,0 +1,145 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type service struct {
+	Package string
+	Name    string
+	Imports map[string]string
+	Methods []*method
+	Types   []*typeDecl
+	Errors  []*serviceError
+}
+
+func (s *service) addImport(path, name string) {
+
+	if s.Imports == nil {
+
+		s.Imports = make(map[string]string)
+	}
+
+	s.Imports[path] = name
+}
+
+func (s *service) importList() []string {
+
+	imports := make([]string, 0, len(s.Imports))
+
+	for path, name := range s.Imports {
+
+		if name != "" && !strings.HasSuffix(path, "/"+name) && path != name {
+
+			imports = append(imports, name+` "`+path+`"`)
+
+			continue
+		}
+
+		imports = append(imports, `"`+path+`"`)
+	}
+
+	sort.Slice(imports, func(i, j int) bool {
+
+		return strings.Trim(imports[i], `"`) < strings.Trim(imports[j], `"`)
+	})
+
+	return imports
+}
+
+type method struct {
+	Name    string
+	RPC     string
+	Summary string
+	Params  string
+	Result  string
+	Context bool
+}
+
+type typeDecl struct {
+	Name   string
+	Type   string
+	Fields []*fieldDecl
+	Params bool
+}
+
+type fieldDecl struct {
+	Name string
+	Type string
+	Tag  string
+}
+
+type serviceError struct {
+	Name    string
+	Code    int16
+	Message string
+}
+
+// goName converts an RPC or JSON name like "user.get_by-id" to "UserGetByID".
+func goName(name string) string {
+
+	var (
+		words = strings.FieldsFunc(name, func(r rune) bool {
+
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		result string
+	)
+
+	for _, word := range words {
+
+		if upper := strings.ToUpper(word); initialisms[upper] {
+
+			result += upper
+
+			continue
+		}
+
+		runes := []rune(word)
+
+		result += string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+
+		result = "X" + result
+	}
+
+	return result
+}
+
+var initialisms = map[string]bool{
+	"API":  true,
+	"HTTP": true,
+	"ID":   true,
+	"IP":   true,
+	"JSON": true,
+	"RPC":  true,
+	"URL":  true,
+	"UUID": true,
+}
+
+func zeroValue(typ string) string {
+
+	switch {
+	case strings.HasPrefix(typ, "*"), strings.HasPrefix(typ, "[]"), strings.HasPrefix(typ, "map["), typ == "interface{}", typ == "error":
+
+		return "nil"
+
+	case typ == "string":
+
+		return `""`
+
+	case typ == "bool":
+
+		return "false"
+
+	case strings.HasPrefix(typ, "int"), strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "float"), typ == "byte", typ == "rune":
+
+		return "0"
+	}
+
+	return typ + "{}"
+}
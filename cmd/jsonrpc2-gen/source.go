@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// sourceService reads the methods of an interface or of a type passed to
+// RegisterObject from the Go sources in dir. Only methods with a supported
+// handler signature are used.
+func sourceService(dir, typeName, namespace, importPath string) (*service, error) {
+
+	fset := token.NewFileSet()
+
+	packages, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	svc := &service{
+		Name: goName(namespace),
+	}
+
+	for _, pkg := range packages {
+
+		q := &qualifier{
+			svc:     svc,
+			fset:    fset,
+			local:   make(map[string]bool),
+			imports: make(map[string]string),
+		}
+
+		if importPath != "" {
+
+			q.pkg = pkg.Name
+
+			svc.addImport(importPath, pkg.Name)
+		}
+
+		var (
+			found   bool
+			methods []*ast.Field
+			docs    = make(map[*ast.Field]*ast.CommentGroup)
+		)
+
+		for _, file := range pkg.Files {
+
+			for _, decl := range file.Decls {
+
+				switch decl := decl.(type) {
+				case *ast.GenDecl:
+
+					for _, spec := range decl.Specs {
+
+						spec, ok := spec.(*ast.TypeSpec)
+
+						if !ok {
+
+							continue
+						}
+
+						q.local[spec.Name.Name] = true
+
+						if spec.Name.Name != typeName {
+
+							continue
+						}
+
+						found = true
+
+						if iface, ok := spec.Type.(*ast.InterfaceType); ok {
+
+							q.file(file)
+
+							for _, field := range iface.Methods.List {
+
+								if len(field.Names) != 0 {
+
+									methods = append(methods, field)
+									docs[field] = field.Doc
+								}
+							}
+						}
+					}
+
+				case *ast.FuncDecl:
+
+					if decl.Recv == nil || len(decl.Recv.List) != 1 || receiverName(decl.Recv.List[0].Type) != typeName {
+
+						continue
+					}
+
+					q.file(file)
+
+					field := &ast.Field{
+						Names: []*ast.Ident{decl.Name},
+						Type:  decl.Type,
+					}
+
+					methods = append(methods, field)
+					docs[field] = decl.Doc
+				}
+			}
+		}
+
+		if !found {
+
+			continue
+		}
+
+		for _, field := range methods {
+
+			method, ok := q.method(namespace, field.Names[0].Name, field.Type.(*ast.FuncType))
+
+			if !ok {
+
+				continue
+			}
+
+			if doc := docs[field]; doc != nil {
+
+				method.Summary = strings.TrimSpace(strings.SplitN(doc.Text(), "\n", 2)[0])
+			}
+
+			svc.Methods = append(svc.Methods, method)
+		}
+
+		return svc, nil
+	}
+
+	return nil, fmt.Errorf("type %s not found in %s", typeName, dir)
+}
+
+func receiverName(expr ast.Expr) string {
+
+	if star, ok := expr.(*ast.StarExpr); ok {
+
+		expr = star.X
+	}
+
+	if ident, ok := expr.(*ast.Ident); ok {
+
+		return ident.Name
+	}
+
+	return ""
+}
+
+type qualifier struct {
+	svc     *service
+	fset    *token.FileSet
+	pkg     string
+	local   map[string]bool
+	imports map[string]string
+}
+
+func (q *qualifier) file(file *ast.File) {
+
+	for _, spec := range file.Imports {
+
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+
+		name := path.Base(importPath)
+
+		if spec.Name != nil {
+
+			name = spec.Name.Name
+		}
+
+		q.imports[name] = importPath
+	}
+}
+
+func (q *qualifier) method(namespace, name string, fn *ast.FuncType) (*method, bool) {
+
+	if !ast.IsExported(name) || fn.Results == nil || fn.Params == nil {
+
+		return nil, false
+	}
+
+	var (
+		params  = fieldTypes(fn.Params)
+		results = fieldTypes(fn.Results)
+		context = len(params) == 2 && q.typeString(params[0]) == "context.Context"
+	)
+
+	if context {
+
+		params = params[1:]
+	}
+
+	if len(params) != 1 || len(results) != 2 || q.typeString(results[1]) != "error" {
+
+		return nil, false
+	}
+
+	method := &method{
+		Name:    name,
+		RPC:     namespace + "." + name,
+		Params:  q.typeString(params[0]),
+		Result:  q.typeString(results[0]),
+		Context: context,
+	}
+
+	if method.Params == "*jsonrpc2.EmptyParams" || method.Params == "jsonrpc2.EmptyParams" {
+
+		method.Params = ""
+	}
+
+	return method, true
+}
+
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+
+	var types []ast.Expr
+
+	for _, field := range fields.List {
+
+		for n := 0; n == 0 || n < len(field.Names); n++ {
+
+			types = append(types, field.Type)
+		}
+	}
+
+	return types
+}
+
+func (q *qualifier) typeString(expr ast.Expr) string {
+
+	switch expr := expr.(type) {
+	case *ast.Ident:
+
+		if q.pkg != "" && q.local[expr.Name] {
+
+			return q.pkg + "." + expr.Name
+		}
+
+		return expr.Name
+
+	case *ast.SelectorExpr:
+
+		if ident, ok := expr.X.(*ast.Ident); ok {
+
+			if importPath, found := q.imports[ident.Name]; found && ident.Name != "context" {
+
+				q.svc.addImport(importPath, ident.Name)
+			}
+		}
+
+	case *ast.StarExpr:
+
+		return "*" + q.typeString(expr.X)
+
+	case *ast.ArrayType:
+
+		if expr.Len == nil {
+
+			return "[]" + q.typeString(expr.Elt)
+		}
+
+	case *ast.MapType:
+
+		return "map[" + q.typeString(expr.Key) + "]" + q.typeString(expr.Value)
+	}
+
+	var buf bytes.Buffer
+
+	printer.Fprint(&buf, q.fset, expr)
+
+	return buf.String()
+}
@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"time"
+)
+
+type FindParams struct {
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags,omitempty"`
+	Limit int      `json:"limit"`
+}
+
+func (p *FindParams) IsValid() bool {
+
+	return p.Limit > 0
+}
+
+type Item struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type FindResult struct {
+	Items []Item      `json:"items"`
+	Next  *FindResult `json:"next,omitempty"`
+}
+
+type Catalog struct{}
+
+// Find returns the items matching the name.
+func (c *Catalog) Find(ctx context.Context, params *FindParams) (*FindResult, error) {
+
+	return &FindResult{}, nil
+}
+
+func (c *Catalog) Count(_ *jsonrpc2.EmptyParams) (int, error) {
+
+	return 0, nil
+}
+
+func (c *Catalog) Updated(_ *jsonrpc2.EmptyParams) (time.Time, error) {
+
+	return time.Now(), nil
+}
+
+func (c *Catalog) helper(_ *jsonrpc2.EmptyParams) (int, error) {
+
+	return 0, nil
+}
+
+func (c *Catalog) Close() error {
+
+	return nil
+}
+
+type Service interface {
+	// Find returns the items matching the name.
+	Find(ctx context.Context, params *FindParams) (*FindResult, error)
+	Count(*jsonrpc2.EmptyParams) (int, error)
+}
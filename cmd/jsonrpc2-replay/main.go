@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/record"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const usage = `Usage:
+
+	jsonrpc2-replay serve [-listen addr] recording.jsonl
+	jsonrpc2-replay diff -url url [-ignore paths] [-timeout d] recording.jsonl
+
+Commands:
+
+	serve	answer calls with the responses of the recording, as a fake upstream
+	diff	send the recorded calls to a server and print the responses that differ
+
+A recording has one call per line, as written by record.Recorder. The ignored
+paths are comma-separated: result.updated_at,result.items.*.id,error.data.
+diff exits with 1 if any response differs.
+`
+
+func main() {
+
+	if len(os.Args) < 2 {
+
+		fmt.Fprint(os.Stderr, usage)
+
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "serve":
+
+		err = serve(os.Args[2:])
+
+	case "diff":
+
+		var differ bool
+
+		if differ, err = diff(os.Args[2:]); err == nil && differ {
+
+			os.Exit(1)
+		}
+
+	default:
+
+		fmt.Fprint(os.Stderr, usage)
+
+		os.Exit(2)
+	}
+
+	if err != nil {
+
+		fmt.Fprintf(os.Stderr, "jsonrpc2-replay: %v\n", err)
+
+		os.Exit(1)
+	}
+}
+
+func serve(args []string) error {
+
+	var (
+		flags  = flag.NewFlagSet("serve", flag.ExitOnError)
+		listen = flags.String("listen", ":8080", "address to listen on")
+	)
+
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+
+		return fmt.Errorf("a recording is required")
+	}
+
+	entries, err := record.LoadFile(flags.Arg(0))
+
+	if err != nil {
+
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "serving %d recorded calls on %s\n", len(entries), *listen)
+
+	return http.ListenAndServe(*listen, record.NewReplayServer(entries))
+}
+
+func diff(args []string) (bool, error) {
+
+	var (
+		flags   = flag.NewFlagSet("diff", flag.ExitOnError)
+		url     = flags.String("url", "", "comma-separated URLs of the server under test")
+		ignore  = flags.String("ignore", "", "comma-separated paths left out of the comparison")
+		timeout = flags.Duration("timeout", 10*time.Second, "request timeout")
+	)
+
+	flags.Parse(args)
+
+	if *url == "" || flags.NArg() != 1 {
+
+		return false, fmt.Errorf("-url and a recording are required")
+	}
+
+	entries, err := record.LoadFile(flags.Arg(0))
+
+	if err != nil {
+
+		return false, err
+	}
+
+	var paths []string
+
+	if *ignore != "" {
+
+		paths = strings.Split(*ignore, ",")
+	}
+
+	client := jsonrpc2.NewClient(jsonrpc2.StaticDiscovery(strings.Split(*url, ",")), jsonrpc2.WithTimeout(*timeout))
+
+	differences, err := record.Compare(context.Background(), client, entries, paths...)
+
+	for _, d := range differences {
+
+		fmt.Println(d.String())
+	}
+
+	if err != nil {
+
+		return false, err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d of %d calls differ\n", len(differences), len(entries))
+
+	return len(differences) != 0, nil
+}
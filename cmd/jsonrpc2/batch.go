@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"io"
+	"strconv"
+	"sync"
+)
+
+type batchCall struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type batchResult struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *jsonrpc2.Error `json:"error,omitempty"`
+	code   int
+}
+
+// runBatch sends every line of in as a separate call, at most concurrency at
+// a time, and writes the results in input order. The exit code is the one of
+// the first failed call.
+func runBatch(client jsonrpc2.Client, in io.Reader, stdout, stderr io.Writer, concurrency int) int {
+
+	if concurrency < 1 {
+
+		concurrency = 1
+	}
+
+	var (
+		results []*batchResult
+		scanner = bufio.NewScanner(in)
+		slots   = make(chan struct{}, concurrency)
+		wg      = &sync.WaitGroup{}
+	)
+
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+
+		data := bytes.TrimSpace(scanner.Bytes())
+
+		if len(data) == 0 {
+
+			continue
+		}
+
+		var (
+			c      batchCall
+			result = &batchResult{
+				ID: json.RawMessage(strconv.Itoa(line)),
+			}
+		)
+
+		results = append(results, result)
+
+		if err := json.Unmarshal(data, &c); err != nil || c.Method == "" {
+
+			if err == nil {
+
+				err = fmt.Errorf("method is required")
+			}
+
+			result.Error = jsonrpc2.NewError(jsonrpc2.ParseError, fmt.Sprintf("line %d: %v", line, err))
+			result.code = exitInvalidParams
+
+			continue
+		}
+
+		if len(c.ID) != 0 {
+
+			result.ID = c.ID
+		}
+
+		slots <- struct{}{}
+
+		wg.Add(1)
+
+		go func(c batchCall, result *batchResult) {
+
+			defer func() {
+
+				<-slots
+
+				wg.Done()
+			}()
+
+			params := jsonrpc2.RawParams(c.Params)
+
+			if string(c.Params) == "null" {
+
+				params = nil
+			}
+
+			data, err := call(client, c.Method, params)
+
+			if err != nil {
+
+				result.Error = rpcError(err)
+				result.code = exitCode(err)
+
+				return
+			}
+
+			result.Result = data
+
+		}(c, result)
+	}
+
+	wg.Wait()
+
+	if err := scanner.Err(); err != nil {
+
+		fmt.Fprintf(stderr, "jsonrpc2: %v\n", err)
+
+		return exitFailure
+	}
+
+	var (
+		code    = exitOK
+		encoder = json.NewEncoder(stdout)
+	)
+
+	for _, result := range results {
+
+		if err := encoder.Encode(result); err != nil {
+
+			fmt.Fprintf(stderr, "jsonrpc2: %v\n", err)
+
+			return exitFailure
+		}
+
+		if code == exitOK {
+
+			code = result.code
+		}
+	}
+
+	return code
+}
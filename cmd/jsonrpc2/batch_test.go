@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunBatch(t *testing.T) {
+
+	ts := testServer()
+
+	defer ts.Close()
+
+	const calls = `{"id": "sum", "method": "Math.Sum", "params": {"a": 1, "b": 2}}
+
+{"method": "Math.Echo", "params": {"text": "hello"}}
+{"method": "Math.Unknown"}
+not json
+{"id": 5, "method": "Math.Fail", "params": null}
+`
+
+	if code, stdout, _ := testRun(calls, "-url", ts.URL, "-batch", "-", "-concurrency", "3"); assert.Equal(t, exitMethodNotFound, code) {
+
+		assert.Equal(t, `{"id":"sum","result":3}
+{"id":3,"result":"hello"}
+{"id":4,"error":{"code":-32601,"message":"Method not found"}}
+{"id":5,"error":{"code":-32700,"message":"Parse Error","data":"line 5: invalid character 'o' in literal null (expecting 'u')"}}
+{"id":5,"error":{"code":-32001,"message":"failed"}}
+`, stdout)
+	}
+
+	file := filepath.Join(t.TempDir(), "calls.jsonl")
+
+	if !assert.NoError(t, ioutil.WriteFile(file, []byte(`{"method": "Math.Sum", "params": {"a": 2, "b": 2}}`), 0644)) {
+
+		return
+	}
+
+	if code, stdout, _ := testRun("", "-url", ts.URL, "-batch", file); assert.Equal(t, exitOK, code) {
+
+		assert.Equal(t, "{\"id\":1,\"result\":4}\n", stdout)
+	}
+
+	code, _, _ := testRun("", "-url", ts.URL, "-batch", file+".missing")
+
+	assert.Equal(t, exitUsage, code)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/stdio"
+	"github.com/kshvakov/jsonrpc2/stream"
+	"github.com/kshvakov/jsonrpc2/websocket"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type headerTransport struct {
+	header http.Header
+	next   http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	request = request.Clone(request.Context())
+
+	for name, values := range t.header {
+
+		request.Header[name] = values
+	}
+
+	return t.next.RoundTrip(request)
+}
+
+// client returns the client of the servers. The HTTP servers are balanced,
+// a server of another transport is the only one. A client holding a
+// connection is an io.Closer.
+func (o *options) client() (jsonrpc2.Client, error) {
+
+	header := make(http.Header)
+
+	for _, h := range o.headers {
+
+		colon := strings.Index(h, ":")
+
+		if colon <= 0 {
+
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", h)
+		}
+
+		header.Add(strings.TrimSpace(h[:colon]), strings.TrimSpace(h[colon+1:]))
+	}
+
+	var discovery jsonrpc2.Discovery
+
+	switch {
+	case o.discovery != "":
+
+		if _, err := jsonrpc2.FileDiscovery(o.discovery).Get(); err != nil {
+
+			return nil, err
+		}
+
+		discovery = jsonrpc2.FileDiscovery(o.discovery)
+
+	default:
+
+		urls := o.urls
+
+		if len(urls) == 0 && os.Getenv("JSONRPC2_URL") != "" {
+
+			urls = stringList{os.Getenv("JSONRPC2_URL")}
+		}
+
+		var addresses jsonrpc2.StaticDiscovery
+
+		for _, url := range urls {
+
+			for _, address := range strings.Split(url, ",") {
+
+				if address = strings.TrimSpace(address); address != "" {
+
+					addresses = append(addresses, address)
+				}
+			}
+		}
+
+		if len(addresses) == 0 {
+
+			return nil, fmt.Errorf("-url or -discovery is required")
+		}
+
+		for _, address := range addresses {
+
+			if scheme(address) == "http" || scheme(address) == "https" {
+
+				continue
+			}
+
+			if len(addresses) != 1 {
+
+				return nil, fmt.Errorf("only HTTP servers are balanced, %q must be the only -url", address)
+			}
+
+			return dial(address, header, o.timeout)
+		}
+
+		discovery = addresses
+	}
+
+	return jsonrpc2.NewClient(discovery, jsonrpc2.WithHTTPClient(&http.Client{
+		Timeout: o.timeout,
+		Transport: &headerTransport{
+			header: header,
+			next:   http.DefaultTransport,
+		},
+	})), nil
+}
+
+// scheme returns the scheme of a URL in lower case.
+func scheme(url string) string {
+
+	if colon := strings.Index(url, ":"); colon > 0 {
+
+		return strings.ToLower(url[:colon])
+	}
+
+	return ""
+}
+
+// dial connects to a server that is not called over HTTP: ws:// and wss://
+// over a WebSocket, tcp:// and unix:// over a stream connection and exec: to
+// a command started with its arguments, over its stdin and stdout. Only the
+// WebSocket handshake carries the headers.
+func dial(url string, header http.Header, timeout time.Duration) (jsonrpc2.Client, error) {
+
+	switch scheme := scheme(url); scheme {
+	case "ws", "wss":
+
+		ctx := context.Background()
+
+		if timeout > 0 {
+
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+
+			defer cancel()
+		}
+
+		client, err := websocket.Dial(ctx, url, websocket.WithHeader(header))
+
+		if err != nil {
+
+			return nil, err
+		}
+
+		return client, nil
+
+	case "tcp", "unix":
+
+		address := strings.TrimPrefix(url[len(scheme)+1:], "//")
+
+		return stream.NewClient(scheme, address, stream.WithDialer(&net.Dialer{Timeout: timeout})), nil
+
+	case "exec":
+
+		args := strings.Fields(url[len(scheme)+1:])
+
+		if len(args) == 0 {
+
+			return nil, fmt.Errorf("%q has no command", url)
+		}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stderr = os.Stderr
+
+		client, err := stdio.Start(cmd)
+
+		if err != nil {
+
+			return nil, err
+		}
+
+		return client, nil
+	}
+
+	return nil, fmt.Errorf("unsupported URL %q", url)
+}
+
+func call(client jsonrpc2.Client, method string, params jsonrpc2.RawParams) (json.RawMessage, error) {
+
+	var result json.RawMessage
+
+	if err := client.SendContext(context.Background(), method, params, &result); err != nil {
+
+		return nil, err
+	}
+
+	if len(result) == 0 {
+
+		result = json.RawMessage("null")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/kshvakov/jsonrpc2/stream"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientHeaders(t *testing.T) {
+
+	var header http.Header
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		header = r.Header
+
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": true}`))
+	}))
+
+	defer ts.Close()
+
+	o := options{
+		urls:    stringList{ts.URL},
+		headers: stringList{"Authorization: Bearer token", "X-Request-Id:1"},
+	}
+
+	client, err := o.client()
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	if result, err := call(client, "Test", nil); assert.NoError(t, err) {
+
+		assert.Equal(t, "true", string(result))
+		assert.Equal(t, "Bearer token", header.Get("Authorization"))
+		assert.Equal(t, "1", header.Get("X-Request-Id"))
+	}
+
+	o.headers = stringList{"invalid"}
+
+	_, err = o.client()
+
+	assert.Error(t, err)
+
+	o = options{}
+
+	_, err = o.client()
+
+	assert.Error(t, err)
+}
+
+func TestClientStream(t *testing.T) {
+
+	s := server.New()
+	s.RegisterObject("Math", &testMath{})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	srv := stream.NewServer(s)
+
+	go srv.Serve(listener)
+
+	defer srv.Close()
+
+	url := "tcp://" + listener.Addr().String()
+
+	if code, stdout, _ := testRun("", "-url", url, "-output", "json", "Math.Sum", "a=1", "b=2"); assert.Equal(t, exitOK, code) {
+
+		assert.Equal(t, "3\n", stdout)
+	}
+
+	o := options{urls: stringList{url, "http://127.0.0.1:1"}}
+
+	_, err = o.client()
+
+	assert.Error(t, err)
+
+	o = options{urls: stringList{url}}
+
+	client, err := o.client()
+
+	if assert.NoError(t, err) {
+
+		_, ok := client.(*stream.Client)
+
+		assert.True(t, ok)
+		assert.NoError(t, client.(io.Closer).Close())
+	}
+
+	o = options{urls: stringList{"exec:"}}
+
+	_, err = o.client()
+
+	assert.Error(t, err)
+
+	code, _, _ := testRun("", "-url", "ws://127.0.0.1:1", "Math.Sum")
+
+	assert.Equal(t, exitUnavailable, code)
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/openrpc"
+	"github.com/kshvakov/jsonrpc2/schema"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const bashCompletion = `_jsonrpc2() {
+
+	COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_LINE:0:COMP_POINT}" 2>/dev/null))
+
+	if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == *= ]]; then
+
+		compopt -o nospace
+	fi
+}
+
+complete -o default -F _jsonrpc2 jsonrpc2
+`
+
+func discover(client jsonrpc2.Client) (*openrpc.Document, error) {
+
+	var document openrpc.Document
+
+	if err := client.SendContext(context.Background(), "rpc.discover", &jsonrpc2.EmptyParams{}, &document); err != nil {
+
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+func help(client jsonrpc2.Client, args []string, stdout, stderr io.Writer) int {
+
+	document, err := discover(client)
+
+	if err != nil {
+
+		printError(stderr, err)
+
+		return exitCode(err)
+	}
+
+	return printHelp(document, args, stdout, stderr)
+}
+
+func printHelp(document *openrpc.Document, args []string, stdout, stderr io.Writer) int {
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+
+	defer w.Flush()
+
+	if len(args) == 0 {
+
+		if document.Info.Title != "" {
+
+			fmt.Fprintf(w, "%s %s\n\n", document.Info.Title, document.Info.Version)
+		}
+
+		for _, m := range document.Methods {
+
+			summary := m.Summary
+
+			if m.Deprecated {
+
+				summary = strings.TrimSpace("(deprecated) " + summary)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\n", m.Name, summary)
+		}
+
+		return exitOK
+	}
+
+	m, found := document.Method(args[0])
+
+	if !found {
+
+		fmt.Fprintf(stderr, "jsonrpc2: method %s not found\n", args[0])
+
+		return exitMethodNotFound
+	}
+
+	fmt.Fprintln(w, m.Name)
+
+	if m.Deprecated {
+
+		fmt.Fprintln(w, "\nDeprecated.")
+	}
+
+	for _, text := range []string{m.Summary, m.Description} {
+
+		if text != "" {
+
+			fmt.Fprintf(w, "\n%s\n", text)
+		}
+	}
+
+	if len(m.Params) != 0 {
+
+		fmt.Fprintln(w, "\nParams:")
+
+		for _, param := range m.Params {
+
+			required := ""
+
+			if param.Required {
+
+				required = "required"
+			}
+
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", param.Name, typeName(param.Schema), required, param.Description)
+		}
+	}
+
+	if m.Result != nil {
+
+		fmt.Fprintf(w, "\nResult:\n  %s\n", typeName(m.Result.Schema))
+	}
+
+	if len(m.Errors) != 0 {
+
+		fmt.Fprintln(w, "\nErrors:")
+
+		for _, e := range m.Errors {
+
+			fmt.Fprintf(w, "  %d\t%s\n", e.Code, e.Message)
+		}
+	}
+
+	return exitOK
+}
+
+func typeName(s *schema.Schema) string {
+
+	switch {
+	case s == nil:
+
+		return "any"
+
+	case s.Ref != "":
+
+		return strings.TrimPrefix(s.Ref, "#/$defs/")
+
+	case s.Type == "array":
+
+		return "[]" + typeName(s.Items)
+
+	case s.Type == "object" && len(s.Properties) == 0 && s.AdditionalProperties != nil:
+
+		return "map[string]" + typeName(s.AdditionalProperties)
+
+	case s.Type == "":
+
+		return "any"
+
+	case s.Format != "":
+
+		return s.Type + " (" + s.Format + ")"
+	}
+
+	return s.Type
+}
+
+func completion(args []string, stdout, stderr io.Writer) int {
+
+	if len(args) != 1 || args[0] != "bash" {
+
+		fmt.Fprintln(stderr, "jsonrpc2: only bash completion is supported")
+
+		return exitUsage
+	}
+
+	fmt.Fprint(stdout, bashCompletion)
+
+	return exitOK
+}
+
+// complete prints the candidates for the last word of the command line up
+// to the cursor. Methods and their params are taken from rpc.discover.
+func complete(args []string, stdout io.Writer) int {
+
+	if len(args) != 1 {
+
+		return exitUsage
+	}
+
+	words := strings.Fields(args[0])
+
+	if len(words) == 0 {
+
+		return exitOK
+	}
+
+	words = words[1:]
+
+	if strings.HasSuffix(args[0], " ") || len(words) == 0 {
+
+		words = append(words, "")
+	}
+
+	var (
+		o          options
+		flags      = newFlagSet(&o, ioutil.Discard)
+		current    = words[len(words)-1]
+		candidates []string
+	)
+
+	if previous := words[:len(words)-1]; len(previous) != 0 && strings.HasPrefix(previous[len(previous)-1], "-") {
+
+		switch strings.TrimLeft(previous[len(previous)-1], "-") {
+		case "output":
+
+			candidates = []string{"pretty", "json", "raw"}
+
+		case "url", "discovery", "header", "timeout", "batch", "concurrency":
+
+			return exitOK
+		}
+	}
+
+	if candidates == nil {
+
+		if err := flags.Parse(words[:len(words)-1]); err != nil {
+
+			return exitOK
+		}
+
+		candidates = completeArgs(&o, flags, flags.Args(), current)
+	}
+
+	sort.Strings(candidates)
+
+	for _, candidate := range candidates {
+
+		if strings.HasPrefix(candidate, current) {
+
+			fmt.Fprintln(stdout, candidate)
+		}
+	}
+
+	return exitOK
+}
+
+func completeArgs(o *options, flags *flag.FlagSet, args []string, current string) []string {
+
+	var candidates []string
+
+	switch {
+	case len(args) == 0 && strings.HasPrefix(current, "-"):
+
+		flags.VisitAll(func(f *flag.Flag) {
+
+			candidates = append(candidates, "-"+f.Name)
+		})
+
+	default:
+
+		client, err := o.client()
+
+		if err != nil {
+
+			return nil
+		}
+
+		if closer, ok := client.(io.Closer); ok {
+
+			defer closer.Close()
+		}
+
+		document, err := discover(client)
+
+		if err != nil {
+
+			return nil
+		}
+
+		switch {
+		case len(args) == 0:
+
+			candidates = append(candidates, "help", "repl")
+
+			fallthrough
+
+		case len(args) == 1 && args[0] == "help":
+
+			for _, m := range document.Methods {
+
+				candidates = append(candidates, m.Name)
+			}
+
+		default:
+
+			m, found := document.Method(args[0])
+
+			if !found {
+
+				return nil
+			}
+
+			given := make(map[string]bool)
+
+			for _, arg := range args[1:] {
+
+				given[strings.SplitN(arg, "=", 2)[0]] = true
+			}
+
+			for _, param := range m.Params {
+
+				if !given[param.Name] {
+
+					candidates = append(candidates, param.Name+"=")
+				}
+			}
+		}
+	}
+
+	return candidates
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"github.com/kshvakov/jsonrpc2/schema"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestHelp(t *testing.T) {
+
+	ts := testServer()
+
+	defer ts.Close()
+
+	if code, stdout, _ := testRun("", "-url", ts.URL, "help"); assert.Equal(t, exitOK, code) {
+
+		assert.Contains(t, stdout, "math 1.0.0")
+		assert.Contains(t, stdout, "Math.Sum   Sum adds two numbers.")
+		assert.Contains(t, stdout, "Math.Echo")
+	}
+
+	if code, stdout, _ := testRun("", "-url", ts.URL, "help", "Math.Sum"); assert.Equal(t, exitOK, code) {
+
+		assert.Contains(t, stdout, "Sum adds two numbers.")
+		assert.Contains(t, stdout, "Params:\n  a  integer  required")
+		assert.Contains(t, stdout, "Result:\n  integer")
+	}
+
+	code, _, _ := testRun("", "-url", ts.URL, "help", "Math.Unknown")
+
+	assert.Equal(t, exitMethodNotFound, code)
+}
+
+func TestTypeName(t *testing.T) {
+
+	for expected, s := range map[string]*schema.Schema{
+		"any":                nil,
+		"Item":               {Ref: "#/$defs/Item"},
+		"[]string":           {Type: "array", Items: &schema.Schema{Type: "string"}},
+		"map[string]integer": {Type: "object", AdditionalProperties: &schema.Schema{Type: "integer"}},
+		"object":             {Type: "object", Properties: map[string]*schema.Schema{"a": {Type: "string"}}},
+		"string (date-time)": {Type: "string", Format: "date-time"},
+	} {
+
+		assert.Equal(t, expected, typeName(s))
+	}
+}
+
+func TestComplete(t *testing.T) {
+
+	ts := testServer()
+
+	defer ts.Close()
+
+	for line, expected := range map[string][]string{
+		"jsonrpc2 -url " + ts.URL + " ":              {"Math.Echo", "Math.Fail", "Math.Sum", "help", "repl"},
+		"jsonrpc2 -url " + ts.URL + " Math.S":        {"Math.Sum"},
+		"jsonrpc2 -url " + ts.URL + " help M":        {"Math.Echo", "Math.Fail", "Math.Sum"},
+		"jsonrpc2 -url " + ts.URL + " Math.Sum ":     {"a=", "b="},
+		"jsonrpc2 -url " + ts.URL + " Math.Sum a=1 ": {"b="},
+		"jsonrpc2 -url " + ts.URL + " Math.Unknown ": nil,
+		"jsonrpc2 -url " + ts.URL + " -output ":      {"json", "pretty", "raw"},
+		"jsonrpc2 -url " + ts.URL + " -output r":     {"raw"},
+		"jsonrpc2 -url " + ts.URL + " -disc":         {"-discovery"},
+		"jsonrpc2 -url ":                             nil,
+		"jsonrpc2 ":                                  nil,
+	} {
+
+		code, stdout, _ := testRun("", "__complete", line)
+
+		if assert.Equal(t, exitOK, code) {
+
+			assert.Equal(t, strings.Join(expected, "\n"), strings.TrimSpace(stdout), line)
+		}
+	}
+
+	if code, stdout, _ := testRun("", "completion", "bash"); assert.Equal(t, exitOK, code) {
+
+		assert.Contains(t, stdout, "complete -o default -F _jsonrpc2 jsonrpc2")
+	}
+
+	code, _, _ := testRun("", "completion", "zsh")
+
+	assert.Equal(t, exitUsage, code)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+const usage = `Usage:
+
+	jsonrpc2 [flags] method [params]
+	jsonrpc2 [flags] -batch file
+	jsonrpc2 [flags] help [method]
+	jsonrpc2 [flags] repl
+	jsonrpc2 completion bash
+
+Params are either a JSON object or array, "-" to read them from stdin, or
+key=value pairs. Keys may be nested with dots (filter.name=x), values that are
+valid JSON are sent as is (limit=10, tags=["a","b"]) and all others as strings.
+
+HTTP servers are balanced. A single server may also be called over a
+WebSocket (ws:// or wss://), a stream connection (tcp://host:port or
+unix:///path) or the stdin and stdout of a command (exec:command args).
+
+The repl command starts an interactive shell with completion, history and
+variables holding earlier results, see :help inside it.
+
+A batch file holds one call per line: {"id": 1, "method": "...", "params": {...}}.
+The results are written in the same order, one JSON object per line.
+
+Exit codes:
+
+	0	success
+	1	other errors
+	2	usage errors
+	3	no live upstreams or transport errors
+	4	method not found
+	5	invalid params, invalid request or parse error
+	6	unauthorized or forbidden
+	7	overloaded or rate limited
+	8	internal or server error
+	9	application errors
+
+Flags:
+
+`
+
+type stringList []string
+
+func (l *stringList) String() string {
+
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+
+	*l = append(*l, value)
+
+	return nil
+}
+
+type options struct {
+	urls        stringList
+	discovery   string
+	headers     stringList
+	timeout     time.Duration
+	output      string
+	batch       string
+	concurrency int
+}
+
+func newFlagSet(o *options, stderr io.Writer) *flag.FlagSet {
+
+	flags := flag.NewFlagSet("jsonrpc2", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&o.urls, "url", "server URL, may be repeated or comma-separated (default $JSONRPC2_URL)")
+	flags.StringVar(&o.discovery, "discovery", "", "file with server URLs, one per line, re-read every second")
+	flags.Var(&o.headers, "header", `HTTP header "Name: value" added to every request, may be repeated`)
+	flags.DurationVar(&o.timeout, "timeout", 10*time.Second, "request timeout")
+	flags.StringVar(&o.output, "output", "pretty", "result format: pretty, json or raw (strings without quotes)")
+	flags.StringVar(&o.batch, "batch", "", `JSONL file with calls, "-" for stdin`)
+	flags.IntVar(&o.concurrency, "concurrency", 1, "number of batch calls in flight")
+	flags.Usage = func() {
+
+		fmt.Fprint(stderr, usage)
+
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+func main() {
+
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+
+	var (
+		o     options
+		flags = newFlagSet(&o, stderr)
+	)
+
+	if err := flags.Parse(args); err != nil {
+
+		if err == flag.ErrHelp {
+
+			return exitOK
+		}
+
+		return exitUsage
+	}
+
+	args = flags.Args()
+
+	if len(args) != 0 {
+
+		switch args[0] {
+		case "completion":
+
+			return completion(args[1:], stdout, stderr)
+
+		case "__complete":
+
+			return complete(args[1:], stdout)
+		}
+	}
+
+	if o.batch == "" && len(args) == 0 {
+
+		flags.Usage()
+
+		return exitUsage
+	}
+
+	switch o.output {
+	case "pretty", "json", "raw":
+	default:
+
+		fmt.Fprintf(stderr, "jsonrpc2: unknown output format %q\n", o.output)
+
+		return exitUsage
+	}
+
+	client, err := o.client()
+
+	if err != nil {
+
+		fmt.Fprintf(stderr, "jsonrpc2: %v\n", err)
+
+		if code := exitCode(err); code == exitUnavailable {
+
+			return code
+		}
+
+		return exitUsage
+	}
+
+	if closer, ok := client.(io.Closer); ok {
+
+		defer closer.Close()
+	}
+
+	if o.batch != "" {
+
+		in := stdin
+
+		if o.batch != "-" {
+
+			file, err := os.Open(o.batch)
+
+			if err != nil {
+
+				fmt.Fprintf(stderr, "jsonrpc2: %v\n", err)
+
+				return exitUsage
+			}
+
+			defer file.Close()
+
+			in = file
+		}
+
+		return runBatch(client, in, stdout, stderr, o.concurrency)
+	}
+
+	switch args[0] {
+	case "help":
+
+		return help(client, args[1:], stdout, stderr)
+
+	case "repl":
+
+		return runREPL(client, o.output, stdout, stderr)
+	}
+
+	if len(args) == 2 && args[1] == "-" {
+
+		data, err := ioutil.ReadAll(stdin)
+
+		if err != nil {
+
+			fmt.Fprintf(stderr, "jsonrpc2: %v\n", err)
+
+			return exitFailure
+		}
+
+		args[1] = string(data)
+	}
+
+	params, err := parseParams(args[1:])
+
+	if err != nil {
+
+		fmt.Fprintf(stderr, "jsonrpc2: %v\n", err)
+
+		return exitUsage
+	}
+
+	result, err := call(client, args[0], params)
+
+	if err != nil {
+
+		printError(stderr, err)
+
+		return exitCode(err)
+	}
+
+	if err := format(stdout, result, o.output); err != nil {
+
+		fmt.Fprintf(stderr, "jsonrpc2: %v\n", err)
+
+		return exitFailure
+	}
+
+	return exitOK
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/openrpc"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type SumParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (p *SumParams) IsValid() bool {
+
+	return true
+}
+
+type EchoParams struct {
+	Text string `json:"text"`
+}
+
+func (p *EchoParams) IsValid() bool {
+
+	return true
+}
+
+type testMath struct{}
+
+func (m *testMath) Sum(params *SumParams) (int, error) {
+
+	return params.A + params.B, nil
+}
+
+func (m *testMath) Echo(params *EchoParams) (string, error) {
+
+	return params.Text, nil
+}
+
+func (m *testMath) Fail(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+	return nil, errors.New("failed")
+}
+
+func testServer() *httptest.Server {
+
+	s := server.New()
+	s.RegisterObject("Math", &testMath{})
+	s.Describe("Math.Sum", server.MethodDoc{
+		Summary: "Sum adds two numbers.",
+	})
+	s.EnableDiscover(openrpc.Info{Title: "math", Version: "1.0.0"})
+
+	return httptest.NewServer(s)
+}
+
+func testRun(stdin string, args ...string) (int, string, string) {
+
+	var stdout, stderr bytes.Buffer
+
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+
+	ts := testServer()
+
+	defer ts.Close()
+
+	if code, stdout, _ := testRun("", "-url", ts.URL, "-output", "json", "Math.Sum", "a=1", "b=2"); assert.Equal(t, exitOK, code) {
+
+		assert.Equal(t, "3\n", stdout)
+	}
+
+	if code, stdout, _ := testRun("", "-url", ts.URL, "Math.Sum", `{"a": 2, "b": 3}`); assert.Equal(t, exitOK, code) {
+
+		assert.Equal(t, "5\n", stdout)
+	}
+
+	if code, stdout, _ := testRun(`{"a": 4, "b": 4}`, "-url", ts.URL, "Math.Sum", "-"); assert.Equal(t, exitOK, code) {
+
+		assert.Equal(t, "8\n", stdout)
+	}
+
+	if code, stdout, _ := testRun("", "-url", ts.URL, "-output", "raw", "Math.Echo", "text=hello"); assert.Equal(t, exitOK, code) {
+
+		assert.Equal(t, "hello\n", stdout)
+	}
+
+	if code, _, stderr := testRun("", "-url", ts.URL, "Math.Unknown"); assert.Equal(t, exitMethodNotFound, code) {
+
+		assert.Equal(t, "jsonrpc2: error -32601: Method not found\n", stderr)
+	}
+
+	if code, _, stderr := testRun("", "-url", ts.URL, "Math.Fail"); assert.Equal(t, exitLogicError, code) {
+
+		assert.Equal(t, "jsonrpc2: error -32001: failed\n", stderr)
+	}
+
+	code, _, _ := testRun("", "-url", ts.URL, "Math.Sum", "a")
+
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = testRun("", "Math.Sum")
+
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = testRun("", "-url", ts.URL, "-output", "yaml", "Math.Sum")
+
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = testRun("", "-url", ts.URL)
+
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestRunUnavailable(t *testing.T) {
+
+	ts := testServer()
+	ts.Close()
+
+	code, _, _ := testRun("", "-url", ts.URL, "Math.Sum")
+
+	assert.Equal(t, exitUnavailable, code)
+}
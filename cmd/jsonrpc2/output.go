@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"io"
+	"net"
+	"net/url"
+)
+
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+	exitUnavailable
+	exitMethodNotFound
+	exitInvalidParams
+	exitUnauthorized
+	exitOverloaded
+	exitServerError
+	exitLogicError
+)
+
+func exitCode(err error) int {
+
+	if err == jsonrpc2.ErrClosed {
+
+		return exitUnavailable
+	}
+
+	switch e := err.(type) {
+	case nil:
+
+		return exitOK
+
+	case *jsonrpc2.ErrorNoLiveUpstreams, *url.Error, net.Error:
+
+		return exitUnavailable
+
+	case *jsonrpc2.LogicError:
+
+		return exitLogicError
+
+	case *jsonrpc2.Error:
+
+		switch e.Code {
+		case jsonrpc2.MethodNotFound:
+
+			return exitMethodNotFound
+
+		case jsonrpc2.ParseError, jsonrpc2.InvalidRequest, jsonrpc2.InvalidParams:
+
+			return exitInvalidParams
+
+		case jsonrpc2.Unauthorized, jsonrpc2.Forbidden:
+
+			return exitUnauthorized
+
+		case jsonrpc2.Overloaded, jsonrpc2.RateLimited:
+
+			return exitOverloaded
+
+		case jsonrpc2.InternalError, jsonrpc2.ServerError:
+
+			return exitServerError
+		}
+
+		return exitLogicError
+	}
+
+	return exitFailure
+}
+
+// rpcError converts an error returned by the client into the error object
+// of a response. Errors which did not come from the server have code 0.
+func rpcError(err error) *jsonrpc2.Error {
+
+	switch e := err.(type) {
+	case *jsonrpc2.Error:
+
+		return e
+
+	case *jsonrpc2.LogicError:
+
+		return &jsonrpc2.Error{
+			Code:    jsonrpc2.LogicErr,
+			Message: e.Error(),
+		}
+	}
+
+	return &jsonrpc2.Error{
+		Message: err.Error(),
+	}
+}
+
+func printError(w io.Writer, err error) {
+
+	e := rpcError(err)
+
+	if e.Code == 0 {
+
+		fmt.Fprintf(w, "jsonrpc2: %s\n", e.Message)
+
+		return
+	}
+
+	if e.Data != "" {
+
+		fmt.Fprintf(w, "jsonrpc2: error %d: %s: %s\n", e.Code, e.Message, e.Data)
+
+		return
+	}
+
+	fmt.Fprintf(w, "jsonrpc2: error %d: %s\n", e.Code, e.Message)
+}
+
+func format(w io.Writer, result json.RawMessage, output string) error {
+
+	var buf bytes.Buffer
+
+	switch output {
+	case "raw":
+
+		var s string
+
+		if err := json.Unmarshal(result, &s); err == nil {
+
+			buf.WriteString(s)
+
+			break
+		}
+
+		if err := json.Compact(&buf, result); err != nil {
+
+			return err
+		}
+
+	case "json":
+
+		if err := json.Compact(&buf, result); err != nil {
+
+			return err
+		}
+
+	default:
+
+		if err := json.Indent(&buf, result, "", "  "); err != nil {
+
+			return err
+		}
+	}
+
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+
+	for code, errs := range map[int][]error{
+		exitOK:             {nil},
+		exitFailure:        {errors.New("unexpected EOF")},
+		exitUnavailable:    {&jsonrpc2.ErrorNoLiveUpstreams{}, &url.Error{Op: "Post", Err: errors.New("refused")}, &net.OpError{Op: "dial", Err: errors.New("refused")}, jsonrpc2.ErrClosed},
+		exitMethodNotFound: {jsonrpc2.NewError(jsonrpc2.MethodNotFound, "")},
+		exitInvalidParams:  {jsonrpc2.NewError(jsonrpc2.InvalidParams, ""), jsonrpc2.NewError(jsonrpc2.ParseError, "")},
+		exitUnauthorized:   {jsonrpc2.NewError(jsonrpc2.Unauthorized, ""), jsonrpc2.NewError(jsonrpc2.Forbidden, "")},
+		exitOverloaded:     {jsonrpc2.NewError(jsonrpc2.RateLimited, ""), jsonrpc2.NewError(jsonrpc2.Overloaded, "")},
+		exitServerError:    {jsonrpc2.NewError(jsonrpc2.InternalError, "")},
+		exitLogicError:     {&jsonrpc2.Error{Code: -32010}},
+	} {
+
+		for _, err := range errs {
+
+			assert.Equal(t, code, exitCode(err), err)
+		}
+	}
+}
+
+func TestPrintError(t *testing.T) {
+
+	for expected, err := range map[string]error{
+		"jsonrpc2: error -32602: Invalid params: a is required\n": jsonrpc2.NewError(jsonrpc2.InvalidParams, "a is required"),
+		"jsonrpc2: no live upstreams\n":                           &jsonrpc2.ErrorNoLiveUpstreams{},
+	} {
+
+		var buf bytes.Buffer
+
+		printError(&buf, err)
+
+		assert.Equal(t, expected, buf.String())
+	}
+}
+
+func TestFormat(t *testing.T) {
+
+	for _, test := range []struct {
+		output, result, expected string
+	}{
+		{"pretty", `{"a":[1]}`, "{\n  \"a\": [\n    1\n  ]\n}\n"},
+		{"json", `{ "a": [1] }`, "{\"a\":[1]}\n"},
+		{"raw", `"line\n"`, "line\n\n"},
+		{"raw", `{ "a": 1 }`, "{\"a\":1}\n"},
+	} {
+
+		var buf bytes.Buffer
+
+		if assert.NoError(t, format(&buf, json.RawMessage(test.result), test.output)) {
+
+			assert.Equal(t, test.expected, buf.String())
+		}
+	}
+}
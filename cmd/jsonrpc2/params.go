@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"strings"
+)
+
+// parseParams accepts a single JSON object or array, or key=value pairs
+// which are collected into an object.
+func parseParams(args []string) (jsonrpc2.RawParams, error) {
+
+	if len(args) == 0 {
+
+		return nil, nil
+	}
+
+	if trimmed := bytes.TrimSpace([]byte(args[0])); len(args) == 1 && len(trimmed) != 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+
+		if !json.Valid(trimmed) {
+
+			return nil, fmt.Errorf("params are not valid JSON")
+		}
+
+		return jsonrpc2.RawParams(trimmed), nil
+	}
+
+	params := make(map[string]interface{})
+
+	for _, arg := range args {
+
+		eq := strings.Index(arg, "=")
+
+		if eq <= 0 {
+
+			return nil, fmt.Errorf("invalid param %q, expected key=value", arg)
+		}
+
+		var (
+			keys   = strings.Split(arg[:eq], ".")
+			value  = arg[eq+1:]
+			object = params
+		)
+
+		for _, key := range keys[:len(keys)-1] {
+
+			switch v := object[key].(type) {
+			case nil:
+
+				next := make(map[string]interface{})
+				object[key] = next
+				object = next
+
+			case map[string]interface{}:
+
+				object = v
+
+			default:
+
+				return nil, fmt.Errorf("param %q conflicts with %q", arg[:eq], key)
+			}
+		}
+
+		key := keys[len(keys)-1]
+
+		if _, found := object[key]; found {
+
+			return nil, fmt.Errorf("param %q is given twice", arg[:eq])
+		}
+
+		if value != "" && json.Valid([]byte(value)) {
+
+			object[key] = json.RawMessage(value)
+
+			continue
+		}
+
+		object[key] = value
+	}
+
+	data, err := json.Marshal(params)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	return jsonrpc2.RawParams(data), nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{nil, ``},
+		{[]string{`{"a": 1}`}, `{"a": 1}`},
+		{[]string{` [1, 2] `}, `[1, 2]`},
+		{[]string{"a=1", "b=text"}, `{"a":1,"b":"text"}`},
+		{[]string{`tags=["x","y"]`, "flag=true"}, `{"flag":true,"tags":["x","y"]}`},
+		{[]string{"filter.name=x", "filter.id=1"}, `{"filter":{"id":1,"name":"x"}}`},
+		{[]string{"empty=", `quoted="1"`, "text=a=b"}, `{"empty":"","quoted":"1","text":"a=b"}`},
+	} {
+
+		if params, err := parseParams(test.args); assert.NoError(t, err, test.args) {
+
+			assert.Equal(t, test.expected, string(params), test.args)
+		}
+	}
+
+	for _, args := range [][]string{
+		{`{"a": `},
+		{"a"},
+		{"=1"},
+		{"a=1", "a=2"},
+		{"a=1", "a.b=2"},
+	} {
+
+		_, err := parseParams(args)
+
+		assert.Error(t, err, args)
+	}
+}
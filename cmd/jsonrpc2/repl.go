@@ -0,0 +1,603 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/openrpc"
+	"github.com/peterh/liner"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const replHelp = `Commands:
+
+	Method [params]          call a method, params are JSON or key=value pairs
+	name = Method [params]   call a method and save the result as $name
+	name = value             save a JSON value or $variable as $name
+	:help [method]           list the methods or describe one
+	:vars                    list the saved variables
+	:output pretty|json|raw  change the result format
+	:time                    toggle the call timing
+	:reload                  reload the methods with rpc.discover
+	:quit                    exit, as does Ctrl-D
+
+The result of the last call is saved as $_. Variables can be used in params,
+with a path into objects and arrays: Catalog.Get id=$items.0.id
+`
+
+var (
+	assignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+	variable   = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)((?:\.[A-Za-z0-9_-]+)*)`)
+)
+
+func newREPL(client jsonrpc2.Client, output string, stdout, stderr io.Writer) *repl {
+
+	return &repl{
+		client: client,
+		vars:   make(map[string]json.RawMessage),
+		output: output,
+		timing: true,
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+type repl struct {
+	client   jsonrpc2.Client
+	document *openrpc.Document
+	vars     map[string]json.RawMessage
+	output   string
+	timing   bool
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+// runREPL reads commands until Ctrl-D or :quit. The history is kept in
+// ~/.jsonrpc2_history.
+func runREPL(client jsonrpc2.Client, output string, stdout, stderr io.Writer) int {
+
+	r := newREPL(client, output, stdout, stderr)
+
+	if err := r.load(); err != nil {
+
+		fmt.Fprintf(stderr, "rpc.discover is not available, completion is disabled: %v\n", err)
+	}
+
+	line := liner.NewLiner()
+
+	defer line.Close()
+
+	line.SetCtrlCAborts(true)
+	line.SetWordCompleter(r.complete)
+
+	history := ""
+
+	if home, err := os.UserHomeDir(); err == nil {
+
+		history = filepath.Join(home, ".jsonrpc2_history")
+
+		if file, err := os.Open(history); err == nil {
+
+			line.ReadHistory(file)
+			file.Close()
+		}
+	}
+
+	defer func() {
+
+		if history == "" {
+
+			return
+		}
+
+		if file, err := os.Create(history); err == nil {
+
+			line.WriteHistory(file)
+			file.Close()
+		}
+	}()
+
+	for {
+
+		input, err := line.Prompt("> ")
+
+		switch err {
+		case nil:
+
+		case liner.ErrPromptAborted:
+
+			continue
+
+		case io.EOF:
+
+			fmt.Fprintln(stdout)
+
+			return exitOK
+
+		default:
+
+			fmt.Fprintf(stderr, "jsonrpc2: %v\n", err)
+
+			return exitFailure
+		}
+
+		if strings.TrimSpace(input) == "" {
+
+			continue
+		}
+
+		line.AppendHistory(input)
+
+		if r.eval(input) {
+
+			return exitOK
+		}
+	}
+}
+
+func (r *repl) load() error {
+
+	document, err := discover(r.client)
+
+	if err != nil {
+
+		return err
+	}
+
+	r.document = document
+
+	return nil
+}
+
+// eval runs one line of input and reports whether the REPL should exit.
+func (r *repl) eval(line string) bool {
+
+	line = strings.TrimSpace(line)
+
+	if strings.HasPrefix(line, ":") {
+
+		return r.command(strings.Fields(line))
+	}
+
+	name := "_"
+
+	if match := assignment.FindStringSubmatch(line); match != nil {
+
+		name, line = match[1], match[2]
+
+		value, err := r.expand(line)
+
+		if err != nil && strings.HasPrefix(line, "$") {
+
+			fmt.Fprintf(r.stderr, "jsonrpc2: %v\n", err)
+
+			return false
+		}
+
+		if err == nil && json.Valid([]byte(value)) {
+
+			r.vars[name] = json.RawMessage(value)
+
+			return false
+		}
+	}
+
+	start := time.Now()
+
+	result, err := r.call(line)
+
+	if elapsed := time.Since(start); r.timing {
+
+		defer fmt.Fprintf(r.stderr, "(%s)\n", elapsed.Round(time.Microsecond))
+	}
+
+	if err != nil {
+
+		printError(r.stderr, err)
+
+		return false
+	}
+
+	r.vars["_"] = result
+	r.vars[name] = result
+
+	if err := format(r.stdout, result, r.output); err != nil {
+
+		fmt.Fprintf(r.stderr, "jsonrpc2: %v\n", err)
+	}
+
+	return false
+}
+
+func (r *repl) call(line string) (json.RawMessage, error) {
+
+	args, err := splitArgs(line)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	for i, arg := range args[1:] {
+
+		if eq := strings.Index(arg, "="); eq > 0 && arg[0] != '{' && arg[0] != '[' {
+
+			value, err := r.expand(arg[eq+1:])
+
+			if err != nil {
+
+				return nil, err
+			}
+
+			args[i+1] = arg[:eq+1] + value
+
+			continue
+		}
+
+		if args[i+1], err = r.expand(arg); err != nil {
+
+			return nil, err
+		}
+	}
+
+	params, err := parseParams(args[1:])
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	return call(r.client, args[0], params)
+}
+
+func (r *repl) command(args []string) bool {
+
+	switch args[0] {
+	case ":quit", ":exit", ":q":
+
+		return true
+
+	case ":help":
+
+		if r.document == nil {
+
+			fmt.Fprint(r.stdout, replHelp)
+
+			return false
+		}
+
+		if len(args) == 1 {
+
+			fmt.Fprint(r.stdout, replHelp, "\n")
+		}
+
+		printHelp(r.document, args[1:], r.stdout, r.stderr)
+
+	case ":vars":
+
+		names := make([]string, 0, len(r.vars))
+
+		for name := range r.vars {
+
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+
+			value := string(r.vars[name])
+
+			if len(value) > 72 {
+
+				value = value[:69] + "..."
+			}
+
+			fmt.Fprintf(r.stdout, "$%s = %s\n", name, value)
+		}
+
+	case ":output":
+
+		if len(args) != 2 || (args[1] != "pretty" && args[1] != "json" && args[1] != "raw") {
+
+			fmt.Fprintln(r.stderr, "usage: :output pretty|json|raw")
+
+			return false
+		}
+
+		r.output = args[1]
+
+	case ":time":
+
+		r.timing = !r.timing
+
+	case ":reload":
+
+		if err := r.load(); err != nil {
+
+			printError(r.stderr, err)
+		}
+
+	default:
+
+		fmt.Fprintf(r.stderr, "unknown command %s, see :help\n", args[0])
+	}
+
+	return false
+}
+
+// expand replaces the $variables outside of JSON strings in text with their
+// values. A variable may be followed by a path of object keys and array
+// indexes: $result.items.0.id.
+func (r *repl) expand(text string) (string, error) {
+
+	var (
+		out      strings.Builder
+		inString bool
+	)
+
+	for i := 0; i < len(text); i++ {
+
+		c := text[i]
+
+		switch {
+		case inString:
+
+			if c == '\\' && i+1 < len(text) {
+
+				out.WriteByte(c)
+
+				i++
+
+				c = text[i]
+
+			} else if c == '"' {
+
+				inString = false
+			}
+
+		case c == '"':
+
+			inString = true
+
+		case c == '$':
+
+			match := variable.FindStringSubmatch(text[i:])
+
+			if match == nil {
+
+				break
+			}
+
+			value, err := r.lookup(match[1], match[2])
+
+			if err != nil {
+
+				return "", err
+			}
+
+			out.Write(value)
+
+			i += len(match[0]) - 1
+
+			continue
+		}
+
+		out.WriteByte(c)
+	}
+
+	return out.String(), nil
+}
+
+func (r *repl) lookup(name, path string) (json.RawMessage, error) {
+
+	value, found := r.vars[name]
+
+	if !found {
+
+		return nil, fmt.Errorf("$%s is not defined", name)
+	}
+
+	if path == "" {
+
+		return value, nil
+	}
+
+	var v interface{}
+
+	if err := json.Unmarshal(value, &v); err != nil {
+
+		return nil, err
+	}
+
+	for _, key := range strings.Split(path[1:], ".") {
+
+		switch node := v.(type) {
+		case map[string]interface{}:
+
+			if v, found = node[key]; !found {
+
+				return nil, fmt.Errorf("$%s%s: no key %s", name, path, key)
+			}
+
+		case []interface{}:
+
+			i, err := strconv.Atoi(key)
+
+			if err != nil || i < 0 || i >= len(node) {
+
+				return nil, fmt.Errorf("$%s%s: no index %s", name, path, key)
+			}
+
+			v = node[i]
+
+		default:
+
+			return nil, fmt.Errorf("$%s%s: %s is not an object or array", name, path, key)
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// complete suggests commands and methods for the first word, param names
+// after a method, and variables for words starting with $.
+func (r *repl) complete(line string, pos int) (string, []string, string) {
+
+	var (
+		head       = string([]rune(line)[:pos])
+		tail       = string([]rune(line)[pos:])
+		start      = strings.LastIndexAny(head, " =") + 1
+		word       = head[start:]
+		fields     = strings.Fields(head[:start])
+		candidates []string
+	)
+
+	if match := assignment.FindStringSubmatch(head[:start]); match != nil {
+
+		fields = strings.Fields(match[2])
+	}
+
+	switch {
+	case strings.HasPrefix(word, "$"):
+
+		for name := range r.vars {
+
+			candidates = append(candidates, "$"+name)
+		}
+
+	case len(fields) == 0:
+
+		candidates = append(candidates, ":help", ":vars", ":output", ":time", ":reload", ":quit")
+
+		fallthrough
+
+	case len(fields) == 1 && fields[0] == ":help":
+
+		if r.document != nil {
+
+			for _, m := range r.document.Methods {
+
+				candidates = append(candidates, m.Name)
+			}
+		}
+
+	case len(fields) == 1 && fields[0] == ":output":
+
+		candidates = []string{"pretty", "json", "raw"}
+
+	case r.document != nil && strings.HasSuffix(head[:start], " "):
+
+		m, found := r.document.Method(fields[0])
+
+		if !found {
+
+			break
+		}
+
+		for _, param := range m.Params {
+
+			if !strings.Contains(head, " "+param.Name+"=") {
+
+				candidates = append(candidates, param.Name+"=")
+			}
+		}
+	}
+
+	sort.Strings(candidates)
+
+	var completions []string
+
+	for _, candidate := range candidates {
+
+		if strings.HasPrefix(candidate, word) {
+
+			completions = append(completions, candidate)
+		}
+	}
+
+	return head[:start], completions, tail
+}
+
+// splitArgs splits a line at spaces outside of JSON strings, objects and
+// arrays.
+func splitArgs(line string) ([]string, error) {
+
+	var (
+		args     []string
+		current  strings.Builder
+		depth    int
+		inString bool
+	)
+
+	for i := 0; i < len(line); i++ {
+
+		c := line[i]
+
+		switch {
+		case inString:
+
+			if c == '\\' && i+1 < len(line) {
+
+				current.WriteByte(c)
+
+				i++
+
+				c = line[i]
+
+			} else if c == '"' {
+
+				inString = false
+			}
+
+		case c == '"':
+
+			inString = true
+
+		case c == '{' || c == '[':
+
+			depth++
+
+		case c == '}' || c == ']':
+
+			depth--
+
+		case depth == 0 && (c == ' ' || c == '\t'):
+
+			if current.Len() != 0 {
+
+				args = append(args, current.String())
+
+				current.Reset()
+			}
+
+			continue
+		}
+
+		current.WriteByte(c)
+	}
+
+	if inString || depth != 0 {
+
+		return nil, fmt.Errorf("unterminated string, object or array")
+	}
+
+	if current.Len() != 0 {
+
+		args = append(args, current.String())
+	}
+
+	if len(args) == 0 {
+
+		return nil, fmt.Errorf("method is required")
+	}
+
+	return args, nil
+}
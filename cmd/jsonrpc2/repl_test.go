@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func testREPL(t *testing.T, url string) (*repl, *bytes.Buffer, *bytes.Buffer) {
+
+	var (
+		stdout, stderr bytes.Buffer
+		o              = options{urls: stringList{url}}
+	)
+
+	client, err := o.client()
+
+	if !assert.NoError(t, err) {
+
+		t.FailNow()
+	}
+
+	r := newREPL(client, "json", &stdout, &stderr)
+	r.timing = false
+
+	return r, &stdout, &stderr
+}
+
+func TestREPLEval(t *testing.T) {
+
+	ts := testServer()
+
+	defer ts.Close()
+
+	r, stdout, stderr := testREPL(t, ts.URL)
+
+	for _, test := range []struct {
+		line, stdout, stderr string
+	}{
+		{"Math.Sum a=1 b=2", "3\n", ""},
+		{`Math.Sum {"a": $_, "b": 10}`, "13\n", ""},
+		{"sum = Math.Sum a=$_ b=1", "14\n", ""},
+		{"Math.Sum a=$sum b=$sum", "28\n", ""},
+		{`pair = {"values": [5, 6], "name": "$x"}`, "", ""},
+		{"Math.Sum a=$pair.values.0 b=$pair.values.1", "11\n", ""},
+		{"Math.Echo text=$pair.name", "\"$x\"\n", ""},
+		{`Math.Echo text="$pair"`, "\"$pair\"\n", ""},
+		{"Math.Sum a=$missing", "", "jsonrpc2: $missing is not defined\n"},
+		{"Math.Sum a=$pair.values.2", "", "jsonrpc2: $pair.values.2: no index 2\n"},
+		{"copy = $missing", "", "jsonrpc2: $missing is not defined\n"},
+		{"Math.Fail", "", "jsonrpc2: error -32001: failed\n"},
+		{`Math.Sum {"a": 1`, "", "jsonrpc2: unterminated string, object or array\n"},
+		{":output raw", "", ""},
+		{"Math.Echo text=raw", "raw\n", ""},
+		{":output yaml", "", "usage: :output pretty|json|raw\n"},
+		{":unknown", "", "unknown command :unknown, see :help\n"},
+	} {
+
+		stdout.Reset()
+		stderr.Reset()
+
+		assert.False(t, r.eval(test.line))
+		assert.Equal(t, test.stdout, stdout.String(), test.line)
+		assert.Equal(t, test.stderr, stderr.String(), test.line)
+	}
+
+	stdout.Reset()
+
+	r.eval(":vars")
+
+	assert.Equal(t, "$_ = \"raw\"\n$pair = {\"values\": [5, 6], \"name\": \"$x\"}\n$sum = 14\n", stdout.String())
+
+	r.timing = true
+	stderr.Reset()
+
+	r.eval("Math.Sum a=1 b=1")
+
+	assert.Regexp(t, `^\(\d.*s\)\n$`, stderr.String())
+
+	assert.True(t, r.eval(":quit"))
+}
+
+func TestREPLHelp(t *testing.T) {
+
+	ts := testServer()
+
+	defer ts.Close()
+
+	r, stdout, _ := testREPL(t, ts.URL)
+
+	r.eval(":help")
+
+	assert.Contains(t, stdout.String(), ":vars")
+	assert.NotContains(t, stdout.String(), "Math.Sum")
+
+	if assert.NoError(t, r.load()) {
+
+		stdout.Reset()
+
+		r.eval(":help")
+
+		assert.Contains(t, stdout.String(), "Math.Sum")
+
+		stdout.Reset()
+
+		r.eval(":help Math.Sum")
+
+		assert.Contains(t, stdout.String(), "Sum adds two numbers.")
+	}
+}
+
+func TestREPLComplete(t *testing.T) {
+
+	ts := testServer()
+
+	defer ts.Close()
+
+	r, _, _ := testREPL(t, ts.URL)
+	r.vars["sum"] = []byte("1")
+	r.vars["_"] = []byte("1")
+
+	if !assert.NoError(t, r.load()) {
+
+		return
+	}
+
+	for _, test := range []struct {
+		line        string
+		head        string
+		completions []string
+	}{
+		{"Math.S", "", []string{"Math.Sum"}},
+		{":h", "", []string{":help"}},
+		{":help Math.E", ":help ", []string{"Math.Echo"}},
+		{":output j", ":output ", []string{"json"}},
+		{"Math.Sum ", "Math.Sum ", []string{"a=", "b="}},
+		{"Math.Sum a=1 ", "Math.Sum a=1 ", []string{"b="}},
+		{"Math.Sum a=$s", "Math.Sum a=", []string{"$sum"}},
+		{"x = Math.Sum b", "x = Math.Sum ", []string{"b="}},
+		{"x = Ma", "x = ", []string{"Math.Echo", "Math.Fail", "Math.Sum"}},
+		{"Math.Unknown ", "Math.Unknown ", nil},
+	} {
+
+		head, completions, tail := r.complete(test.line+"tail", len([]rune(test.line)))
+
+		assert.Equal(t, test.head, head, test.line)
+		assert.Equal(t, test.completions, completions, test.line)
+		assert.Equal(t, "tail", tail)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+
+	if args, err := splitArgs(` Math.Sum  {"a": [1, 2], "b": "x y"}  text="a b\" c" `); assert.NoError(t, err) {
+
+		assert.Equal(t, []string{"Math.Sum", `{"a": [1, 2], "b": "x y"}`, `text="a b\" c"`}, args)
+	}
+
+	_, err := splitArgs(" ")
+
+	assert.Error(t, err)
+}
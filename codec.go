@@ -0,0 +1,107 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Codec reads and writes the messages of a connection. A message is a
+// request, a response or a batch of them.
+type Codec interface {
+	ReadMessage() (json.RawMessage, error)
+	WriteMessage(message json.RawMessage) error
+	Close() error
+}
+
+// Dialer opens a codec to the server at address. The client writes a call to
+// the codec, reads the response and closes it.
+type Dialer func(ctx context.Context, address string) (Codec, error)
+
+func WithDialer(dialer Dialer) ClientOption {
+
+	return func(c *client) {
+
+		c.dialer = dialer
+	}
+}
+
+// HTTPDialer sends each message written to the codec as a POST request to
+// the address and reads the response body as the reply.
+func HTTPDialer(httpClient *http.Client) Dialer {
+
+	return func(ctx context.Context, address string) (Codec, error) {
+
+		return &httpCodec{
+			ctx:        ctx,
+			url:        address,
+			httpClient: httpClient,
+		}, nil
+	}
+}
+
+type httpCodec struct {
+	ctx        context.Context
+	url        string
+	httpClient *http.Client
+	response   *http.Response
+}
+
+func (c *httpCodec) WriteMessage(message json.RawMessage) error {
+
+	request, err := http.NewRequestWithContext(c.ctx, "POST", c.url, bytes.NewReader(message))
+
+	if err != nil {
+
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	c.Close()
+
+	c.response, err = c.httpClient.Do(request)
+
+	return err
+}
+
+func (c *httpCodec) ReadMessage() (json.RawMessage, error) {
+
+	if c.response == nil {
+
+		return nil, errors.New("no message was written")
+	}
+
+	defer c.Close()
+
+	return ioutil.ReadAll(c.response.Body)
+}
+
+// readStream returns the body of the response, it is closed with the codec.
+func (c *httpCodec) readStream() (io.Reader, error) {
+
+	if c.response == nil {
+
+		return nil, errors.New("no message was written")
+	}
+
+	return c.response.Body, nil
+}
+
+func (c *httpCodec) Close() error {
+
+	if c.response == nil {
+
+		return nil
+	}
+
+	err := c.response.Body.Close()
+
+	c.response = nil
+
+	return err
+}
@@ -0,0 +1,141 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testCodec struct {
+	written  []json.RawMessage
+	reply    func(message json.RawMessage) (json.RawMessage, error)
+	closed   bool
+	writeErr error
+}
+
+func (c *testCodec) ReadMessage() (json.RawMessage, error) {
+
+	return c.reply(c.written[len(c.written)-1])
+}
+
+func (c *testCodec) WriteMessage(message json.RawMessage) error {
+
+	c.written = append(c.written, message)
+
+	return c.writeErr
+}
+
+func (c *testCodec) Close() error {
+
+	c.closed = true
+
+	return nil
+}
+
+func TestClientDialer(t *testing.T) {
+
+	var (
+		codec = &testCodec{
+			reply: func(message json.RawMessage) (json.RawMessage, error) {
+
+				var request ServerRequest
+
+				json.Unmarshal(message, &request)
+
+				return json.RawMessage(fmt.Sprintf(`{"jsonrpc": "2.0", "id": %d, "result": %s}`, request.RequestID, request.Params)), nil
+			},
+		}
+		addresses []string
+		client    = NewClient(&testDiscovery{addresses: []string{"pipe://a"}}, WithDialer(func(ctx context.Context, address string) (Codec, error) {
+
+			addresses = append(addresses, address)
+
+			return codec, nil
+		}))
+		result []int
+	)
+
+	if assert.NoError(t, client.Send("Echo", testParams{1, 2}, &result)) {
+
+		assert.Equal(t, []int{1, 2}, result)
+		assert.Equal(t, []string{"pipe://a"}, addresses)
+		assert.True(t, codec.closed)
+	}
+
+	codec.writeErr = errors.New("broken pipe")
+
+	assert.EqualError(t, client.Send("Echo", testParams{1}, &result), "broken pipe")
+
+	codec.writeErr = nil
+	codec.reply = func(json.RawMessage) (json.RawMessage, error) {
+
+		return json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "error": {"code": -32001, "message": "failed"}}`), nil
+	}
+
+	_, ok := client.Send("Echo", testParams{1}, &result).(*LogicError)
+
+	assert.True(t, ok)
+}
+
+func TestClientDialError(t *testing.T) {
+
+	client := NewClient(&testDiscovery{addresses: []string{"pipe://a", "pipe://b"}}, WithDialer(func(ctx context.Context, address string) (Codec, error) {
+
+		return nil, fmt.Errorf("dial %s: refused", address)
+	}))
+
+	assert.Error(t, client.Send("Echo", testParams{1}, nil))
+}
+
+type testParams []int
+
+func (p testParams) IsValid() bool {
+
+	return true
+}
+
+func TestHTTPDialer(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		data, _ := ioutil.ReadAll(r.Body)
+
+		w.Write(append([]byte("echo "), data...))
+	}))
+
+	defer testServer.Close()
+
+	codec, err := HTTPDialer(http.DefaultClient)(context.Background(), testServer.URL)
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer codec.Close()
+
+	_, err = codec.ReadMessage()
+
+	assert.EqualError(t, err, "no message was written")
+
+	for _, message := range []string{"1", "2"} {
+
+		if assert.NoError(t, codec.WriteMessage(json.RawMessage(message))) {
+
+			reply, err := codec.ReadMessage()
+
+			if assert.NoError(t, err) {
+
+				assert.Equal(t, "echo "+message, string(reply))
+			}
+		}
+	}
+
+	assert.NoError(t, codec.Close())
+}
@@ -0,0 +1,610 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClosed is returned by the calls of a closed connection.
+var ErrClosed = errors.New("jsonrpc2: connection closed")
+
+// CancelRequest is the notification cancelling the request in flight with
+// the id of its params. The handler's context is cancelled and the request is
+// answered with RequestCancelled.
+const CancelRequest = "$/cancelRequest"
+
+// Handler handles the requests and notifications received by a Conn and
+// returns the encoded response, nil if there is none. The server of the
+// server package is a Handler.
+type Handler interface {
+	HandleMessage(ctx context.Context, message json.RawMessage) json.RawMessage
+}
+
+type ConnOption func(*Conn)
+
+// WithHandler sets the handler of the requests and notifications sent by the
+// peer. Without a handler requests are answered with method not found.
+func WithHandler(handler Handler) ConnOption {
+
+	return func(c *Conn) {
+
+		c.handler = handler
+	}
+}
+
+// WithContext sets the parent of the context the handler is called with.
+func WithContext(ctx context.Context) ConnOption {
+
+	return func(c *Conn) {
+
+		c.ctx = ctx
+	}
+}
+
+// NewConn starts reading the messages of codec. Responses are matched to the
+// calls in flight by id, so any number of calls can share the connection.
+// Requests are passed to the handler concurrently, notifications one by one
+// in the order they were received, so a notification handler must not wait
+// for the peer.
+func NewConn(codec Codec, options ...ConnOption) *Conn {
+
+	c := &Conn{
+		codec:         codec,
+		ctx:           context.Background(),
+		mutex:         &sync.Mutex{},
+		writeMutex:    &sync.Mutex{},
+		inFlight:      &sync.WaitGroup{},
+		pending:       make(map[int]chan json.RawMessage),
+		incoming:      make(map[int]*incomingCall),
+		progressFuncs: make(map[int]ProgressFunc),
+		notifications: make(chan json.RawMessage, 64),
+		idle:          make(chan struct{}),
+		closing:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	for _, option := range options {
+
+		option(c)
+	}
+
+	c.ctx, c.cancel = context.WithCancel(context.WithValue(c.ctx, connKey{}, c))
+
+	c.inFlight.Add(1)
+
+	go c.notify()
+	go c.read()
+
+	return c
+}
+
+// Conn is a connection to a peer. It implements Client.
+type Conn struct {
+	codec         Codec
+	handler       Handler
+	ctx           context.Context
+	cancel        context.CancelFunc
+	mutex         *sync.Mutex
+	writeMutex    *sync.Mutex
+	inFlight      *sync.WaitGroup
+	pending       map[int]chan json.RawMessage
+	incoming      map[int]*incomingCall
+	progressFuncs map[int]ProgressFunc
+	active        int
+	notifications chan json.RawMessage
+	nextID        int
+	closed        bool
+	shutdown      bool
+	err           error
+	idle          chan struct{}
+	closing       chan struct{}
+	done          chan struct{}
+}
+
+type connKey struct{}
+
+type incomingCall struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+type cancelParams struct {
+	ID int `json:"id"`
+}
+
+func (p *cancelParams) IsValid() bool {
+
+	return true
+}
+
+// ConnFromContext returns the connection a request was received on, nil if
+// it was not received on a Conn.
+func ConnFromContext(ctx context.Context) *Conn {
+
+	c, _ := ctx.Value(connKey{}).(*Conn)
+
+	return c
+}
+
+func (c *Conn) Send(method string, params Params, result interface{}) error {
+
+	return c.SendContext(context.Background(), method, params, result)
+}
+
+// SendContext calls method on the peer. If ctx is done first the peer is sent
+// CancelRequest for the call.
+func (c *Conn) SendContext(ctx context.Context, method string, params Params, result interface{}) error {
+
+	c.mutex.Lock()
+
+	if c.err != nil {
+
+		c.mutex.Unlock()
+
+		return c.err
+	}
+
+	c.nextID++
+
+	var (
+		id    = c.nextID
+		reply = make(chan json.RawMessage, 1)
+	)
+
+	c.pending[id] = reply
+
+	if fn := progressFromContext(ctx); fn != nil {
+
+		c.progressFuncs[id] = fn
+	}
+
+	c.mutex.Unlock()
+
+	defer func() {
+
+		c.mutex.Lock()
+
+		delete(c.pending, id)
+		delete(c.progressFuncs, id)
+
+		c.checkIdle()
+
+		c.mutex.Unlock()
+	}()
+
+	data, _ := json.Marshal(Request{
+		Jsonrpc:   "2.0",
+		RequestID: id,
+		Method:    method,
+		Params:    params,
+	})
+
+	if err := c.write(data); err != nil {
+
+		return err
+	}
+
+	select {
+	case message := <-reply:
+
+		return decodeResponse(message, result)
+
+	case <-c.closing:
+
+		select {
+		case message := <-reply:
+
+			return decodeResponse(message, result)
+
+		default:
+
+			return c.Err()
+		}
+
+	case <-ctx.Done():
+
+		c.Notify(CancelRequest, &cancelParams{ID: id})
+
+		return ctx.Err()
+	}
+}
+
+type notification struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  Params `json:"params,omitempty"`
+}
+
+// Notify sends a request without an id, the peer does not answer it.
+func (c *Conn) Notify(method string, params Params) error {
+
+	if err := c.Err(); err != nil {
+
+		return err
+	}
+
+	data, _ := json.Marshal(notification{
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+
+	return c.write(data)
+}
+
+// Close closes the codec, cancels the context of the handlers and waits for
+// them to return. The calls in flight fail with ErrClosed.
+func (c *Conn) Close() error {
+
+	c.mutex.Lock()
+
+	c.closed = true
+
+	c.mutex.Unlock()
+
+	err := c.codec.Close()
+
+	c.cancel()
+
+	<-c.done
+
+	return err
+}
+
+// Shutdown stops new calls and notifications, waits for the calls in flight
+// in both directions and closes the connection. The requests the peer sends
+// meanwhile are answered with ServerError, its notifications are dropped. If
+// ctx is done first the connection is closed, the calls still in flight fail
+// with ErrClosed and Shutdown returns the error of ctx.
+func (c *Conn) Shutdown(ctx context.Context) error {
+
+	c.mutex.Lock()
+
+	if c.err == nil {
+
+		c.err = ErrClosed
+	}
+
+	c.shutdown = true
+
+	c.checkIdle()
+
+	c.mutex.Unlock()
+
+	select {
+	case <-c.idle:
+
+		return c.Close()
+
+	case <-c.done:
+
+		return nil
+
+	case <-ctx.Done():
+
+		c.Close()
+
+		return ctx.Err()
+	}
+}
+
+// checkIdle closes idle once the connection is shutting down and no call is
+// in flight. It must be called with the mutex held.
+func (c *Conn) checkIdle() {
+
+	if !c.shutdown || len(c.pending) != 0 || c.active != 0 {
+
+		return
+	}
+
+	select {
+	case <-c.idle:
+
+	default:
+
+		close(c.idle)
+	}
+}
+
+// Done is closed when the connection is closed and the handlers returned.
+func (c *Conn) Done() <-chan struct{} {
+
+	return c.done
+}
+
+// Err returns the reason the connection was closed, nil while it is open.
+func (c *Conn) Err() error {
+
+	c.mutex.Lock()
+
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+func (c *Conn) write(data []byte) error {
+
+	c.writeMutex.Lock()
+
+	defer c.writeMutex.Unlock()
+
+	return c.codec.WriteMessage(data)
+}
+
+// read dispatches the messages until the codec fails. Then the calls in
+// flight fail, the handlers are waited for so that their responses are still
+// written and the codec is closed.
+func (c *Conn) read() {
+
+	for {
+
+		message, err := c.codec.ReadMessage()
+
+		if err != nil {
+
+			c.mutex.Lock()
+
+			if c.closed || err == io.EOF {
+
+				err = ErrClosed
+			}
+
+			if c.err == nil {
+
+				c.err = err
+			}
+
+			c.mutex.Unlock()
+
+			break
+		}
+
+		c.dispatch(message)
+	}
+
+	close(c.closing)
+	close(c.notifications)
+
+	c.inFlight.Wait()
+	c.cancel()
+	c.codec.Close()
+
+	close(c.done)
+}
+
+func (c *Conn) dispatch(message json.RawMessage) {
+
+	if isBatch(message) {
+
+		c.handle(message, 0, false)
+
+		return
+	}
+
+	var m struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+
+	if err := json.Unmarshal(message, &m); err != nil {
+
+		if c.handler != nil {
+
+			c.reply(0, NewError(ParseError, err.Error()))
+		}
+
+		return
+	}
+
+	var (
+		id      int
+		validID = json.Unmarshal(m.ID, &id) == nil
+	)
+
+	switch {
+	case m.Method == CancelRequest:
+
+		c.cancelIncoming(message)
+
+	case m.Method == Progress:
+
+		c.progress(message)
+
+	case m.Method != "" && (len(m.ID) == 0 || string(m.ID) == "null"):
+
+		if c.handler != nil {
+
+			c.mutex.Lock()
+
+			shutdown := c.shutdown
+
+			if !shutdown {
+
+				c.active++
+			}
+
+			c.mutex.Unlock()
+
+			if !shutdown {
+
+				c.notifications <- message
+			}
+		}
+
+	case m.Method != "":
+
+		c.handle(message, id, validID)
+
+	case validID:
+
+		c.mutex.Lock()
+
+		reply, found := c.pending[id]
+
+		c.mutex.Unlock()
+
+		if found {
+
+			select {
+			case reply <- message:
+
+			default:
+			}
+		}
+	}
+}
+
+// handle passes a request to the handler. The ids of the requests in flight
+// are tracked so that they can be cancelled, a request reusing one of them is
+// rejected.
+func (c *Conn) handle(message json.RawMessage, id int, tracked bool) {
+
+	if c.handler == nil {
+
+		c.reply(id, NewError(MethodNotFound, ""))
+
+		return
+	}
+
+	c.mutex.Lock()
+
+	if c.shutdown {
+
+		c.mutex.Unlock()
+
+		c.reply(id, NewError(ServerError, "shutting down"))
+
+		return
+	}
+
+	if _, found := c.incoming[id]; tracked && found {
+
+		c.mutex.Unlock()
+
+		c.reply(id, NewError(InvalidRequest, "duplicate id"))
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	call := &incomingCall{cancel: cancel}
+
+	if tracked {
+
+		c.incoming[id] = call
+
+		ctx = c.withReporter(ctx, id, message)
+	}
+
+	c.active++
+
+	c.mutex.Unlock()
+
+	c.inFlight.Add(1)
+
+	go func() {
+
+		defer c.inFlight.Done()
+		defer cancel()
+
+		response := c.handler.HandleMessage(ctx, message)
+
+		c.mutex.Lock()
+
+		if tracked {
+
+			delete(c.incoming, id)
+		}
+
+		c.active--
+
+		c.checkIdle()
+
+		cancelled := call.cancelled
+
+		c.mutex.Unlock()
+
+		if cancelled {
+
+			c.reply(id, NewError(RequestCancelled, ""))
+
+			return
+		}
+
+		if len(response) != 0 {
+
+			c.write(response)
+		}
+	}()
+}
+
+// cancelIncoming cancels the context of a request in flight, its response is
+// replaced with RequestCancelled.
+func (c *Conn) cancelIncoming(message json.RawMessage) {
+
+	var m struct {
+		Params cancelParams `json:"params"`
+	}
+
+	if json.Unmarshal(message, &m) != nil {
+
+		return
+	}
+
+	c.mutex.Lock()
+
+	call, found := c.incoming[m.Params.ID]
+
+	if found {
+
+		call.cancelled = true
+	}
+
+	c.mutex.Unlock()
+
+	if found {
+
+		call.cancel()
+	}
+}
+
+func (c *Conn) notify() {
+
+	defer c.inFlight.Done()
+
+	for message := range c.notifications {
+
+		c.handler.HandleMessage(c.ctx, message)
+
+		c.mutex.Lock()
+
+		c.active--
+
+		c.checkIdle()
+
+		c.mutex.Unlock()
+	}
+}
+
+func (c *Conn) reply(id int, e *Error) {
+
+	data, _ := json.Marshal(Response{
+		Jsonrpc:   "2.0",
+		RequestID: id,
+		Error:     e,
+	})
+
+	c.write(data)
+}
+
+func isBatch(message json.RawMessage) bool {
+
+	message = bytes.TrimLeft(message, " \t\r\n")
+
+	return len(message) != 0 && message[0] == '['
+}
@@ -0,0 +1,587 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestPipe() (*pipeCodec, *pipeCodec) {
+
+	var (
+		ab   = make(chan json.RawMessage, 16)
+		ba   = make(chan json.RawMessage, 16)
+		done = make(chan struct{})
+		once = &sync.Once{}
+	)
+
+	return &pipeCodec{in: ba, out: ab, done: done, once: once}, &pipeCodec{in: ab, out: ba, done: done, once: once}
+}
+
+type pipeCodec struct {
+	in   chan json.RawMessage
+	out  chan json.RawMessage
+	done chan struct{}
+	once *sync.Once
+}
+
+func (c *pipeCodec) ReadMessage() (json.RawMessage, error) {
+
+	select {
+	case message := <-c.in:
+
+		return message, nil
+
+	case <-c.done:
+
+		return nil, io.EOF
+	}
+}
+
+func (c *pipeCodec) WriteMessage(message json.RawMessage) error {
+
+	select {
+	case <-c.done:
+
+		return io.ErrClosedPipe
+
+	default:
+	}
+
+	select {
+	case c.out <- message:
+
+		return nil
+
+	case <-c.done:
+
+		return io.ErrClosedPipe
+	}
+}
+
+func (c *pipeCodec) Close() error {
+
+	c.once.Do(func() {
+
+		close(c.done)
+	})
+
+	return nil
+}
+
+type testHandler func(ctx context.Context, request *ServerRequest) (interface{}, *Error)
+
+func (fn testHandler) HandleMessage(ctx context.Context, message json.RawMessage) json.RawMessage {
+
+	var request ServerRequest
+
+	json.Unmarshal(message, &request)
+
+	result, err := fn(ctx, &request)
+
+	data, _ := json.Marshal(Response{
+		Jsonrpc:   "2.0",
+		RequestID: request.RequestID,
+		Result:    result,
+		Error:     err,
+	})
+
+	return data
+}
+
+func TestConn(t *testing.T) {
+
+	var (
+		a, b          = newTestPipe()
+		mutex         = &sync.Mutex{}
+		notifications []string
+		server        = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			switch request.Method {
+			case "Echo":
+
+				var (
+					params []int
+					delay  int
+				)
+
+				if json.Unmarshal(request.Params, &params); len(params) != 0 {
+
+					delay = params[0]
+				}
+
+				time.Sleep(time.Duration(delay) * time.Millisecond)
+
+				return delay, nil
+
+			case "Notify":
+
+				mutex.Lock()
+				notifications = append(notifications, string(request.Params))
+				mutex.Unlock()
+
+				return nil, nil
+
+			case "Fail":
+
+				return nil, &Error{Code: LogicErr, Message: "failed"}
+			}
+
+			return nil, NewError(MethodNotFound, "")
+		})))
+		client = NewConn(b)
+		wg     = &sync.WaitGroup{}
+	)
+
+	defer server.Close()
+	defer client.Close()
+
+	for _, delay := range []int{30, 0, 20, 10} {
+
+		wg.Add(1)
+
+		go func(delay int) {
+
+			defer wg.Done()
+
+			var result int
+
+			if assert.NoError(t, client.Send("Echo", testParams{delay}, &result)) {
+
+				assert.Equal(t, delay, result)
+			}
+
+		}(delay)
+	}
+
+	wg.Wait()
+
+	assert.NoError(t, client.Notify("Notify", testParams{1}))
+
+	_, ok := client.Send("Fail", testParams{}, nil).(*LogicError)
+
+	assert.True(t, ok)
+
+	if err, ok := client.Send("Unknown", testParams{}, nil).(*Error); assert.True(t, ok) {
+
+		assert.Equal(t, int16(MethodNotFound), err.Code)
+	}
+
+	if err, ok := server.Send("Echo", testParams{}, nil).(*Error); assert.True(t, ok) {
+
+		assert.Equal(t, int16(MethodNotFound), err.Code)
+	}
+
+	assert.Eventually(t, func() bool {
+
+		mutex.Lock()
+
+		defer mutex.Unlock()
+
+		return len(notifications) == 1 && notifications[0] == "[1]"
+
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, client.SendContext(ctx, "Echo", testParams{100}, nil))
+}
+
+func TestConnCallback(t *testing.T) {
+
+	var (
+		a, b   = newTestPipe()
+		server = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			var name string
+
+			if err := ConnFromContext(ctx).SendContext(ctx, "Name", nil, &name); err != nil {
+
+				return nil, NewError(InternalError, err.Error())
+			}
+
+			return "hello " + name, nil
+		})))
+		client = NewConn(b, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			return "client", nil
+		})))
+		result string
+	)
+
+	defer server.Close()
+	defer client.Close()
+
+	if assert.NoError(t, client.Send("Hello", nil, &result)) {
+
+		assert.Equal(t, "hello client", result)
+	}
+
+	assert.Nil(t, ConnFromContext(context.Background()))
+}
+
+func TestConnClose(t *testing.T) {
+
+	var (
+		a, b     = newTestPipe()
+		started  = make(chan struct{})
+		canceled = make(chan struct{})
+		server   = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			close(started)
+
+			<-ctx.Done()
+
+			close(canceled)
+
+			return nil, nil
+		})))
+		client = NewConn(b)
+		err    = make(chan error)
+	)
+
+	go func() {
+
+		err <- client.Send("Wait", nil, nil)
+	}()
+
+	<-started
+
+	assert.NoError(t, server.Close())
+
+	<-canceled
+
+	assert.Equal(t, ErrClosed, <-err)
+	assert.Equal(t, ErrClosed, server.Err())
+
+	<-client.Done()
+
+	assert.Equal(t, ErrClosed, client.Err())
+	assert.Equal(t, ErrClosed, client.Send("Echo", nil, nil))
+	assert.Equal(t, ErrClosed, client.Notify("Echo", nil))
+}
+
+func TestConnParseError(t *testing.T) {
+
+	var (
+		a, b   = newTestPipe()
+		server = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			return nil, nil
+		})))
+	)
+
+	defer server.Close()
+
+	b.WriteMessage(json.RawMessage(`{"id": `))
+
+	message, _ := b.ReadMessage()
+
+	var response Response
+
+	if assert.NoError(t, json.Unmarshal(message, &response)) && assert.NotNil(t, response.Error) {
+
+		assert.Equal(t, ParseError, response.Error.Code)
+	}
+
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "id": 9, "result": 1}`))
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "Echo"}`))
+
+	message, _ = b.ReadMessage()
+
+	if assert.NoError(t, json.Unmarshal(message, &response)) {
+
+		assert.Equal(t, 1, response.RequestID)
+	}
+}
+
+func TestIsBatch(t *testing.T) {
+
+	assert.True(t, isBatch(json.RawMessage(" \n[{}]")))
+	assert.False(t, isBatch(json.RawMessage(`{}`)))
+	assert.False(t, isBatch(nil))
+}
+
+func TestConnNotificationOrder(t *testing.T) {
+
+	var (
+		a, b     = newTestPipe()
+		received = make(chan int, 100)
+		server   = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			var params []int
+
+			json.Unmarshal(request.Params, &params)
+
+			time.Sleep(time.Duration(params[0]%3) * time.Millisecond)
+
+			received <- params[0]
+
+			return nil, nil
+		})))
+		client = NewConn(b)
+	)
+
+	defer server.Close()
+	defer client.Close()
+
+	for i := 0; i < 20; i++ {
+
+		assert.NoError(t, client.Notify("Notify", testParams{i}))
+	}
+
+	for i := 0; i < 20; i++ {
+
+		assert.Equal(t, i, <-received)
+	}
+}
+
+func TestConnDuplicateID(t *testing.T) {
+
+	var (
+		a, b    = newTestPipe()
+		release = make(chan struct{})
+		server  = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			<-release
+
+			return request.RequestID, nil
+		})))
+	)
+
+	defer server.Close()
+
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "id": 7, "method": "Wait"}`))
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "id": 7, "method": "Wait"}`))
+
+	var response Response
+
+	message, _ := b.ReadMessage()
+
+	if assert.NoError(t, json.Unmarshal(message, &response)) && assert.NotNil(t, response.Error) {
+
+		assert.Equal(t, 7, response.RequestID)
+		assert.Equal(t, int16(InvalidRequest), response.Error.Code)
+		assert.Equal(t, "duplicate id", response.Error.Data)
+	}
+
+	close(release)
+
+	message, _ = b.ReadMessage()
+
+	if assert.NoError(t, json.Unmarshal(message, &response)) {
+
+		assert.Equal(t, float64(7), response.Result)
+	}
+
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "id": 7, "method": "Wait"}`))
+
+	message, _ = b.ReadMessage()
+
+	response = Response{}
+
+	if assert.NoError(t, json.Unmarshal(message, &response)) {
+
+		assert.Nil(t, response.Error)
+	}
+}
+
+func TestConnShutdown(t *testing.T) {
+
+	var (
+		a, b    = newTestPipe()
+		started = make(chan struct{}, 2)
+		server  = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			started <- struct{}{}
+
+			var params []int
+
+			json.Unmarshal(request.Params, &params)
+
+			select {
+			case <-time.After(time.Duration(params[0]) * time.Millisecond):
+
+				return params[0], nil
+
+			case <-ctx.Done():
+
+				return nil, NewError(InternalError, "canceled")
+			}
+		})))
+		client = NewConn(b)
+		result = make(chan error, 1)
+	)
+
+	go func() {
+
+		result <- client.Send("Wait", testParams{30}, nil)
+	}()
+
+	<-started
+
+	assert.NoError(t, client.Shutdown(context.Background()))
+	assert.NoError(t, <-result)
+	assert.Equal(t, ErrClosed, client.Send("Wait", testParams{0}, nil))
+	assert.Equal(t, ErrClosed, client.Notify("Wait", testParams{0}))
+
+	<-server.Done()
+
+	a, b = newTestPipe()
+	server = NewConn(a, WithHandler(server.handler))
+	client = NewConn(b)
+
+	defer server.Close()
+
+	go func() {
+
+		result <- client.Send("Wait", testParams{10000}, nil)
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, client.Shutdown(ctx))
+	assert.Equal(t, ErrClosed, <-result)
+	assert.NoError(t, client.Shutdown(context.Background()))
+}
+
+func TestConnShutdownRejectsRequests(t *testing.T) {
+
+	var (
+		a, b     = newTestPipe()
+		started  = make(chan struct{}, 1)
+		release  = make(chan struct{})
+		notified = make(chan struct{}, 1)
+		server   = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			if request.Method == "Notify" {
+
+				notified <- struct{}{}
+
+				return nil, nil
+			}
+
+			started <- struct{}{}
+
+			<-release
+
+			return 1, nil
+		})))
+		client   = NewConn(b)
+		result   = make(chan error, 1)
+		shutdown = make(chan error, 1)
+	)
+
+	defer client.Close()
+
+	go func() {
+
+		result <- client.Send("Wait", testParams{}, nil)
+	}()
+
+	<-started
+
+	go func() {
+
+		shutdown <- server.Shutdown(context.Background())
+	}()
+
+	assert.Eventually(t, func() bool {
+
+		return server.Err() != nil
+
+	}, time.Second, time.Millisecond)
+
+	if err, ok := client.Send("Wait", testParams{}, nil).(*Error); assert.True(t, ok) {
+
+		assert.Equal(t, int16(ServerError), err.Code)
+		assert.Equal(t, "shutting down", err.Data)
+	}
+
+	assert.NoError(t, client.Notify("Notify", testParams{}))
+
+	close(release)
+
+	assert.NoError(t, <-result)
+	assert.NoError(t, <-shutdown)
+
+	select {
+	case <-notified:
+
+		t.Fatal("a notification was handled during the shutdown")
+
+	default:
+	}
+}
+
+func TestConnCancelRequest(t *testing.T) {
+
+	var (
+		a, b      = newTestPipe()
+		started   = make(chan struct{}, 1)
+		cancelled = make(chan struct{}, 1)
+		server    = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			started <- struct{}{}
+
+			<-ctx.Done()
+
+			cancelled <- struct{}{}
+
+			return 42, nil
+		})))
+	)
+
+	defer server.Close()
+
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "id": 3, "method": "Wait"}`))
+
+	<-started
+
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "method": "$/cancelRequest", "params": {"id": 4}}`))
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "method": "$/cancelRequest", "params": {"id": 3}}`))
+
+	<-cancelled
+
+	var response Response
+
+	message, _ := b.ReadMessage()
+
+	if assert.NoError(t, json.Unmarshal(message, &response)) && assert.NotNil(t, response.Error) {
+
+		assert.Equal(t, 3, response.RequestID)
+		assert.Equal(t, int16(RequestCancelled), response.Error.Code)
+		assert.Nil(t, response.Result)
+	}
+
+	client := NewConn(b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+
+		<-started
+
+		cancel()
+	}()
+
+	assert.Equal(t, context.Canceled, client.SendContext(ctx, "Wait", testParams{}, nil))
+
+	select {
+	case <-cancelled:
+
+	case <-time.After(time.Second):
+
+		t.Error("the handler was not cancelled")
+	}
+}
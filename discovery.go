@@ -0,0 +1,47 @@
+package jsonrpc2
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// StaticDiscovery is a fixed list of server URLs.
+type StaticDiscovery []string
+
+func (s StaticDiscovery) Get() ([]string, error) {
+
+	return s, nil
+}
+
+// FileDiscovery reads the server URLs from a file, one per line, on every
+// Get so that the balancer picks up changes of the file. Blank lines and
+// lines starting with # are skipped.
+type FileDiscovery string
+
+func (f FileDiscovery) Get() ([]string, error) {
+
+	file, err := os.Open(string(f))
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var (
+		addresses []string
+		scanner   = bufio.NewScanner(file)
+	)
+
+	for scanner.Scan() {
+
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+
+			addresses = append(addresses, line)
+		}
+	}
+
+	return addresses, scanner.Err()
+}
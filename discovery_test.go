@@ -0,0 +1,35 @@
+package jsonrpc2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticDiscovery(t *testing.T) {
+
+	if addresses, err := (StaticDiscovery{"http://a", "http://b"}).Get(); assert.NoError(t, err) {
+
+		assert.Equal(t, []string{"http://a", "http://b"}, addresses)
+	}
+}
+
+func TestFileDiscovery(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "upstreams")
+
+	if !assert.NoError(t, ioutil.WriteFile(file, []byte("# upstreams\nhttp://a\n\n  http://b  \n"), 0644)) {
+
+		return
+	}
+
+	if addresses, err := FileDiscovery(file).Get(); assert.NoError(t, err) {
+
+		assert.Equal(t, []string{"http://a", "http://b"}, addresses)
+	}
+
+	_, err := FileDiscovery(file + ".missing").Get()
+
+	assert.Error(t, err)
+}
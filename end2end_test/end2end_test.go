@@ -50,7 +50,7 @@ func (t *testService) Sum(param *TestSumParam) (*TestSumResult, error) {
 	}, nil
 }
 
-func ExampleClient(url string) *exampleClient {
+func newExampleClient(url string) *exampleClient {
 
 	return &exampleClient{
 		rpc: jsonrpc2.NewClient(&testDiscovery{addresses: []string{url}}),
@@ -98,7 +98,7 @@ func TestEnd2End(t *testing.T) {
 
 	defer testServer.Close()
 
-	client := ExampleClient(testServer.URL)
+	client := newExampleClient(testServer.URL)
 
 	if result, err := client.EmptyParams(); assert.NoError(t, err) {
 
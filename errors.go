@@ -1,22 +1,50 @@
 package jsonrpc2
 
+import (
+	"fmt"
+)
+
 const (
-	ParseError     int16 = -32700
-	InvalidRequest       = -32600
-	MethodNotFound       = -32601
-	InvalidParams        = -32602
-	InternalError        = -32603
-	ServerError          = -32000
-	LogicErr             = -32001
+	ParseError       int16 = -32700
+	InvalidRequest         = -32600
+	MethodNotFound         = -32601
+	InvalidParams          = -32602
+	InternalError          = -32603
+	ServerError            = -32000
+	LogicErr               = -32001
+	Overloaded             = -32002
+	RateLimited            = -32003
+	Unauthorized           = -32004
+	Forbidden              = -32005
+	RequestCancelled       = -32006
 )
 
 var Errors = map[int16]string{
-	ParseError:     "Parse Error",
-	InvalidRequest: "Invalid Request",
-	MethodNotFound: "Method not found",
-	InvalidParams:  "Invalid params",
-	InternalError:  "Internal error",
-	ServerError:    "Server error",
+	ParseError:       "Parse Error",
+	InvalidRequest:   "Invalid Request",
+	MethodNotFound:   "Method not found",
+	InvalidParams:    "Invalid params",
+	InternalError:    "Internal error",
+	ServerError:      "Server error",
+	Overloaded:       "Server overloaded",
+	RateLimited:      "Rate limit exceeded",
+	Unauthorized:     "Unauthorized",
+	Forbidden:        "Forbidden",
+	RequestCancelled: "Request cancelled",
+}
+
+func NewError(code int16, data string) *Error {
+
+	return &Error{
+		Code:    code,
+		Message: Errors[code],
+		Data:    data,
+	}
+}
+
+func (e *Error) Error() string {
+
+	return fmt.Sprintf("%d:%s", e.Code, e.Message)
 }
 
 type LogicError struct {
@@ -0,0 +1,115 @@
+package jsonrpc2
+
+import (
+	"context"
+	"time"
+)
+
+// The methods of a server running calls as jobs. A call of a job method
+// returns the Job at once, its result is read with JobResultMethod once its
+// state is JobDone.
+const (
+	JobStatusMethod = "rpc.job.status"
+	JobResultMethod = "rpc.job.result"
+	JobCancelMethod = "rpc.job.cancel"
+)
+
+type JobState string
+
+const (
+	JobRunning   JobState = "running"
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// Job is the status of a call run in the background.
+type Job struct {
+	ID      string    `json:"id"`
+	Method  string    `json:"method"`
+	State   JobState  `json:"state"`
+	Error   *Error    `json:"error,omitempty"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+type JobParams struct {
+	ID string `json:"id"`
+}
+
+func (p *JobParams) IsValid() bool {
+
+	return p.ID != ""
+}
+
+// CallJob calls a method the server runs as a job and waits for its result
+// with WaitJob. The job is polled and cancelled on the upstream that started
+// it, so the upstreams of a balancing client need not share a JobStore.
+func CallJob(ctx context.Context, client Client, method string, params Params, result interface{}, interval time.Duration) error {
+
+	ctx = pinUpstream(ctx)
+
+	var job Job
+
+	if err := client.SendContext(ctx, method, params, &job); err != nil {
+
+		return err
+	}
+
+	return WaitJob(ctx, client, job.ID, result, interval)
+}
+
+// WaitJob polls the status of a job every interval until it is finished and
+// decodes its result, the error of a failed job is returned as the error of
+// a call. If ctx is done first the job is cancelled. A balancing client may
+// poll any of its upstreams, so they must share a JobStore unless the job was
+// started by CallJob.
+func WaitJob(ctx context.Context, client Client, id string, result interface{}, interval time.Duration) error {
+
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+
+		var job Job
+
+		if err := client.SendContext(ctx, JobStatusMethod, &JobParams{ID: id}, &job); err != nil {
+
+			if ctx.Err() != nil {
+
+				cancelJob(ctx, client, id)
+
+				return ctx.Err()
+			}
+
+			return err
+		}
+
+		if job.State != JobRunning {
+
+			return client.SendContext(ctx, JobResultMethod, &JobParams{ID: id}, result)
+		}
+
+		select {
+		case <-ticker.C:
+
+		case <-ctx.Done():
+
+			cancelJob(ctx, client, id)
+
+			return ctx.Err()
+		}
+	}
+}
+
+// cancelJob cancels a job once ctx is done, the values of ctx still pin the
+// call to the upstream of the job.
+func cancelJob(ctx context.Context, client Client, id string) {
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+
+	defer cancel()
+
+	client.SendContext(ctx, JobCancelMethod, &JobParams{ID: id}, nil)
+}
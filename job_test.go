@@ -0,0 +1,130 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// jobClient answers the job methods with the states in order.
+type jobClient struct {
+	states []JobState
+	calls  []string
+}
+
+func (c *jobClient) Send(method string, params Params, result interface{}) error {
+
+	return c.SendContext(context.Background(), method, params, result)
+}
+
+func (c *jobClient) SendContext(ctx context.Context, method string, params Params, result interface{}) error {
+
+	c.calls = append(c.calls, method)
+
+	switch method {
+	case JobStatusMethod:
+
+		job := result.(*Job)
+		job.State = c.states[0]
+
+		if len(c.states) > 1 {
+
+			c.states = c.states[1:]
+		}
+
+	case JobResultMethod:
+
+		return json.Unmarshal([]byte(`"report"`), result)
+
+	case JobCancelMethod:
+
+	default:
+
+		result.(*Job).ID = "1"
+	}
+
+	return nil
+}
+
+func TestCallJob(t *testing.T) {
+
+	client := &jobClient{states: []JobState{JobRunning, JobRunning, JobDone}}
+
+	var result string
+
+	if assert.NoError(t, CallJob(context.Background(), client, "Report.Build", &EmptyParams{}, &result, time.Millisecond)) {
+
+		assert.Equal(t, "report", result)
+		assert.Equal(t, []string{"Report.Build", JobStatusMethod, JobStatusMethod, JobStatusMethod, JobResultMethod}, client.calls)
+	}
+}
+
+func TestWaitJobCancel(t *testing.T) {
+
+	client := &jobClient{states: []JobState{JobRunning}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+	defer cancel()
+
+	var result string
+
+	assert.Equal(t, context.DeadlineExceeded, WaitJob(ctx, client, "1", &result, time.Millisecond))
+	assert.Equal(t, JobCancelMethod, client.calls[len(client.calls)-1])
+}
+
+func TestCallJobPinsUpstream(t *testing.T) {
+
+	newUpstream := func() *httptest.Server {
+
+		var started bool
+
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			var request Request
+
+			json.NewDecoder(r.Body).Decode(&request)
+
+			response := Response{Jsonrpc: "2.0", RequestID: request.RequestID}
+
+			switch {
+			case request.Method == "Report.Build":
+
+				started, response.Result = true, &Job{ID: "1", State: JobRunning}
+
+			case !started:
+
+				response.Error = NewError(InvalidParams, "unknown job")
+
+			case request.Method == JobStatusMethod:
+
+				response.Result = &Job{ID: "1", State: JobDone}
+
+			default:
+
+				response.Result = "report"
+			}
+
+			json.NewEncoder(w).Encode(&response)
+		}))
+	}
+
+	a, b := newUpstream(), newUpstream()
+
+	defer a.Close()
+	defer b.Close()
+
+	var (
+		client = NewClient(&testDiscovery{addresses: []string{a.URL, b.URL}})
+		result string
+	)
+
+	if assert.NoError(t, CallJob(context.Background(), client, "Report.Build", &EmptyParams{}, &result, time.Millisecond)) {
+
+		assert.Equal(t, "report", result)
+	}
+}
@@ -0,0 +1,49 @@
+package jsonrpc2test
+
+import (
+	"sync"
+)
+
+// Discovery is an in-memory jsonrpc2.Discovery whose addresses and error can
+// be changed while a client uses it.
+type Discovery struct {
+	mutex     *sync.Mutex
+	addresses []string
+	err       error
+}
+
+func NewDiscovery(addresses ...string) *Discovery {
+
+	return &Discovery{
+		mutex:     &sync.Mutex{},
+		addresses: addresses,
+	}
+}
+
+func (d *Discovery) Get() ([]string, error) {
+
+	d.mutex.Lock()
+
+	defer d.mutex.Unlock()
+
+	if d.err != nil {
+
+		return nil, d.err
+	}
+
+	return append([]string(nil), d.addresses...), nil
+}
+
+func (d *Discovery) Set(addresses ...string) {
+
+	d.mutex.Lock()
+	d.addresses = addresses
+	d.mutex.Unlock()
+}
+
+func (d *Discovery) SetError(err error) {
+
+	d.mutex.Lock()
+	d.err = err
+	d.mutex.Unlock()
+}
@@ -0,0 +1,37 @@
+package jsonrpc2test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiscovery(t *testing.T) {
+
+	d := NewDiscovery("http://a")
+
+	if addresses, err := d.Get(); assert.NoError(t, err) {
+
+		assert.Equal(t, []string{"http://a"}, addresses)
+	}
+
+	d.Set("http://b", "http://c")
+
+	if addresses, err := d.Get(); assert.NoError(t, err) {
+
+		assert.Equal(t, []string{"http://b", "http://c"}, addresses)
+	}
+
+	d.SetError(errors.New("unavailable"))
+
+	_, err := d.Get()
+
+	assert.EqualError(t, err, "unavailable")
+
+	d.SetError(nil)
+
+	if addresses, err := d.Get(); assert.NoError(t, err) {
+
+		assert.Len(t, addresses, 2)
+	}
+}
@@ -0,0 +1,121 @@
+package jsonrpc2test
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Expectation is the programmed answer to calls of a method. Without params
+// or a matcher it matches every call of the method.
+type Expectation struct {
+	method  string
+	match   func(params json.RawMessage) bool
+	result  interface{}
+	err     error
+	latency time.Duration
+	times   int
+	calls   int
+}
+
+// WithParams matches calls whose params are equal to params as JSON.
+func (e *Expectation) WithParams(params interface{}) *Expectation {
+
+	e.match = equalParams(params)
+
+	return e
+}
+
+func (e *Expectation) Match(fn func(params json.RawMessage) bool) *Expectation {
+
+	e.match = fn
+
+	return e
+}
+
+func (e *Expectation) Return(result interface{}) *Expectation {
+
+	e.result = result
+
+	return e
+}
+
+// ReturnError answers the call with err. A *jsonrpc2.Error is sent as is,
+// any other error as a logic error with its message.
+func (e *Expectation) ReturnError(err error) *Expectation {
+
+	e.err = err
+
+	return e
+}
+
+func (e *Expectation) After(latency time.Duration) *Expectation {
+
+	e.latency = latency
+
+	return e
+}
+
+// Times limits how often the expectation matches. Calls beyond the limit are
+// matched by the following expectations of the method.
+func (e *Expectation) Times(n int) *Expectation {
+
+	e.times = n
+
+	return e
+}
+
+func (e *Expectation) Once() *Expectation {
+
+	return e.Times(1)
+}
+
+func (e *Expectation) matches(method string, params json.RawMessage) bool {
+
+	if e.method != method || (e.times > 0 && e.calls >= e.times) {
+
+		return false
+	}
+
+	return e.match == nil || e.match(params)
+}
+
+func (e *Expectation) met() bool {
+
+	if e.times > 0 {
+
+		return e.calls == e.times
+	}
+
+	return e.calls > 0
+}
+
+func equalParams(params interface{}) func(json.RawMessage) bool {
+
+	var (
+		data, _  = json.Marshal(params)
+		expected interface{}
+	)
+
+	if raw, ok := params.(json.RawMessage); ok {
+
+		data = raw
+	}
+
+	json.Unmarshal(data, &expected)
+
+	return func(actual json.RawMessage) bool {
+
+		var v interface{}
+
+		if len(actual) != 0 {
+
+			if err := json.Unmarshal(actual, &v); err != nil {
+
+				return false
+			}
+		}
+
+		return reflect.DeepEqual(expected, v)
+	}
+}
@@ -0,0 +1,61 @@
+package jsonrpc2test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExpectationMatches(t *testing.T) {
+
+	e := (&Expectation{method: "Sum"}).WithParams(map[string]int{"a": 1, "b": 2})
+
+	assert.True(t, e.matches("Sum", json.RawMessage(`{"b": 2, "a": 1}`)))
+	assert.False(t, e.matches("Sum", json.RawMessage(`{"a": 1}`)))
+	assert.False(t, e.matches("Sum", json.RawMessage(`{`)))
+	assert.False(t, e.matches("Div", json.RawMessage(`{"a": 1, "b": 2}`)))
+
+	e.WithParams(json.RawMessage(`[1, "x"]`))
+
+	assert.True(t, e.matches("Sum", json.RawMessage(`[1, "x"]`)))
+
+	e.WithParams(nil)
+
+	assert.True(t, e.matches("Sum", nil))
+	assert.True(t, e.matches("Sum", json.RawMessage(`null`)))
+
+	e.Match(func(params json.RawMessage) bool {
+
+		return len(params) > 10
+	})
+
+	assert.True(t, e.matches("Sum", json.RawMessage(`{"a": 10000}`)))
+	assert.False(t, e.matches("Sum", json.RawMessage(`{}`)))
+}
+
+func TestExpectationTimes(t *testing.T) {
+
+	e := (&Expectation{method: "Sum"}).Times(2)
+
+	assert.False(t, e.met())
+
+	for i := 0; i < 2; i++ {
+
+		if assert.True(t, e.matches("Sum", nil)) {
+
+			e.calls++
+		}
+	}
+
+	assert.False(t, e.matches("Sum", nil))
+	assert.True(t, e.met())
+
+	e = &Expectation{method: "Sum"}
+
+	assert.False(t, e.met())
+
+	e.calls = 3
+
+	assert.True(t, e.met())
+	assert.Equal(t, 1, e.Once().times)
+}
@@ -0,0 +1,158 @@
+package jsonrpc2test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Fault is applied to the HTTP requests of the mock server before they are
+// handled. A request is delayed, then dropped by closing the connection
+// without a response or answered with the HTTP status, whichever is set.
+type Fault struct {
+	// Method limits the fault to requests holding a call of the method.
+	Method string
+	Delay  time.Duration
+	Drop   bool
+	Status int
+	// Times limits how many requests the fault is applied to, 0 for all.
+	Times int
+	// Probability applies the fault to a share of the requests, 0 for all.
+	Probability float64
+}
+
+type fault struct {
+	Fault
+	applied int
+}
+
+// Inject adds a fault. Faults are matched in the order they were added and
+// at most one is applied to a request.
+func (s *Server) Inject(f Fault) {
+
+	s.mutex.Lock()
+	s.faults = append(s.faults, &fault{Fault: f})
+	s.mutex.Unlock()
+}
+
+func (s *Server) ClearFaults() {
+
+	s.mutex.Lock()
+	s.faults = nil
+	s.mutex.Unlock()
+}
+
+func (s *Server) fault(methods []string) *Fault {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	for _, f := range s.faults {
+
+		if f.Times > 0 && f.applied >= f.Times {
+
+			continue
+		}
+
+		if f.Method != "" && !contains(methods, f.Method) {
+
+			continue
+		}
+
+		if f.Probability > 0 && f.Probability < 1 && s.random.Float64() >= f.Probability {
+
+			continue
+		}
+
+		f.applied++
+
+		applied := f.Fault
+
+		return &applied
+	}
+
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+
+		return
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if f := s.fault(methodsOf(body)); f != nil {
+
+		if f.Delay > 0 {
+
+			select {
+			case <-time.After(f.Delay):
+
+			case <-r.Context().Done():
+
+				return
+			}
+		}
+
+		if f.Drop {
+
+			panic(http.ErrAbortHandler)
+		}
+
+		if f.Status != 0 {
+
+			http.Error(w, http.StatusText(f.Status), f.Status)
+
+			return
+		}
+	}
+
+	s.rpc.ServeHTTP(w, r)
+}
+
+func methodsOf(body []byte) []string {
+
+	var (
+		call  struct{ Method string }
+		batch []struct{ Method string }
+	)
+
+	if err := json.Unmarshal(body, &batch); err == nil {
+
+		methods := make([]string, 0, len(batch))
+
+		for _, call := range batch {
+
+			methods = append(methods, call.Method)
+		}
+
+		return methods
+	}
+
+	if err := json.Unmarshal(body, &call); err == nil {
+
+		return []string{call.Method}
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+
+	for _, v := range list {
+
+		if v == s {
+
+			return true
+		}
+	}
+
+	return false
+}
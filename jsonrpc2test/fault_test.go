@@ -0,0 +1,88 @@
+package jsonrpc2test
+
+import (
+	"bytes"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFaults(t *testing.T) {
+
+	s := NewServer()
+
+	defer s.Close()
+
+	s.On("Ping").Return("pong")
+	s.On("Other").Return("ok")
+
+	s.Inject(Fault{Status: http.StatusServiceUnavailable, Times: 1})
+	s.Inject(Fault{Drop: true, Method: "Ping", Times: 1})
+	s.Inject(Fault{Delay: 100 * time.Millisecond, Times: 1})
+
+	client := s.Client()
+
+	var result string
+
+	assert.Error(t, client.Send("Ping", &jsonrpc2.EmptyParams{}, &result))
+	assert.Error(t, client.Send("Ping", &jsonrpc2.EmptyParams{}, &result))
+
+	start := time.Now()
+
+	if assert.NoError(t, client.Send("Ping", &jsonrpc2.EmptyParams{}, &result)) {
+
+		assert.Equal(t, "pong", result)
+		assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	}
+
+	assert.NoError(t, client.Send("Ping", &jsonrpc2.EmptyParams{}, &result))
+	assert.True(t, s.AssertNumberOfCalls(t, "Ping", 2))
+
+	s.Inject(Fault{Status: http.StatusBadGateway, Method: "Ping"})
+
+	assert.NoError(t, client.Send("Other", &jsonrpc2.EmptyParams{}, &result))
+	assert.Error(t, client.Send("Ping", &jsonrpc2.EmptyParams{}, &result))
+
+	response, err := http.Post(s.URL, "application/json", bytes.NewReader([]byte(`[{"jsonrpc": "2.0", "id": 1, "method": "Other"}, {"jsonrpc": "2.0", "id": 2, "method": "Ping"}]`)))
+
+	if assert.NoError(t, err) {
+
+		assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+
+		response.Body.Close()
+	}
+
+	s.ClearFaults()
+
+	assert.NoError(t, client.Send("Ping", &jsonrpc2.EmptyParams{}, &result))
+}
+
+func TestFaultProbability(t *testing.T) {
+
+	s := NewServer()
+
+	defer s.Close()
+
+	s.Inject(Fault{Status: http.StatusInternalServerError, Probability: 0.5})
+
+	var failed int
+
+	for i := 0; i < 200; i++ {
+
+		if f := s.fault([]string{"Ping"}); f != nil {
+
+			failed++
+		}
+	}
+
+	assert.InDelta(t, 100, failed, 30)
+}
+
+func TestMethodsOf(t *testing.T) {
+
+	assert.Equal(t, []string{"A"}, methodsOf([]byte(`{"method": "A"}`)))
+	assert.Equal(t, []string{"A", "B"}, methodsOf([]byte(`[{"method": "A"}, {"method": "B"}]`)))
+	assert.Nil(t, methodsOf([]byte(`{`)))
+}
@@ -0,0 +1,308 @@
+package jsonrpc2test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Call is a call received by the mock server.
+type Call struct {
+	Method string
+	Params json.RawMessage
+	Header http.Header
+	Time   time.Time
+}
+
+// NewServer starts a mock server. It answers calls with the programmed
+// expectations, records them and applies the injected faults. Calls of
+// methods without expectations are answered with method not found, calls
+// whose params match none of the expectations of the method with invalid
+// params.
+func NewServer() *Server {
+
+	s := &Server{
+		mutex:  &sync.Mutex{},
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	rpc := server.New()
+	rpc.SetBatchConcurrency(8)
+	rpc.Use(s.middleware)
+
+	s.rpc = rpc
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.httpServer.URL
+
+	return s
+}
+
+type Server struct {
+	URL          string
+	rpc          http.Handler
+	httpServer   *httptest.Server
+	mutex        *sync.Mutex
+	random       *rand.Rand
+	expectations []*Expectation
+	calls        []Call
+	faults       []*fault
+}
+
+func (s *Server) Close() {
+
+	s.httpServer.Close()
+}
+
+// Discovery returns an in-memory discovery with the address of the server.
+func (s *Server) Discovery() *Discovery {
+
+	return NewDiscovery(s.URL)
+}
+
+func (s *Server) Client(options ...jsonrpc2.ClientOption) jsonrpc2.Client {
+
+	return jsonrpc2.NewClient(s.Discovery(), options...)
+}
+
+// On adds an expectation for method. Expectations are matched in the order
+// they were added.
+func (s *Server) On(method string) *Expectation {
+
+	e := &Expectation{
+		method: method,
+	}
+
+	s.mutex.Lock()
+	s.expectations = append(s.expectations, e)
+	s.mutex.Unlock()
+
+	return e
+}
+
+// Reset removes the expectations, the recorded calls and the faults.
+func (s *Server) Reset() {
+
+	s.mutex.Lock()
+	s.expectations = nil
+	s.calls = nil
+	s.faults = nil
+	s.mutex.Unlock()
+}
+
+func (s *Server) Calls() []Call {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	return append([]Call(nil), s.calls...)
+}
+
+func (s *Server) CallsTo(method string) []Call {
+
+	var calls []Call
+
+	for _, call := range s.Calls() {
+
+		if call.Method == method {
+
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+func (s *Server) middleware(next server.HandlerFunc) server.HandlerFunc {
+
+	return func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+		call := Call{
+			Method: request.Method,
+			Params: request.Params,
+			Time:   time.Now(),
+		}
+
+		if r := server.HTTPRequest(ctx); r != nil {
+
+			call.Header = r.Header
+		}
+
+		var (
+			expectation *Expectation
+			known       bool
+		)
+
+		s.mutex.Lock()
+
+		s.calls = append(s.calls, call)
+
+		for _, e := range s.expectations {
+
+			known = known || e.method == request.Method
+
+			if e.matches(request.Method, request.Params) {
+
+				e.calls++
+
+				expectation = e
+
+				break
+			}
+		}
+
+		s.mutex.Unlock()
+
+		if expectation == nil {
+
+			if known {
+
+				return nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "no expectation matches the params")
+			}
+
+			return next(ctx, request)
+		}
+
+		if expectation.latency > 0 {
+
+			select {
+			case <-time.After(expectation.latency):
+
+			case <-ctx.Done():
+
+				return nil, ctx.Err()
+			}
+		}
+
+		if expectation.err != nil {
+
+			return nil, expectation.err
+		}
+
+		return expectation.result, nil
+	}
+}
+
+// AssertCalled checks that method was called, with params equal as JSON if
+// they are given.
+func (s *Server) AssertCalled(t testing.TB, method string, params ...interface{}) bool {
+
+	t.Helper()
+
+	calls := s.CallsTo(method)
+
+	if len(params) == 0 && len(calls) != 0 {
+
+		return true
+	}
+
+	for _, call := range calls {
+
+		if len(params) != 0 && equalParams(params[0])(call.Params) {
+
+			return true
+		}
+	}
+
+	if len(params) != 0 {
+
+		t.Errorf("%s was not called with params %s, calls: %s", method, marshal(params[0]), paramsOf(calls))
+
+		return false
+	}
+
+	t.Errorf("%s was not called", method)
+
+	return false
+}
+
+func (s *Server) AssertNotCalled(t testing.TB, method string) bool {
+
+	t.Helper()
+
+	if calls := s.CallsTo(method); len(calls) != 0 {
+
+		t.Errorf("%s was called %d times, calls: %s", method, len(calls), paramsOf(calls))
+
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) AssertNumberOfCalls(t testing.TB, method string, n int) bool {
+
+	t.Helper()
+
+	if calls := s.CallsTo(method); len(calls) != n {
+
+		t.Errorf("%s was called %d times, expected %d", method, len(calls), n)
+
+		return false
+	}
+
+	return true
+}
+
+// AssertExpectations checks that every expectation was matched: exactly as
+// often as set with Times, or at least once.
+func (s *Server) AssertExpectations(t testing.TB) bool {
+
+	t.Helper()
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	ok := true
+
+	for _, e := range s.expectations {
+
+		if !e.met() {
+
+			if e.times > 0 {
+
+				t.Errorf("%s was matched %d times, expected %d", e.method, e.calls, e.times)
+
+			} else {
+
+				t.Errorf("%s was not called", e.method)
+			}
+
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+func marshal(v interface{}) string {
+
+	if raw, ok := v.(json.RawMessage); ok {
+
+		return string(raw)
+	}
+
+	data, _ := json.Marshal(v)
+
+	return string(data)
+}
+
+func paramsOf(calls []Call) []string {
+
+	params := make([]string, 0, len(calls))
+
+	for _, call := range calls {
+
+		params = append(params, string(call.Params))
+	}
+
+	return params
+}
@@ -0,0 +1,169 @@
+package jsonrpc2test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sumParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (p *sumParams) IsValid() bool {
+
+	return true
+}
+
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestServer(t *testing.T) {
+
+	s := NewServer()
+
+	defer s.Close()
+
+	s.On("Math.Sum").WithParams(&sumParams{A: 1, B: 2}).Return(3)
+	s.On("Math.Sum").WithParams(&sumParams{A: 1, B: 1}).Return(2).Once()
+	s.On("Math.Div").ReturnError(jsonrpc2.NewError(jsonrpc2.InvalidParams, "division by zero"))
+	s.On("Math.Fail").ReturnError(errors.New("failed"))
+
+	client := s.Client()
+
+	var result int
+
+	if assert.NoError(t, client.Send("Math.Sum", &sumParams{A: 1, B: 2}, &result)) {
+
+		assert.Equal(t, 3, result)
+	}
+
+	if assert.NoError(t, client.Send("Math.Sum", &sumParams{A: 1, B: 1}, &result)) {
+
+		assert.Equal(t, 2, result)
+	}
+
+	if err, ok := client.Send("Math.Sum", &sumParams{A: 1, B: 1}, &result).(*jsonrpc2.Error); assert.True(t, ok) {
+
+		assert.Equal(t, int16(jsonrpc2.InvalidParams), err.Code)
+		assert.Equal(t, "no expectation matches the params", err.Data)
+	}
+
+	if err, ok := client.Send("Math.Div", &sumParams{}, &result).(*jsonrpc2.Error); assert.True(t, ok) {
+
+		assert.Equal(t, "division by zero", err.Data)
+	}
+
+	assert.EqualError(t, client.Send("Math.Fail", &jsonrpc2.EmptyParams{}, &result), "failed")
+
+	if err, ok := client.Send("Math.Unknown", &jsonrpc2.EmptyParams{}, &result).(*jsonrpc2.Error); assert.True(t, ok) {
+
+		assert.Equal(t, int16(jsonrpc2.MethodNotFound), err.Code)
+	}
+
+	assert.Len(t, s.Calls(), 6)
+	assert.Len(t, s.CallsTo("Math.Sum"), 3)
+	assert.True(t, s.AssertCalled(t, "Math.Sum"))
+	assert.True(t, s.AssertCalled(t, "Math.Sum", map[string]int{"a": 1, "b": 1}))
+	assert.True(t, s.AssertNotCalled(t, "Math.Mul"))
+	assert.True(t, s.AssertNumberOfCalls(t, "Math.Div", 1))
+	assert.True(t, s.AssertExpectations(t))
+
+	r := &recorder{}
+
+	assert.False(t, s.AssertCalled(r, "Math.Mul"))
+	assert.False(t, s.AssertCalled(r, "Math.Sum", `{"a": 5}`))
+	assert.False(t, s.AssertNotCalled(r, "Math.Sum"))
+	assert.False(t, s.AssertNumberOfCalls(r, "Math.Sum", 1))
+
+	s.On("Math.Mul")
+	s.On("Math.Pow").Times(2)
+
+	assert.False(t, s.AssertExpectations(r))
+
+	if assert.Len(t, r.errors, 6) {
+
+		assert.Equal(t, "Math.Mul was not called", r.errors[0])
+		assert.True(t, strings.HasPrefix(r.errors[1], `Math.Sum was not called with params "{\"a\": 5}"`), r.errors[1])
+		assert.Equal(t, "Math.Sum was called 3 times, expected 1", r.errors[3])
+		assert.Equal(t, "Math.Pow was matched 0 times, expected 2", r.errors[5])
+	}
+
+	s.Reset()
+
+	assert.Empty(t, s.Calls())
+	assert.True(t, s.AssertExpectations(t))
+}
+
+func TestServerLatency(t *testing.T) {
+
+	s := NewServer()
+
+	defer s.Close()
+
+	s.On("Slow").Return("ok").After(100 * time.Millisecond)
+
+	var (
+		result string
+		start  = time.Now()
+	)
+
+	if assert.NoError(t, s.Client().Send("Slow", &jsonrpc2.EmptyParams{}, &result)) {
+
+		assert.Equal(t, "ok", result)
+		assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+
+	defer cancel()
+
+	assert.Error(t, s.Client().SendContext(ctx, "Slow", &jsonrpc2.EmptyParams{}, &result))
+}
+
+func TestServerRecordsHeaders(t *testing.T) {
+
+	s := NewServer()
+
+	defer s.Close()
+
+	s.On("Ping").Return("pong")
+
+	client := s.Client(jsonrpc2.WithHTTPClient(&http.Client{
+		Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+
+			r.Header.Set("X-Request-Id", "42")
+
+			return http.DefaultTransport.RoundTrip(r)
+		}),
+	}))
+
+	if assert.NoError(t, client.Send("Ping", &jsonrpc2.EmptyParams{}, nil)) && assert.Len(t, s.Calls(), 1) {
+
+		assert.Equal(t, "42", s.Calls()[0].Header.Get("X-Request-Id"))
+		assert.JSONEq(t, `{}`, string(s.Calls()[0].Params))
+	}
+}
+
+type roundTripper func(r *http.Request) (*http.Response, error)
+
+func (fn roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+
+	return fn(r)
+}
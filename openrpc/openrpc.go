@@ -0,0 +1,56 @@
+package openrpc
+
+import (
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/schema"
+)
+
+const Version = "1.2.6"
+
+type Document struct {
+	OpenRPC string   `json:"openrpc"`
+	Info    Info     `json:"info"`
+	Methods []Method `json:"methods"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Method struct {
+	Name           string              `json:"name"`
+	Tags           []Tag               `json:"tags,omitempty"`
+	Summary        string              `json:"summary,omitempty"`
+	Description    string              `json:"description,omitempty"`
+	Params         []ContentDescriptor `json:"params"`
+	Result         *ContentDescriptor  `json:"result,omitempty"`
+	Errors         []jsonrpc2.Error    `json:"errors,omitempty"`
+	Deprecated     bool                `json:"deprecated,omitempty"`
+	ParamStructure string              `json:"paramStructure,omitempty"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type ContentDescriptor struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *schema.Schema `json:"schema"`
+}
+
+func (d *Document) Method(name string) (*Method, bool) {
+
+	for i := range d.Methods {
+
+		if d.Methods[i].Name == name {
+
+			return &d.Methods[i], true
+		}
+	}
+
+	return nil, false
+}
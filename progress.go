@@ -0,0 +1,134 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Progress is the notification reporting the progress of a request in
+// flight. Its params carry the id of the request, the progressToken of the
+// request params if it had one and the reported value.
+const Progress = "$/progress"
+
+type progressParams struct {
+	ID    int             `json:"id"`
+	Token json.RawMessage `json:"token,omitempty"`
+	Value interface{}     `json:"value"`
+}
+
+func (p *progressParams) IsValid() bool {
+
+	return true
+}
+
+// Reporter reports the progress of a request to the peer that sent it.
+type Reporter struct {
+	conn  *Conn
+	id    int
+	token json.RawMessage
+}
+
+type reporterKey struct{}
+
+// ReporterFromContext returns the reporter of the request a handler was
+// called for. It is nil if the request was not received on a Conn, Report
+// of a nil reporter does nothing.
+func ReporterFromContext(ctx context.Context) *Reporter {
+
+	r, _ := ctx.Value(reporterKey{}).(*Reporter)
+
+	return r
+}
+
+func (r *Reporter) Report(value interface{}) error {
+
+	if r == nil {
+
+		return nil
+	}
+
+	return r.conn.Notify(Progress, &progressParams{
+		ID:    r.id,
+		Token: r.token,
+		Value: value,
+	})
+}
+
+// ProgressFunc receives the values reported for a call. It is called by the
+// goroutine reading the connection, before the response, and must not block.
+type ProgressFunc func(value json.RawMessage)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose calls on a Conn pass the progress
+// reported by the peer to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// WithProgressChannel is WithProgress sending the values to ch, they are
+// dropped while ch is full.
+func WithProgressChannel(ctx context.Context, ch chan<- json.RawMessage) context.Context {
+
+	return WithProgress(ctx, func(value json.RawMessage) {
+
+		select {
+		case ch <- value:
+
+		default:
+		}
+	})
+}
+
+func progressFromContext(ctx context.Context) ProgressFunc {
+
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+
+	return fn
+}
+
+// withReporter adds the reporter of the request message with id to ctx.
+func (c *Conn) withReporter(ctx context.Context, id int, message json.RawMessage) context.Context {
+
+	var m struct {
+		Params struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"params"`
+	}
+
+	json.Unmarshal(message, &m)
+
+	return context.WithValue(ctx, reporterKey{}, &Reporter{
+		conn:  c,
+		id:    id,
+		token: m.Params.ProgressToken,
+	})
+}
+
+// progress passes a progress notification to the callback of its call.
+func (c *Conn) progress(message json.RawMessage) {
+
+	var m struct {
+		Params struct {
+			ID    int             `json:"id"`
+			Value json.RawMessage `json:"value"`
+		} `json:"params"`
+	}
+
+	if json.Unmarshal(message, &m) != nil {
+
+		return
+	}
+
+	c.mutex.Lock()
+
+	fn, found := c.progressFuncs[m.Params.ID]
+
+	c.mutex.Unlock()
+
+	if found {
+
+		fn(m.Params.Value)
+	}
+}
@@ -0,0 +1,107 @@
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type progressTestParams struct {
+	ProgressToken string `json:"progressToken,omitempty"`
+}
+
+func (p *progressTestParams) IsValid() bool {
+
+	return true
+}
+
+func TestProgress(t *testing.T) {
+
+	var (
+		a, b   = newTestPipe()
+		server = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			reporter := ReporterFromContext(ctx)
+
+			for i := 1; i <= 3; i++ {
+
+				reporter.Report(i)
+			}
+
+			return "done", nil
+		})))
+		client = NewConn(b)
+		values []int
+	)
+
+	defer server.Close()
+	defer client.Close()
+
+	ctx := WithProgress(context.Background(), func(value json.RawMessage) {
+
+		var v int
+
+		json.Unmarshal(value, &v)
+
+		values = append(values, v)
+	})
+
+	var result string
+
+	if assert.NoError(t, client.SendContext(ctx, "Report", &progressTestParams{}, &result)) {
+
+		assert.Equal(t, "done", result)
+		assert.Equal(t, []int{1, 2, 3}, values)
+	}
+
+	assert.NoError(t, client.Send("Report", &progressTestParams{}, &result))
+	assert.Empty(t, client.progressFuncs)
+
+	ch := make(chan json.RawMessage, 2)
+
+	if assert.NoError(t, client.SendContext(WithProgressChannel(context.Background(), ch), "Report", &progressTestParams{}, &result)) {
+
+		assert.Len(t, ch, 2)
+		assert.Equal(t, "1", string(<-ch))
+	}
+}
+
+func TestProgressToken(t *testing.T) {
+
+	var (
+		a, b   = newTestPipe()
+		server = NewConn(a, WithHandler(testHandler(func(ctx context.Context, request *ServerRequest) (interface{}, *Error) {
+
+			ReporterFromContext(ctx).Report(50)
+
+			return nil, nil
+		})))
+	)
+
+	defer server.Close()
+
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "id": 5, "method": "Report", "params": {"progressToken": "report-1"}}`))
+
+	message, _ := b.ReadMessage()
+
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "method": "$/progress", "params": {"id": 5, "token": "report-1", "value": 50}}`, string(message))
+
+	b.ReadMessage()
+
+	b.WriteMessage(json.RawMessage(`{"jsonrpc": "2.0", "id": 6, "method": "Report", "params": [1]}`))
+
+	message, _ = b.ReadMessage()
+
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "method": "$/progress", "params": {"id": 6, "value": 50}}`, string(message))
+}
+
+func TestReporterWithoutConn(t *testing.T) {
+
+	reporter := ReporterFromContext(context.Background())
+
+	if assert.Nil(t, reporter) {
+
+		assert.NoError(t, reporter.Report(1))
+	}
+}
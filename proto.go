@@ -15,6 +15,32 @@ func (_ EmptyParams) IsValid() bool {
 	return true
 }
 
+// RawParams are params already encoded, empty params are sent as an empty
+// object.
+type RawParams json.RawMessage
+
+func (p RawParams) IsValid() bool {
+
+	return true
+}
+
+func (p RawParams) MarshalJSON() ([]byte, error) {
+
+	if len(p) == 0 {
+
+		return []byte("{}"), nil
+	}
+
+	return p, nil
+}
+
+func (p *RawParams) UnmarshalJSON(data []byte) error {
+
+	*p = append((*p)[:0], data...)
+
+	return nil
+}
+
 type Request struct {
 	Jsonrpc   string `json:"jsonrpc"`
 	RequestID int    `json:"id"`
@@ -0,0 +1,31 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRawParams(t *testing.T) {
+
+	for params, expected := range map[string]string{
+		"":         `{}`,
+		`[1, 2]`:   `[1, 2]`,
+		`{"a": 1}`: `{"a": 1}`,
+	} {
+
+		if data, err := json.Marshal(RawParams(params)); assert.NoError(t, err) {
+
+			assert.JSONEq(t, expected, string(data))
+		}
+	}
+
+	var call struct {
+		Params RawParams `json:"params"`
+	}
+
+	if assert.NoError(t, json.Unmarshal([]byte(`{"params": {"a": [1]}}`), &call)) {
+
+		assert.Equal(t, `{"a": [1]}`, string(call.Params))
+	}
+}
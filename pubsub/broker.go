@@ -0,0 +1,350 @@
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"sync"
+)
+
+// Policy is what a subscription does when its buffer is full.
+type Policy int
+
+const (
+	// Drop drops the event.
+	Drop Policy = iota
+	// Block blocks Send until the event fits in the buffer.
+	Block
+	// Disconnect closes the connection of the subscriber.
+	Disconnect
+)
+
+var (
+	ErrUnsubscribed = errors.New("pubsub: unsubscribed")
+	ErrSlowConsumer = errors.New("pubsub: slow consumer disconnected")
+)
+
+// Method is the method of the notifications carrying the events.
+const Method = "subscription"
+
+type Option func(*Broker)
+
+// WithBuffer sets the number of events buffered per subscription, 64 by
+// default.
+func WithBuffer(size int) Option {
+
+	return func(b *Broker) {
+
+		b.buffer = size
+	}
+}
+
+// WithPolicy sets the policy of the subscriptions, Drop by default.
+func WithPolicy(policy Policy) Option {
+
+	return func(b *Broker) {
+
+		b.policy = policy
+	}
+}
+
+type Registrar interface {
+	RegisterFunc(method string, fn interface{})
+}
+
+func NewBroker(options ...Option) *Broker {
+
+	b := &Broker{
+		buffer:        64,
+		mutex:         &sync.Mutex{},
+		subscriptions: make(map[string]*Subscription),
+	}
+
+	for _, option := range options {
+
+		option(b)
+	}
+
+	return b
+}
+
+// Broker keeps the subscriptions of the clients of a server.
+type Broker struct {
+	buffer        int
+	policy        Policy
+	mutex         *sync.Mutex
+	subscriptions map[string]*Subscription
+}
+
+type unsubscribeParams struct {
+	Subscription string `json:"subscription"`
+}
+
+func (p *unsubscribeParams) IsValid() bool {
+
+	return p.Subscription != ""
+}
+
+// Register registers rpc.unsubscribe on s, it ends a subscription of the
+// connection it is called on.
+func (b *Broker) Register(s Registrar) {
+
+	s.RegisterFunc("rpc.unsubscribe", func(ctx context.Context, params *unsubscribeParams) (bool, error) {
+
+		b.mutex.Lock()
+
+		sub, found := b.subscriptions[params.Subscription]
+
+		b.mutex.Unlock()
+
+		if !found || sub.conn != jsonrpc2.ConnFromContext(ctx) {
+
+			return false, nil
+		}
+
+		sub.finish()
+
+		return true, nil
+	})
+}
+
+// Subscribe creates a subscription of the client of a call, the handler
+// returns its ID and sends the events to it. It fails if the call was not
+// received on a persistent connection.
+func (b *Broker) Subscribe(ctx context.Context) (*Subscription, error) {
+
+	conn := jsonrpc2.ConnFromContext(ctx)
+
+	if conn == nil {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.ServerError, "subscriptions require a persistent connection")
+	}
+
+	id := make([]byte, 8)
+
+	if _, err := rand.Read(id); err != nil {
+
+		return nil, err
+	}
+
+	sub := &Subscription{
+		id:      hex.EncodeToString(id),
+		conn:    conn,
+		broker:  b,
+		policy:  b.policy,
+		events:  make(chan interface{}, b.buffer),
+		mutex:   &sync.Mutex{},
+		once:    &sync.Once{},
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	b.mutex.Lock()
+
+	b.subscriptions[sub.id] = sub
+
+	b.mutex.Unlock()
+
+	go sub.pump()
+
+	return sub, nil
+}
+
+// Len returns the number of subscriptions.
+func (b *Broker) Len() int {
+
+	b.mutex.Lock()
+
+	defer b.mutex.Unlock()
+
+	return len(b.subscriptions)
+}
+
+// Subscription is the server side of a subscription.
+type Subscription struct {
+	id      string
+	conn    *jsonrpc2.Conn
+	broker  *Broker
+	policy  Policy
+	events  chan interface{}
+	mutex   *sync.Mutex
+	dropped int
+	once    *sync.Once
+	closing chan struct{}
+	closed  bool
+	done    chan struct{}
+}
+
+type eventParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result,omitempty"`
+	Closed       bool        `json:"closed,omitempty"`
+}
+
+func (p *eventParams) IsValid() bool {
+
+	return true
+}
+
+func (s *Subscription) ID() string {
+
+	return s.id
+}
+
+// Send queues an event for the subscriber and applies the policy of the
+// subscription if its buffer is full. It returns ErrUnsubscribed once the
+// subscription ended.
+func (s *Subscription) Send(event interface{}) error {
+
+	s.mutex.Lock()
+
+	closed := s.closed
+
+	s.mutex.Unlock()
+
+	if closed {
+
+		return ErrUnsubscribed
+	}
+
+	select {
+	case s.events <- event:
+
+		return nil
+
+	case <-s.done:
+
+		return ErrUnsubscribed
+
+	default:
+	}
+
+	switch s.policy {
+	case Block:
+
+		select {
+		case s.events <- event:
+
+			return nil
+
+		case <-s.done:
+
+			return ErrUnsubscribed
+		}
+
+	case Disconnect:
+
+		go s.conn.Close()
+
+		s.finish()
+
+		return ErrSlowConsumer
+	}
+
+	s.mutex.Lock()
+
+	s.dropped++
+
+	s.mutex.Unlock()
+
+	return nil
+}
+
+// Dropped returns the number of events dropped by the Drop policy.
+func (s *Subscription) Dropped() int {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	return s.dropped
+}
+
+// Close ends the subscription once the buffered events are sent.
+func (s *Subscription) Close() {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	if !s.closed {
+
+		s.closed = true
+
+		close(s.closing)
+	}
+}
+
+// Done is closed when the subscription ended: it was closed, the client
+// unsubscribed or disconnected.
+func (s *Subscription) Done() <-chan struct{} {
+
+	return s.done
+}
+
+func (s *Subscription) pump() {
+
+	defer s.finish()
+
+	for {
+
+		select {
+		case event := <-s.events:
+
+			if err := s.conn.Notify(Method, &eventParams{Subscription: s.id, Result: event}); err != nil {
+
+				return
+			}
+
+		case <-s.closing:
+
+			for {
+
+				select {
+				case event := <-s.events:
+
+					if err := s.conn.Notify(Method, &eventParams{Subscription: s.id, Result: event}); err != nil {
+
+						return
+					}
+
+				default:
+
+					s.conn.Notify(Method, &eventParams{Subscription: s.id, Closed: true})
+
+					return
+				}
+			}
+
+		case <-s.conn.Done():
+
+			return
+
+		case <-s.done:
+
+			return
+		}
+	}
+}
+
+func (s *Subscription) finish() {
+
+	s.once.Do(func() {
+
+		s.mutex.Lock()
+
+		s.closed = true
+
+		s.mutex.Unlock()
+
+		s.broker.mutex.Lock()
+
+		delete(s.broker.subscriptions, s.id)
+
+		s.broker.mutex.Unlock()
+
+		close(s.done)
+	})
+}
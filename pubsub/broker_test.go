@@ -0,0 +1,257 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestCodec() *testCodec {
+
+	return &testCodec{
+		in:     make(chan json.RawMessage, 16),
+		out:    make(chan json.RawMessage),
+		closed: make(chan struct{}),
+		once:   &sync.Once{},
+	}
+}
+
+// testCodec blocks the writes until the test reads them.
+type testCodec struct {
+	in     chan json.RawMessage
+	out    chan json.RawMessage
+	closed chan struct{}
+	once   *sync.Once
+}
+
+func (c *testCodec) ReadMessage() (json.RawMessage, error) {
+
+	select {
+	case message := <-c.in:
+
+		return message, nil
+
+	case <-c.closed:
+
+		return nil, io.EOF
+	}
+}
+
+func (c *testCodec) WriteMessage(message json.RawMessage) error {
+
+	select {
+	case c.out <- message:
+
+		return nil
+
+	case <-c.closed:
+
+		return io.ErrClosedPipe
+	}
+}
+
+func (c *testCodec) Close() error {
+
+	c.once.Do(func() {
+
+		close(c.closed)
+	})
+
+	return nil
+}
+
+// subscribe subscribes on a connection whose writes are read from the codec.
+func subscribe(t *testing.T, broker *Broker) (*jsonrpc2.Conn, *testCodec, *Subscription) {
+
+	var (
+		codec = newTestCodec()
+		subs  = make(chan *Subscription, 1)
+		srv   = server.New()
+	)
+
+	broker.Register(srv)
+
+	srv.RegisterFunc("Ticks.Subscribe", func(ctx context.Context, params *jsonrpc2.EmptyParams) (string, error) {
+
+		sub, err := broker.Subscribe(ctx)
+
+		if err != nil {
+
+			return "", err
+		}
+
+		subs <- sub
+
+		return sub.ID(), nil
+	})
+
+	conn := jsonrpc2.NewConn(codec, jsonrpc2.WithHandler(srv))
+
+	codec.in <- json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"Ticks.Subscribe","params":{}}`)
+
+	var response struct {
+		Result string `json:"result"`
+	}
+
+	if assert.NoError(t, json.Unmarshal(<-codec.out, &response)) {
+
+		assert.NotEmpty(t, response.Result)
+	}
+
+	return conn, codec, <-subs
+}
+
+func TestBrokerSubscribeWithoutConn(t *testing.T) {
+
+	if _, err := NewBroker().Subscribe(context.Background()); assert.Error(t, err) {
+
+		if e, ok := err.(*jsonrpc2.Error); assert.True(t, ok) {
+
+			assert.Equal(t, int16(jsonrpc2.ServerError), e.Code)
+		}
+	}
+}
+
+func TestBrokerEvents(t *testing.T) {
+
+	broker := NewBroker()
+
+	conn, codec, sub := subscribe(t, broker)
+
+	defer conn.Close()
+
+	assert.Equal(t, 1, broker.Len())
+	assert.NoError(t, sub.Send(42))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","method":"subscription","params":{"subscription":"`+sub.ID()+`","result":42}}`, string(<-codec.out))
+
+	sub.Close()
+
+	assert.JSONEq(t, `{"jsonrpc":"2.0","method":"subscription","params":{"subscription":"`+sub.ID()+`","closed":true}}`, string(<-codec.out))
+
+	<-sub.Done()
+
+	assert.Equal(t, 0, broker.Len())
+	assert.Equal(t, ErrUnsubscribed, sub.Send(43))
+}
+
+func TestBrokerUnsubscribe(t *testing.T) {
+
+	broker := NewBroker()
+
+	conn, codec, sub := subscribe(t, broker)
+
+	defer conn.Close()
+
+	codec.in <- json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"rpc.unsubscribe","params":{"subscription":"unknown"}}`)
+
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":2,"result":false}`, string(<-codec.out))
+
+	codec.in <- json.RawMessage(`{"jsonrpc":"2.0","id":3,"method":"rpc.unsubscribe","params":{"subscription":"` + sub.ID() + `"}}`)
+
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":3,"result":true}`, string(<-codec.out))
+
+	<-sub.Done()
+
+	assert.Equal(t, 0, broker.Len())
+}
+
+func TestBrokerDisconnected(t *testing.T) {
+
+	broker := NewBroker()
+
+	conn, _, sub := subscribe(t, broker)
+
+	conn.Close()
+
+	select {
+	case <-sub.Done():
+
+		assert.Equal(t, 0, broker.Len())
+
+	case <-time.After(time.Second):
+
+		t.Error("the subscription did not end")
+	}
+}
+
+func TestBrokerPolicyDrop(t *testing.T) {
+
+	conn, _, sub := subscribe(t, NewBroker(WithBuffer(1), WithPolicy(Drop)))
+
+	defer conn.Close()
+
+	for i := 0; i < 10; i++ {
+
+		assert.NoError(t, sub.Send(i))
+	}
+
+	assert.True(t, sub.Dropped() >= 8)
+}
+
+func TestBrokerPolicyBlock(t *testing.T) {
+
+	conn, codec, sub := subscribe(t, NewBroker(WithBuffer(1), WithPolicy(Block)))
+
+	defer conn.Close()
+
+	sent := make(chan error, 3)
+
+	go func() {
+
+		for i := 0; i < 3; i++ {
+
+			sent <- sub.Send(i)
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+
+		var event struct {
+			Params struct {
+				Result int `json:"result"`
+			} `json:"params"`
+		}
+
+		if assert.NoError(t, json.Unmarshal(<-codec.out, &event)) {
+
+			assert.Equal(t, i, event.Params.Result)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+
+		assert.NoError(t, <-sent)
+	}
+
+	assert.Equal(t, 0, sub.Dropped())
+}
+
+func TestBrokerPolicyDisconnect(t *testing.T) {
+
+	conn, _, sub := subscribe(t, NewBroker(WithBuffer(1), WithPolicy(Disconnect)))
+
+	var err error
+
+	for i := 0; i < 3 && err == nil; i++ {
+
+		err = sub.Send(i)
+	}
+
+	assert.Equal(t, ErrSlowConsumer, err)
+
+	select {
+	case <-conn.Done():
+
+	case <-time.After(time.Second):
+
+		t.Error("the connection was not closed")
+	}
+
+	<-sub.Done()
+}
@@ -0,0 +1,301 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"sync"
+	"time"
+)
+
+// NewClient returns the client side of the subscriptions. Register it on the
+// handler of the connections, the events are then received as the channels
+// of the subscriptions.
+func NewClient() *Client {
+
+	return &Client{
+		mutex:         &sync.Mutex{},
+		subscriptions: make(map[string]*ClientSubscription),
+		early:         make(map[string]*earlyEvents),
+		earlyTimeout:  earlyTimeout,
+	}
+}
+
+type Client struct {
+	mutex         *sync.Mutex
+	subscriptions map[string]*ClientSubscription
+	early         map[string]*earlyEvents
+	earlyTimeout  time.Duration
+	buffered      int
+}
+
+// maxEarly bounds the events received before the subscribe call returned
+// their subscription ID.
+const maxEarly = 1024
+
+// earlyTimeout is how long the events of an unknown subscription are kept:
+// those of a subscription already finished or whose subscribe call failed
+// are never claimed.
+const earlyTimeout = 10 * time.Second
+
+type earlyEvents struct {
+	received time.Time
+	events   []*clientEventParams
+}
+
+type clientEventParams struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+	Closed       bool            `json:"closed"`
+}
+
+func (p *clientEventParams) IsValid() bool {
+
+	return p.Subscription != ""
+}
+
+// Register registers the subscription notification method on s, the server
+// the connections are given as handler.
+func (c *Client) Register(s Registrar) {
+
+	s.RegisterFunc(Method, func(params *clientEventParams) (interface{}, error) {
+
+		c.mutex.Lock()
+
+		sub, found := c.subscriptions[params.Subscription]
+
+		if !found {
+
+			c.expire(time.Now())
+
+			if c.buffered < maxEarly {
+
+				early, found := c.early[params.Subscription]
+
+				if !found {
+
+					early = &earlyEvents{received: time.Now()}
+
+					c.early[params.Subscription] = early
+				}
+
+				early.events = append(early.events, params)
+
+				c.buffered++
+			}
+
+			c.mutex.Unlock()
+
+			return nil, nil
+		}
+
+		c.mutex.Unlock()
+
+		sub.deliver(params)
+
+		return nil, nil
+	})
+}
+
+// expire drops the events of the unknown subscriptions first received more
+// than earlyTimeout before now.
+func (c *Client) expire(now time.Time) {
+
+	for id, early := range c.early {
+
+		if now.Sub(early.received) > c.earlyTimeout {
+
+			delete(c.early, id)
+
+			c.buffered -= len(early.events)
+		}
+	}
+}
+
+// Subscribe calls method on conn, it returns the subscription whose ID the
+// method returns. The events must be consumed: a subscription whose queue of
+// pending events is full ends with ErrSlowConsumer and is unsubscribed, the
+// notifications of the connection never wait for it.
+func (c *Client) Subscribe(ctx context.Context, conn *jsonrpc2.Conn, method string, params jsonrpc2.Params) (*ClientSubscription, error) {
+
+	var id string
+
+	if err := conn.SendContext(ctx, method, params, &id); err != nil {
+
+		return nil, err
+	}
+
+	sub := &ClientSubscription{
+		id:     id,
+		conn:   conn,
+		client: c,
+		events: make(chan json.RawMessage),
+		queue:  make(chan *clientEventParams, 64),
+		mutex:  &sync.Mutex{},
+		once:   &sync.Once{},
+		done:   make(chan struct{}),
+	}
+
+	c.mutex.Lock()
+
+	var events []*clientEventParams
+
+	if early, found := c.early[id]; found {
+
+		events = early.events
+	}
+
+	delete(c.early, id)
+
+	c.buffered -= len(events)
+	c.subscriptions[id] = sub
+
+	c.mutex.Unlock()
+
+	go sub.forward(events)
+
+	return sub, nil
+}
+
+// ClientSubscription is the client side of a subscription.
+type ClientSubscription struct {
+	id     string
+	conn   *jsonrpc2.Conn
+	client *Client
+	events chan json.RawMessage
+	queue  chan *clientEventParams
+	mutex  *sync.Mutex
+	once   *sync.Once
+	err    error
+	done   chan struct{}
+}
+
+func (s *ClientSubscription) ID() string {
+
+	return s.id
+}
+
+// Events returns the channel of the events, it is closed when the
+// subscription ends.
+func (s *ClientSubscription) Events() <-chan json.RawMessage {
+
+	return s.events
+}
+
+// Err returns why the subscription ended: nil if it was closed by either
+// side or the error of the connection.
+func (s *ClientSubscription) Err() error {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	return s.err
+}
+
+// Unsubscribe ends the subscription on the server and closes the channel of
+// the events.
+func (s *ClientSubscription) Unsubscribe(ctx context.Context) error {
+
+	var ok bool
+
+	err := s.conn.SendContext(ctx, "rpc.unsubscribe", &unsubscribeParams{Subscription: s.id}, &ok)
+
+	s.finish(nil)
+
+	return err
+}
+
+// deliver queues an event without blocking the notifications of the
+// connection, the subscription ends if the queue is full.
+func (s *ClientSubscription) deliver(event *clientEventParams) {
+
+	select {
+	case s.queue <- event:
+
+	case <-s.done:
+
+	default:
+
+		s.finish(ErrSlowConsumer)
+
+		go s.conn.Notify("rpc.unsubscribe", &unsubscribeParams{Subscription: s.id})
+	}
+}
+
+// forward sends the events received before the subscription was known, then
+// the events of the queue to the channel of the subscription until it ends.
+func (s *ClientSubscription) forward(early []*clientEventParams) {
+
+	defer close(s.events)
+
+	for _, event := range early {
+
+		if !s.send(event) {
+
+			return
+		}
+	}
+
+	for {
+
+		select {
+		case event := <-s.queue:
+
+			if !s.send(event) {
+
+				return
+			}
+
+		case <-s.done:
+
+			return
+
+		case <-s.conn.Done():
+
+			s.finish(s.conn.Err())
+
+			return
+		}
+	}
+}
+
+func (s *ClientSubscription) send(event *clientEventParams) bool {
+
+	if event.Closed {
+
+		s.finish(nil)
+
+		return false
+	}
+
+	select {
+	case s.events <- event.Result:
+
+		return true
+
+	case <-s.done:
+
+		return false
+	}
+}
+
+func (s *ClientSubscription) finish(err error) {
+
+	s.once.Do(func() {
+
+		s.mutex.Lock()
+
+		s.err = err
+
+		s.mutex.Unlock()
+
+		s.client.mutex.Lock()
+
+		delete(s.client.subscriptions, s.id)
+
+		s.client.mutex.Unlock()
+
+		close(s.done)
+	})
+}
@@ -0,0 +1,278 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/kshvakov/jsonrpc2/stream"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+type ticksParams struct {
+	Count int `json:"count"`
+}
+
+func (p *ticksParams) IsValid() bool {
+
+	return true
+}
+
+// connect returns a client connection to a server whose Ticks.Subscribe sends
+// count events before it returns, the subscriptions it created are sent to
+// the returned channel.
+func connect(t *testing.T, broker *Broker) (*Client, *jsonrpc2.Conn, *jsonrpc2.Conn, chan *Subscription) {
+
+	var (
+		a, b = net.Pipe()
+		subs = make(chan *Subscription, 1)
+		srv  = server.New()
+	)
+
+	broker.Register(srv)
+
+	srv.RegisterFunc("Ticks.Subscribe", func(ctx context.Context, params *ticksParams) (string, error) {
+
+		sub, err := broker.Subscribe(ctx)
+
+		if err != nil {
+
+			return "", err
+		}
+
+		for i := 0; i < params.Count; i++ {
+
+			sub.Send(i)
+		}
+
+		subs <- sub
+
+		return sub.ID(), nil
+	})
+
+	var (
+		client   = NewClient()
+		handlers = server.New()
+	)
+
+	client.Register(handlers)
+
+	serverConn := jsonrpc2.NewConn(stream.NewCodec(a, stream.Newline), jsonrpc2.WithHandler(srv))
+	clientConn := jsonrpc2.NewConn(stream.NewCodec(b, stream.Newline), jsonrpc2.WithHandler(handlers))
+
+	return client, clientConn, serverConn, subs
+}
+
+func receive(t *testing.T, sub *ClientSubscription) (int, bool) {
+
+	select {
+	case event, ok := <-sub.Events():
+
+		if !ok {
+
+			return 0, false
+		}
+
+		var value int
+
+		assert.NoError(t, json.Unmarshal(event, &value))
+
+		return value, true
+
+	case <-time.After(time.Second):
+
+		t.Fatal("no event was received")
+	}
+
+	return 0, false
+}
+
+func TestClientSubscribe(t *testing.T) {
+
+	client, conn, serverConn, subs := connect(t, NewBroker())
+
+	defer serverConn.Close()
+	defer conn.Close()
+
+	sub, err := client.Subscribe(context.Background(), conn, "Ticks.Subscribe", &ticksParams{Count: 3})
+
+	if assert.NoError(t, err) {
+
+		serverSub := <-subs
+
+		assert.Equal(t, serverSub.ID(), sub.ID())
+
+		serverSub.Send(3)
+
+		for i := 0; i < 4; i++ {
+
+			value, ok := receive(t, sub)
+
+			if assert.True(t, ok) {
+
+				assert.Equal(t, i, value)
+			}
+		}
+
+		serverSub.Send(4)
+		serverSub.Close()
+
+		value, ok := receive(t, sub)
+
+		if assert.True(t, ok) {
+
+			assert.Equal(t, 4, value)
+		}
+
+		_, ok = receive(t, sub)
+
+		assert.False(t, ok)
+		assert.NoError(t, sub.Err())
+	}
+}
+
+func TestClientUnsubscribe(t *testing.T) {
+
+	broker := NewBroker()
+
+	client, conn, serverConn, subs := connect(t, broker)
+
+	defer serverConn.Close()
+	defer conn.Close()
+
+	sub, err := client.Subscribe(context.Background(), conn, "Ticks.Subscribe", &ticksParams{})
+
+	if assert.NoError(t, err) {
+
+		serverSub := <-subs
+
+		assert.NoError(t, sub.Unsubscribe(context.Background()))
+
+		_, ok := receive(t, sub)
+
+		assert.False(t, ok)
+
+		<-serverSub.Done()
+
+		assert.Equal(t, 0, broker.Len())
+		assert.Equal(t, ErrUnsubscribed, serverSub.Send(1))
+	}
+}
+
+func TestClientSlowConsumer(t *testing.T) {
+
+	client, conn, serverConn, subs := connect(t, NewBroker(WithPolicy(Block)))
+
+	defer serverConn.Close()
+	defer conn.Close()
+
+	sub, err := client.Subscribe(context.Background(), conn, "Ticks.Subscribe", &ticksParams{})
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	serverSub := <-subs
+
+	go func() {
+
+		for i := 0; serverSub.Send(i) == nil; i++ {
+		}
+	}()
+
+	select {
+	case <-serverSub.Done():
+
+	case <-time.After(time.Second):
+
+		t.Fatal("the slow consumer was not unsubscribed")
+	}
+
+	for range sub.Events() {
+	}
+
+	assert.Equal(t, ErrSlowConsumer, sub.Err())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+	defer cancel()
+
+	sub.Unsubscribe(ctx)
+
+	assert.NoError(t, ctx.Err())
+}
+
+func TestClientDisconnected(t *testing.T) {
+
+	client, conn, serverConn, _ := connect(t, NewBroker())
+
+	defer conn.Close()
+
+	sub, err := client.Subscribe(context.Background(), conn, "Ticks.Subscribe", &ticksParams{})
+
+	if assert.NoError(t, err) {
+
+		serverConn.Close()
+
+		_, ok := receive(t, sub)
+
+		assert.False(t, ok)
+		assert.Equal(t, jsonrpc2.ErrClosed, sub.Err())
+	}
+}
+
+func TestClientSubscribeError(t *testing.T) {
+
+	client, conn, serverConn, _ := connect(t, NewBroker())
+
+	defer serverConn.Close()
+	defer conn.Close()
+
+	_, err := client.Subscribe(context.Background(), conn, "Ticks.Unknown", &ticksParams{})
+
+	assert.Error(t, err)
+}
+
+func TestClientExpireEarly(t *testing.T) {
+
+	var (
+		client   = NewClient()
+		handlers = server.New()
+	)
+
+	client.Register(handlers)
+
+	event := func(id string) {
+
+		data, _ := json.Marshal(&jsonrpc2.Request{
+			Jsonrpc: "2.0",
+			Method:  Method,
+			Params:  &clientEventParams{Subscription: id, Result: json.RawMessage("1")},
+		})
+
+		handlers.HandleMessage(context.Background(), data)
+	}
+
+	for i := 0; i < maxEarly+1; i++ {
+
+		event("finished")
+	}
+
+	assert.Equal(t, maxEarly, client.buffered)
+
+	client.earlyTimeout = 0
+
+	time.Sleep(time.Millisecond)
+
+	event("next")
+
+	if assert.Len(t, client.early, 1) && assert.Contains(t, client.early, "next") {
+
+		assert.Len(t, client.early["next"].events, 1)
+		assert.Equal(t, 1, client.buffered)
+	}
+}
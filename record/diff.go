@@ -0,0 +1,224 @@
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Difference is a recorded call answered differently by the server under
+// test.
+type Difference struct {
+	Entry   Entry
+	Result  json.RawMessage
+	Error   *jsonrpc2.Error
+	Changes []string
+}
+
+func (d *Difference) String() string {
+
+	return fmt.Sprintf("%s %s:\n\t%s", d.Entry.Method, d.Entry.Params, strings.Join(d.Changes, "\n\t"))
+}
+
+// Compare sends the recorded calls with client and returns the calls whose
+// responses differ from the recording. The responses are compared as
+// {"result": ..., "error": ...} documents, ignore lists the paths left out of
+// the comparison: "result.updated_at", "result.items.*.id" or "error.data".
+// A path ignores everything below it, * matches any object key or array
+// index. Compare stops at the first call failed before a response was
+// received.
+func Compare(ctx context.Context, client jsonrpc2.Client, entries []Entry, ignore ...string) ([]Difference, error) {
+
+	var (
+		differences []Difference
+		patterns    = make([][]string, 0, len(ignore))
+	)
+
+	for _, path := range ignore {
+
+		patterns = append(patterns, strings.Split(path, "."))
+	}
+
+	for _, entry := range entries {
+
+		var (
+			result json.RawMessage
+			err    = client.SendContext(ctx, entry.Method, jsonrpc2.RawParams(entry.Params), &result)
+		)
+
+		e := errorOf(err)
+
+		if err != nil && e == nil {
+
+			return differences, fmt.Errorf("%s: %v", entry.Method, err)
+		}
+
+		if changes := diffResponses(entry.Result, entry.Error, result, e, patterns); len(changes) != 0 {
+
+			differences = append(differences, Difference{
+				Entry:   entry,
+				Result:  result,
+				Error:   e,
+				Changes: changes,
+			})
+		}
+	}
+
+	return differences, nil
+}
+
+func diffResponses(expectedResult json.RawMessage, expectedError *jsonrpc2.Error, result json.RawMessage, e *jsonrpc2.Error, ignore [][]string) []string {
+
+	var (
+		changes  []string
+		expected = document(expectedResult, expectedError)
+		actual   = document(result, e)
+	)
+
+	diff(nil, expected, actual, ignore, &changes)
+
+	return changes
+}
+
+func document(result json.RawMessage, e *jsonrpc2.Error) interface{} {
+
+	if string(result) == "null" {
+
+		result = nil
+	}
+
+	data, _ := json.Marshal(struct {
+		Result json.RawMessage `json:"result,omitempty"`
+		Error  *jsonrpc2.Error `json:"error,omitempty"`
+	}{result, e})
+
+	var v interface{}
+
+	json.Unmarshal(data, &v)
+
+	return v
+}
+
+func diff(path []string, expected, actual interface{}, ignore [][]string, changes *[]string) {
+
+	if ignored(path, ignore) {
+
+		return
+	}
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+
+		if a, ok := actual.(map[string]interface{}); ok {
+
+			keys := make([]string, 0, len(e))
+
+			for k := range e {
+
+				keys = append(keys, k)
+			}
+
+			for k := range a {
+
+				if _, found := e[k]; !found {
+
+					keys = append(keys, k)
+				}
+			}
+
+			sort.Strings(keys)
+
+			for _, k := range keys {
+
+				diff(append(path[:len(path):len(path)], k), e[k], a[k], ignore, changes)
+			}
+
+			return
+		}
+
+	case []interface{}:
+
+		if a, ok := actual.([]interface{}); ok {
+
+			n := len(e)
+
+			if len(a) > n {
+
+				n = len(a)
+			}
+
+			for i := 0; i < n; i++ {
+
+				var ev, av interface{}
+
+				if i < len(e) {
+
+					ev = e[i]
+				}
+
+				if i < len(a) {
+
+					av = a[i]
+				}
+
+				diff(append(path[:len(path):len(path)], strconv.Itoa(i)), ev, av, ignore, changes)
+			}
+
+			return
+		}
+	}
+
+	if reflect.DeepEqual(expected, actual) {
+
+		return
+	}
+
+	*changes = append(*changes, fmt.Sprintf("%s: %s != %s", strings.Join(path, "."), show(expected), show(actual)))
+}
+
+func show(v interface{}) string {
+
+	if v == nil {
+
+		return "missing"
+	}
+
+	data, _ := json.Marshal(v)
+
+	return string(data)
+}
+
+func ignored(path []string, ignore [][]string) bool {
+
+	for _, pattern := range ignore {
+
+		if len(pattern) > len(path) {
+
+			continue
+		}
+
+		matched := true
+
+		for i, segment := range pattern {
+
+			if segment != "*" && segment != path[i] {
+
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+
+			return true
+		}
+	}
+
+	return false
+}
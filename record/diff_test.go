@@ -0,0 +1,76 @@
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDiffResponses(t *testing.T) {
+
+	var (
+		expected = json.RawMessage(`{"id": 1, "name": "a", "items": [{"id": 1, "n": 1}, {"id": 2, "n": 2}], "updated_at": "x"}`)
+		actual   = json.RawMessage(`{"id": 1, "name": "b", "items": [{"id": 7, "n": 1}], "updated_at": "y", "extra": true}`)
+	)
+
+	assert.Equal(t, []string{
+		`result.extra: missing != true`,
+		`result.items.0.id: 1 != 7`,
+		`result.items.1: {"id":2,"n":2} != missing`,
+		`result.name: "a" != "b"`,
+		`result.updated_at: "x" != "y"`,
+	}, diffResponses(expected, nil, actual, nil, nil))
+
+	assert.Equal(t, []string{
+		`result.items.1: {"id":2,"n":2} != missing`,
+		`result.name: "a" != "b"`,
+	}, diffResponses(expected, nil, actual, nil, patterns("result.updated_at", "result.items.*.id", "result.extra")))
+
+	assert.Empty(t, diffResponses(expected, nil, actual, nil, patterns("result")))
+
+	assert.Equal(t, []string{
+		`error: {"code":-32602,"message":"Invalid params"} != missing`,
+		`result: missing != 1`,
+	}, diffResponses(nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, ""), json.RawMessage(`1`), nil, nil))
+
+	assert.Empty(t, diffResponses(nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "a"), nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "b"), patterns("error.data")))
+	assert.Empty(t, diffResponses(json.RawMessage(`null`), nil, nil, nil, nil))
+	assert.Equal(t, []string{`result: [1] != {"0":1}`}, diffResponses(json.RawMessage(`[1]`), nil, json.RawMessage(`{"0": 1}`), nil, nil))
+}
+
+func TestCompareUnavailable(t *testing.T) {
+
+	client := jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{"http://127.0.0.1:1"})
+
+	_, err := Compare(context.Background(), client, []Entry{{Method: "Math.Sum"}})
+
+	if assert.Error(t, err) {
+
+		assert.True(t, strings.HasPrefix(err.Error(), "Math.Sum: "), err.Error())
+	}
+}
+
+func TestDifferenceString(t *testing.T) {
+
+	d := Difference{
+		Entry:   Entry{Method: "Math.Sum", Params: json.RawMessage(`{"a":1}`)},
+		Changes: []string{"result: 1 != 2", "error.code: 1 != 2"},
+	}
+
+	assert.Equal(t, "Math.Sum {\"a\":1}:\n\tresult: 1 != 2\n\terror.code: 1 != 2", d.String())
+}
+
+func patterns(paths ...string) [][]string {
+
+	var p [][]string
+
+	for _, path := range paths {
+
+		p = append(p, strings.Split(path, "."))
+	}
+
+	return p
+}
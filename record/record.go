@@ -0,0 +1,287 @@
+package record
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is a recorded call and its response, a recording has one entry per
+// line.
+type Entry struct {
+	Time     time.Time       `json:"time"`
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Error    *jsonrpc2.Error `json:"error,omitempty"`
+	Duration time.Duration   `json:"duration"`
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+
+	return &Recorder{
+		mutex:   &sync.Mutex{},
+		encoder: json.NewEncoder(w),
+	}
+}
+
+type Recorder struct {
+	mutex   *sync.Mutex
+	encoder *json.Encoder
+}
+
+func (r *Recorder) Record(entry Entry) error {
+
+	r.mutex.Lock()
+
+	defer r.mutex.Unlock()
+
+	return r.encoder.Encode(entry)
+}
+
+// Middleware records the calls handled by a server. A jsonrpc2.Stream result
+// is not read by the middleware: its elements are recorded as the server
+// writes them and the entry once the stream ended.
+func (r *Recorder) Middleware(next server.HandlerFunc) server.HandlerFunc {
+
+	return func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+		start := time.Now()
+
+		result, err := next(ctx, request)
+
+		entry := Entry{
+			Time:     start,
+			Method:   request.Method,
+			Params:   request.Params,
+			Error:    handlerError(err),
+			Duration: time.Since(start),
+		}
+
+		if stream, ok := result.(jsonrpc2.Stream); ok && err == nil && stream != nil {
+
+			return r.tee(entry, stream), nil
+		}
+
+		if err == nil && result != nil {
+
+			entry.Result, _ = json.Marshal(result)
+		}
+
+		r.Record(entry)
+
+		return result, err
+	}
+}
+
+// tee returns a stream passing the elements of stream on and recording them
+// with entry when it ended.
+func (r *Recorder) tee(entry Entry, stream jsonrpc2.Stream) jsonrpc2.Stream {
+
+	return func(yield func(element interface{}) error) error {
+
+		var elements []json.RawMessage
+
+		err := stream(func(element interface{}) error {
+
+			data, err := json.Marshal(element)
+
+			if err != nil {
+
+				return err
+			}
+
+			elements = append(elements, data)
+
+			return yield(json.RawMessage(data))
+		})
+
+		entry.Duration = time.Since(entry.Time)
+
+		if err != nil {
+
+			entry.Error = handlerError(err)
+
+		} else {
+
+			entry.Result, _ = json.Marshal(elements)
+		}
+
+		r.Record(entry)
+
+		return err
+	}
+}
+
+// handlerError returns the error the server answers with for an error of a
+// handler.
+func handlerError(err error) *jsonrpc2.Error {
+
+	if e := errorOf(err); e != nil || err == nil {
+
+		return e
+	}
+
+	return &jsonrpc2.Error{
+		Code:    jsonrpc2.LogicErr,
+		Message: err.Error(),
+	}
+}
+
+// Client records the calls sent by client. Calls failed before a response
+// was received are not recorded.
+func (r *Recorder) Client(client jsonrpc2.Client) jsonrpc2.Client {
+
+	return &recordingClient{
+		client:   client,
+		recorder: r,
+	}
+}
+
+type recordingClient struct {
+	client   jsonrpc2.Client
+	recorder *Recorder
+}
+
+func (c *recordingClient) Send(method string, params jsonrpc2.Params, result interface{}) error {
+
+	return c.SendContext(context.Background(), method, params, result)
+}
+
+func (c *recordingClient) SendContext(ctx context.Context, method string, params jsonrpc2.Params, result interface{}) error {
+
+	var (
+		raw      json.RawMessage
+		elements []json.RawMessage
+		start    = time.Now()
+		fn, tee  = result.(jsonrpc2.ElementFunc)
+		err      error
+	)
+
+	if tee {
+
+		err = c.client.SendContext(ctx, method, params, jsonrpc2.ElementFunc(func(element json.RawMessage) error {
+
+			elements = append(elements, element)
+
+			return fn(element)
+		}))
+
+		if err == nil {
+
+			raw, _ = json.Marshal(elements)
+		}
+
+		result = nil
+
+	} else {
+
+		err = c.client.SendContext(ctx, method, params, &raw)
+	}
+
+	entry := Entry{
+		Time:     start,
+		Method:   method,
+		Error:    errorOf(err),
+		Duration: time.Since(start),
+	}
+
+	if err != nil && entry.Error == nil {
+
+		return err
+	}
+
+	entry.Params, _ = json.Marshal(params)
+
+	if err == nil && len(raw) != 0 {
+
+		entry.Result = raw
+
+		if result != nil {
+
+			err = json.Unmarshal(raw, result)
+		}
+	}
+
+	c.recorder.Record(entry)
+
+	return err
+}
+
+// errorOf returns the error as it was sent in the response, or nil if err is
+// not an error of the JSON-RPC protocol.
+func errorOf(err error) *jsonrpc2.Error {
+
+	switch e := err.(type) {
+	case nil:
+
+		return nil
+
+	case *jsonrpc2.Error:
+
+		return e
+
+	case *jsonrpc2.LogicError:
+
+		return &jsonrpc2.Error{
+			Code:    jsonrpc2.LogicErr,
+			Message: e.Error(),
+		}
+	}
+
+	return nil
+}
+
+// Load reads a recording.
+func Load(r io.Reader) ([]Entry, error) {
+
+	var (
+		entries []Entry
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+
+		line++
+
+		if len(scanner.Bytes()) == 0 {
+
+			continue
+		}
+
+		var entry Entry
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+func LoadFile(name string) ([]Entry, error) {
+
+	file, err := os.Open(name)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return Load(file)
+}
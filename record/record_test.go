@@ -0,0 +1,258 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type sumParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (p *sumParams) IsValid() bool {
+
+	return true
+}
+
+type testMath struct {
+	offset int
+}
+
+func (m *testMath) Sum(params *sumParams) (int, error) {
+
+	return params.A + params.B + m.offset, nil
+}
+
+func (m *testMath) Div(params *sumParams) (int, error) {
+
+	if params.B == 0 {
+
+		return 0, jsonrpc2.NewError(jsonrpc2.InvalidParams, "division by zero")
+	}
+
+	return params.A / params.B, nil
+}
+
+func (m *testMath) Fail(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+	return nil, errors.New("failed")
+}
+
+func newTestServer(offset int, middlewares ...server.Middleware) *httptest.Server {
+
+	s := server.New()
+	s.RegisterObject("Math", &testMath{offset: offset})
+	s.Use(middlewares...)
+
+	return httptest.NewServer(s)
+}
+
+func TestRecorderMiddleware(t *testing.T) {
+
+	var (
+		buf      bytes.Buffer
+		recorder = NewRecorder(&buf)
+		s        = newTestServer(0, recorder.Middleware)
+		client   = jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{s.URL})
+		result   int
+	)
+
+	defer s.Close()
+
+	assert.NoError(t, client.Send("Math.Sum", &sumParams{A: 1, B: 2}, &result))
+	assert.Error(t, client.Send("Math.Div", &sumParams{A: 1}, &result))
+	assert.Error(t, client.Send("Math.Fail", &jsonrpc2.EmptyParams{}, &result))
+
+	entries, err := Load(&buf)
+
+	if assert.NoError(t, err) && assert.Len(t, entries, 3) {
+
+		assert.Equal(t, "Math.Sum", entries[0].Method)
+		assert.JSONEq(t, `{"a": 1, "b": 2}`, string(entries[0].Params))
+		assert.Equal(t, "3", string(entries[0].Result))
+		assert.Nil(t, entries[0].Error)
+		assert.False(t, entries[0].Time.IsZero())
+
+		if assert.NotNil(t, entries[1].Error) {
+
+			assert.Equal(t, int16(jsonrpc2.InvalidParams), entries[1].Error.Code)
+			assert.Equal(t, "division by zero", entries[1].Error.Data)
+		}
+
+		if assert.NotNil(t, entries[2].Error) {
+
+			assert.Equal(t, int16(jsonrpc2.LogicErr), entries[2].Error.Code)
+			assert.Equal(t, "failed", entries[2].Error.Message)
+		}
+	}
+}
+
+func TestRecorderClient(t *testing.T) {
+
+	var (
+		buf    bytes.Buffer
+		s      = newTestServer(0)
+		client = NewRecorder(&buf).Client(jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{s.URL}))
+		result int
+	)
+
+	defer s.Close()
+
+	if assert.NoError(t, client.Send("Math.Sum", &sumParams{A: 2, B: 2}, &result)) {
+
+		assert.Equal(t, 4, result)
+	}
+
+	assert.NoError(t, client.Send("Math.Sum", &sumParams{A: 2, B: 2}, nil))
+	assert.EqualError(t, client.Send("Math.Fail", &jsonrpc2.EmptyParams{}, &result), "failed")
+
+	unavailable := NewRecorder(&buf).Client(jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{"http://127.0.0.1:1"}))
+
+	assert.Error(t, unavailable.Send("Math.Sum", &sumParams{}, &result))
+
+	entries, err := Load(&buf)
+
+	if assert.NoError(t, err) && assert.Len(t, entries, 3) {
+
+		assert.Equal(t, "4", string(entries[0].Result))
+		assert.Equal(t, "4", string(entries[1].Result))
+		assert.JSONEq(t, `{}`, string(entries[2].Params))
+
+		if assert.NotNil(t, entries[2].Error) {
+
+			assert.Equal(t, int16(jsonrpc2.LogicErr), entries[2].Error.Code)
+		}
+	}
+}
+
+func TestRecorderStream(t *testing.T) {
+
+	var (
+		buf      bytes.Buffer
+		runs     int32
+		recorder = NewRecorder(&buf)
+		s        = server.New()
+		elements []string
+	)
+
+	s.RegisterFunc("Range", func(params *sumParams) (jsonrpc2.Stream, error) {
+
+		return func(yield func(element interface{}) error) error {
+
+			atomic.AddInt32(&runs, 1)
+
+			for i := params.A; i < params.B; i++ {
+
+				if err := yield(i); err != nil {
+
+					return err
+				}
+			}
+
+			return nil
+		}, nil
+	})
+	s.Use(recorder.Middleware)
+
+	testServer := httptest.NewServer(s)
+
+	defer testServer.Close()
+
+	client := recorder.Client(jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{testServer.URL}))
+
+	assert.NoError(t, client.Send("Range", &sumParams{A: 1, B: 4}, jsonrpc2.ElementFunc(func(element json.RawMessage) error {
+
+		elements = append(elements, string(element))
+
+		return nil
+	})))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	assert.Equal(t, []string{"1", "2", "3"}, elements)
+
+	entries, err := Load(&buf)
+
+	if assert.NoError(t, err) && assert.Len(t, entries, 2) {
+
+		for _, entry := range entries {
+
+			assert.Equal(t, "Range", entry.Method)
+			assert.JSONEq(t, `[1, 2, 3]`, string(entry.Result))
+			assert.Nil(t, entry.Error)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+
+	entries, err := Load(strings.NewReader("{\"method\": \"A\", \"params\": [1]}\n\n{\"method\": \"B\", \"error\": {\"code\": -32601, \"message\": \"Method not found\"}}\n"))
+
+	if assert.NoError(t, err) && assert.Len(t, entries, 2) {
+
+		assert.Equal(t, "A", entries[0].Method)
+		assert.Equal(t, int16(jsonrpc2.MethodNotFound), entries[1].Error.Code)
+	}
+
+	_, err = Load(strings.NewReader("{\"method\": \"A\"}\n{\n"))
+
+	assert.EqualError(t, err, "line 2: unexpected end of JSON input")
+
+	_, err = LoadFile("testdata/missing.jsonl")
+
+	assert.Error(t, err)
+}
+
+func TestRecordReplayCompare(t *testing.T) {
+
+	var (
+		buf    bytes.Buffer
+		s      = newTestServer(0, NewRecorder(&buf).Middleware)
+		client = jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{s.URL})
+		result int
+	)
+
+	client.Send("Math.Sum", &sumParams{A: 1, B: 2}, &result)
+	client.Send("Math.Div", &sumParams{A: 1}, &result)
+
+	s.Close()
+
+	entries, err := Load(&buf)
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	same := newTestServer(0)
+
+	defer same.Close()
+
+	differences, err := Compare(context.Background(), jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{same.URL}), entries)
+
+	if assert.NoError(t, err) {
+
+		assert.Empty(t, differences)
+	}
+
+	changed := newTestServer(10)
+
+	defer changed.Close()
+
+	differences, err = Compare(context.Background(), jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{changed.URL}), entries)
+
+	if assert.NoError(t, err) && assert.Len(t, differences, 1) {
+
+		assert.Equal(t, []string{"result: 3 != 13"}, differences[0].Changes)
+		assert.Equal(t, "13", string(differences[0].Result))
+	}
+}
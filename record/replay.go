@@ -0,0 +1,125 @@
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"net/http"
+	"sync"
+)
+
+// Replay returns a middleware answering calls with the responses of the
+// recording. Calls are matched by method and params compared as JSON, if a
+// call was recorded several times the responses are served in the order of
+// the recording and the last one is repeated. Calls of methods that are not
+// in the recording are passed to the next handler, calls of a recorded method
+// with other params are answered with invalid params.
+func Replay(entries []Entry) server.Middleware {
+
+	r := &replayer{
+		mutex:     &sync.Mutex{},
+		responses: make(map[string][]Entry),
+		served:    make(map[string]int),
+		methods:   make(map[string]bool),
+	}
+
+	for _, entry := range entries {
+
+		key := key(entry.Method, entry.Params)
+
+		r.responses[key] = append(r.responses[key], entry)
+		r.methods[entry.Method] = true
+	}
+
+	return r.middleware
+}
+
+// NewReplayServer returns a server answering calls with the responses of the
+// recording, it stands in for the upstream the recording was made against.
+func NewReplayServer(entries []Entry) http.Handler {
+
+	s := server.New()
+	s.Use(Replay(entries))
+
+	return s
+}
+
+type replayer struct {
+	mutex     *sync.Mutex
+	responses map[string][]Entry
+	served    map[string]int
+	methods   map[string]bool
+}
+
+func (r *replayer) middleware(next server.HandlerFunc) server.HandlerFunc {
+
+	return func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+		if !r.methods[request.Method] {
+
+			return next(ctx, request)
+		}
+
+		entry, found := r.next(key(request.Method, request.Params))
+
+		if !found {
+
+			return nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "no recording matches the params")
+		}
+
+		if entry.Error != nil {
+
+			return nil, entry.Error
+		}
+
+		if len(entry.Result) == 0 {
+
+			return nil, nil
+		}
+
+		return entry.Result, nil
+	}
+}
+
+func (r *replayer) next(key string) (Entry, bool) {
+
+	r.mutex.Lock()
+
+	defer r.mutex.Unlock()
+
+	responses := r.responses[key]
+
+	if len(responses) == 0 {
+
+		return Entry{}, false
+	}
+
+	i := r.served[key]
+
+	if i < len(responses)-1 {
+
+		r.served[key]++
+	}
+
+	return responses[i], true
+}
+
+// key identifies a call by its method and params, the params are normalized
+// so that the order of object keys and the white space do not matter.
+func key(method string, params json.RawMessage) string {
+
+	var v interface{}
+
+	if len(params) != 0 {
+
+		if err := json.Unmarshal(params, &v); err != nil {
+
+			return method + " " + string(params)
+		}
+	}
+
+	data, _ := json.Marshal(v)
+
+	return method + " " + string(data)
+}
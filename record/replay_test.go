@@ -0,0 +1,66 @@
+package record
+
+import (
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReplayServer(t *testing.T) {
+
+	entries := []Entry{
+		{Method: "Math.Sum", Params: json.RawMessage(`{"a": 1, "b": 2}`), Result: json.RawMessage(`3`)},
+		{Method: "Math.Sum", Params: json.RawMessage(`{"b":2,"a":1}`), Result: json.RawMessage(`4`)},
+		{Method: "Math.Div", Params: json.RawMessage(`{"a": 1, "b": 0}`), Error: jsonrpc2.NewError(jsonrpc2.InvalidParams, "division by zero")},
+		{Method: "Math.Fail", Params: json.RawMessage(`{}`), Error: &jsonrpc2.Error{Code: jsonrpc2.LogicErr, Message: "failed"}},
+		{Method: "Math.Nothing", Params: json.RawMessage(`{}`)},
+	}
+
+	s := httptest.NewServer(NewReplayServer(entries))
+
+	defer s.Close()
+
+	var (
+		client = jsonrpc2.NewClient(jsonrpc2.StaticDiscovery{s.URL})
+		result int
+	)
+
+	for _, expected := range []int{3, 4, 4} {
+
+		if assert.NoError(t, client.Send("Math.Sum", &sumParams{A: 1, B: 2}, &result)) {
+
+			assert.Equal(t, expected, result)
+		}
+	}
+
+	if err, ok := client.Send("Math.Sum", &sumParams{A: 2, B: 2}, &result).(*jsonrpc2.Error); assert.True(t, ok) {
+
+		assert.Equal(t, int16(jsonrpc2.InvalidParams), err.Code)
+		assert.Equal(t, "no recording matches the params", err.Data)
+	}
+
+	if err, ok := client.Send("Math.Div", &sumParams{A: 1}, &result).(*jsonrpc2.Error); assert.True(t, ok) {
+
+		assert.Equal(t, "division by zero", err.Data)
+	}
+
+	_, ok := client.Send("Math.Fail", &jsonrpc2.EmptyParams{}, &result).(*jsonrpc2.LogicError)
+
+	assert.True(t, ok)
+	assert.NoError(t, client.Send("Math.Nothing", &jsonrpc2.EmptyParams{}, nil))
+
+	if err, ok := client.Send("Math.Mul", &sumParams{}, &result).(*jsonrpc2.Error); assert.True(t, ok) {
+
+		assert.Equal(t, int16(jsonrpc2.MethodNotFound), err.Code)
+	}
+}
+
+func TestKey(t *testing.T) {
+
+	assert.Equal(t, key("A", json.RawMessage(`{"b": [1, 2], "a": "x"}`)), key("A", json.RawMessage(`{"a":"x","b":[1,2]}`)))
+	assert.Equal(t, key("A", nil), key("A", json.RawMessage(`null`)))
+	assert.NotEqual(t, key("A", nil), key("B", nil))
+	assert.Equal(t, "A {", key("A", json.RawMessage(`{`)))
+}
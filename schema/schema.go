@@ -0,0 +1,389 @@
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Definitions          map[string]*Schema `json:"$defs,omitempty"`
+	pattern              *regexp.Regexp
+}
+
+// Overrider is implemented by types that describe their own JSON Schema.
+type Overrider interface {
+	JSONSchema() *Schema
+}
+
+var (
+	overriderType     = reflect.TypeOf((*Overrider)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func For(v interface{}) *Schema {
+
+	return Reflect(reflect.TypeOf(v))
+}
+
+func Reflect(t reflect.Type) *Schema {
+
+	r := reflector{
+		definitions: make(map[string]*Schema),
+		stack:       make(map[reflect.Type]bool),
+		recursive:   make(map[reflect.Type]bool),
+	}
+
+	schema := r.reflect(t)
+
+	if len(r.definitions) != 0 {
+
+		if schema.Ref != "" {
+
+			schema = &Schema{Ref: schema.Ref}
+		}
+
+		schema.Definitions = r.definitions
+	}
+
+	schema.compile()
+
+	return schema
+}
+
+type reflector struct {
+	definitions map[string]*Schema
+	stack       map[reflect.Type]bool
+	recursive   map[reflect.Type]bool
+}
+
+func (r *reflector) reflect(t reflect.Type) *Schema {
+
+	if t == nil {
+
+		return &Schema{}
+	}
+
+	if t.Implements(overriderType) && t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface {
+
+		return reflect.Zero(t).Interface().(Overrider).JSONSchema()
+	}
+
+	if reflect.PtrTo(t).Implements(overriderType) {
+
+		return reflect.New(t).Interface().(Overrider).JSONSchema()
+	}
+
+	switch t {
+	case timeType:
+
+		return &Schema{Type: "string", Format: "date-time"}
+
+	case rawMessageType:
+
+		return &Schema{}
+	}
+
+	if t.Kind() != reflect.Ptr {
+
+		if reflect.PtrTo(t).Implements(marshalerType) {
+
+			return &Schema{}
+		}
+
+		if reflect.PtrTo(t).Implements(textMarshalerType) {
+
+			return &Schema{Type: "string"}
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+
+		return r.reflect(t.Elem())
+
+	case reflect.Bool:
+
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+
+		return &Schema{Type: "integer"}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+
+		return &Schema{Type: "integer", Minimum: new(float64)}
+
+	case reflect.Float32, reflect.Float64:
+
+		return &Schema{Type: "number"}
+
+	case reflect.String:
+
+		return &Schema{Type: "string"}
+
+	case reflect.Slice:
+
+		if t.Elem().Kind() == reflect.Uint8 {
+
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: r.reflect(t.Elem())}
+
+	case reflect.Array:
+
+		length := t.Len()
+
+		return &Schema{Type: "array", Items: r.reflect(t.Elem()), MinItems: &length, MaxItems: &length}
+
+	case reflect.Map:
+
+		return &Schema{Type: "object", AdditionalProperties: r.reflect(t.Elem())}
+
+	case reflect.Struct:
+
+		return r.reflectStruct(t)
+	}
+
+	return &Schema{}
+}
+
+func (r *reflector) reflectStruct(t reflect.Type) *Schema {
+
+	if r.stack[t] {
+
+		r.recursive[t] = true
+
+		return &Schema{Ref: "#/$defs/" + r.name(t)}
+	}
+
+	r.stack[t] = true
+
+	defer delete(r.stack, t)
+
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for _, field := range Fields(t) {
+
+		schema.Properties[field.Name] = r.reflect(field.Type)
+
+		if !field.OmitEmpty && field.Type.Kind() != reflect.Ptr {
+
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+
+	if r.recursive[t] {
+
+		r.definitions[r.name(t)] = schema
+
+		return &Schema{Ref: "#/$defs/" + r.name(t)}
+	}
+
+	return schema
+}
+
+func (r *reflector) name(t reflect.Type) string {
+
+	if t.Name() != "" {
+
+		return t.Name()
+	}
+
+	return strings.NewReplacer(" ", "", "{", "_", "}", "_", ";", "_", "\"", "").Replace(t.String())
+}
+
+type Field struct {
+	Name      string
+	Type      reflect.Type
+	OmitEmpty bool
+	Index     []int
+	tagged    bool
+}
+
+// Fields returns the JSON fields of a struct type following the encoding/json
+// rules for tags and embedded structs.
+func Fields(t reflect.Type) []Field {
+
+	var (
+		fields  []Field
+		hidden  = make(map[string]bool)
+		visited = map[reflect.Type]bool{t: true}
+		current = []Field{{Type: t}}
+		next    []Field
+	)
+
+	for len(current) != 0 {
+
+		var level []Field
+
+		for _, parent := range current {
+
+			for i := 0; i < parent.Type.NumField(); i++ {
+
+				field := parent.Type.Field(i)
+
+				ft := field.Type
+
+				if field.Anonymous && ft.Kind() == reflect.Ptr {
+
+					ft = ft.Elem()
+				}
+
+				if field.PkgPath != "" && !(field.Anonymous && ft.Kind() == reflect.Struct) {
+
+					continue
+				}
+
+				tag := field.Tag.Get("json")
+
+				if tag == "-" {
+
+					continue
+				}
+
+				name, options := tag, ""
+
+				if i := strings.Index(tag, ","); i != -1 {
+
+					name, options = tag[:i], tag[i:]
+				}
+
+				index := append(append([]int{}, parent.Index...), i)
+
+				if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+
+					if !visited[ft] {
+
+						visited[ft] = true
+
+						next = append(next, Field{Type: ft, Index: index})
+					}
+
+					continue
+				}
+
+				if name == "" {
+
+					name = field.Name
+				}
+
+				level = append(level, Field{
+					Name:      name,
+					Type:      field.Type,
+					OmitEmpty: strings.Contains(options, ",omitempty"),
+					Index:     index,
+					tagged:    tag != "" && tag[0] != ',',
+				})
+			}
+		}
+
+		fields = append(fields, dominant(hidden, level)...)
+
+		for _, field := range level {
+
+			hidden[field.Name] = true
+		}
+
+		current, next = next, nil
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+
+		return lessIndex(fields[i].Index, fields[j].Index)
+	})
+
+	return fields
+}
+
+func dominant(hidden map[string]bool, level []Field) []Field {
+
+	byName := make(map[string][]Field)
+
+	for _, field := range level {
+
+		if !hidden[field.Name] {
+
+			byName[field.Name] = append(byName[field.Name], field)
+		}
+	}
+
+	var result []Field
+
+	for _, field := range level {
+
+		candidates, found := byName[field.Name]
+
+		if !found {
+
+			continue
+		}
+
+		delete(byName, field.Name)
+
+		if len(candidates) == 1 {
+
+			result = append(result, candidates[0])
+
+			continue
+		}
+
+		var tagged []Field
+
+		for _, candidate := range candidates {
+
+			if candidate.tagged {
+
+				tagged = append(tagged, candidate)
+			}
+		}
+
+		if len(tagged) == 1 {
+
+			result = append(result, tagged[0])
+		}
+	}
+
+	return result
+}
+
+func lessIndex(a, b []int) bool {
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+
+		if a[i] != b[i] {
+
+			return a[i] < b[i]
+		}
+	}
+
+	return len(a) < len(b)
+}
@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testBase struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+	Name    string    `json:"name"`
+}
+
+type testEmail string
+
+func (testEmail) JSONSchema() *Schema {
+
+	return &Schema{Type: "string", Format: "email"}
+}
+
+type testLevel int
+
+func (*testLevel) JSONSchema() *Schema {
+
+	return &Schema{Type: "integer", Enum: []interface{}{1, 2, 3}}
+}
+
+type testUser struct {
+	testBase
+	*testAudit
+	Name     string          `json:"login"`
+	Email    testEmail       `json:"email"`
+	Level    testLevel       `json:"level,omitempty"`
+	Nick     *string         `json:"nick"`
+	Tags     []string        `json:"tags,omitempty"`
+	Labels   map[string]int  `json:"labels,omitempty"`
+	Avatar   []byte          `json:"avatar,omitempty"`
+	Pair     [2]float64      `json:"pair"`
+	Extra    json.RawMessage `json:"extra,omitempty"`
+	Any      interface{}     `json:"any,omitempty"`
+	Ignored  string          `json:"-"`
+	Untagged bool
+	private  string
+}
+
+type testAudit struct {
+	UpdatedBy string `json:"updatedBy,omitempty"`
+}
+
+type testTree struct {
+	Value    int         `json:"value"`
+	Children []*testTree `json:"children,omitempty"`
+}
+
+func TestFields(t *testing.T) {
+
+	var names []string
+
+	for _, field := range Fields(reflect.TypeOf(testUser{})) {
+
+		names = append(names, field.Name)
+	}
+
+	assert.Equal(t, []string{"id", "created", "name", "updatedBy", "login", "email", "level", "nick", "tags", "labels", "avatar", "pair", "extra", "any", "Untagged"}, names)
+}
+
+func TestReflect(t *testing.T) {
+
+	data, err := json.Marshal(For(&testUser{}))
+
+	if assert.NoError(t, err) {
+
+		assert.JSONEq(t, `{
+			"type": "object",
+			"required": ["id", "created", "name", "login", "email", "pair", "Untagged"],
+			"properties": {
+				"id": {"type": "integer"},
+				"created": {"type": "string", "format": "date-time"},
+				"name": {"type": "string"},
+				"updatedBy": {"type": "string"},
+				"login": {"type": "string"},
+				"email": {"type": "string", "format": "email"},
+				"level": {"type": "integer", "enum": [1, 2, 3]},
+				"nick": {"type": "string"},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"labels": {"type": "object", "additionalProperties": {"type": "integer"}},
+				"avatar": {"type": "string", "format": "byte"},
+				"pair": {"type": "array", "items": {"type": "number"}, "minItems": 2, "maxItems": 2},
+				"extra": {},
+				"any": {},
+				"Untagged": {"type": "boolean"}
+			}
+		}`, string(data))
+	}
+}
+
+func TestReflectRecursive(t *testing.T) {
+
+	data, err := json.Marshal(For(testTree{}))
+
+	if assert.NoError(t, err) {
+
+		assert.JSONEq(t, `{
+			"$ref": "#/$defs/testTree",
+			"$defs": {
+				"testTree": {
+					"type": "object",
+					"required": ["value"],
+					"properties": {
+						"value": {"type": "integer"},
+						"children": {"type": "array", "items": {"$ref": "#/$defs/testTree"}}
+					}
+				}
+			}
+		}`, string(data))
+	}
+}
@@ -0,0 +1,336 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+
+	if e.Path == "" {
+
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks a JSON document against the schema. An empty document is
+// validated as an empty object and null is accepted for any type, as encoding/json does.
+func (s *Schema) Validate(data json.RawMessage) error {
+
+	if len(bytes.TrimSpace(data)) == 0 {
+
+		data = json.RawMessage("{}")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+
+	if err := decoder.Decode(&value); err != nil {
+
+		return &ValidationError{Message: err.Error()}
+	}
+
+	return s.validate(s, "", value)
+}
+
+func (s *Schema) validate(root *Schema, path string, value interface{}) error {
+
+	if s.Ref != "" {
+
+		definition, found := root.Definitions[strings.TrimPrefix(s.Ref, "#/$defs/")]
+
+		if !found {
+
+			return &ValidationError{Path: path, Message: fmt.Sprintf("unresolved reference %s", s.Ref)}
+		}
+
+		return definition.validate(root, path, value)
+	}
+
+	if value != nil && len(s.Enum) != 0 && !s.inEnum(value) {
+
+		return &ValidationError{Path: path, Message: "value is not one of the allowed values"}
+	}
+
+	switch v := value.(type) {
+	case bool:
+
+		if s.Type != "" && s.Type != "boolean" {
+
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got boolean", s.Type)}
+		}
+
+	case json.Number:
+
+		return s.validateNumber(path, v)
+
+	case string:
+
+		return s.validateString(path, v)
+
+	case []interface{}:
+
+		return s.validateArray(root, path, v)
+
+	case map[string]interface{}:
+
+		return s.validateObject(root, path, v)
+	}
+
+	return nil
+}
+
+func (s *Schema) validateNumber(path string, v json.Number) error {
+
+	switch s.Type {
+	case "", "number":
+
+	case "integer":
+
+		if _, err := v.Int64(); err != nil {
+
+			if f, err := v.Float64(); err != nil || f != float64(int64(f)) {
+
+				return &ValidationError{Path: path, Message: fmt.Sprintf("expected integer, got %s", v)}
+			}
+		}
+
+	default:
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got number", s.Type)}
+	}
+
+	f, _ := v.Float64()
+
+	if s.Minimum != nil && f < *s.Minimum {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("%s is less than %v", v, *s.Minimum)}
+	}
+
+	if s.Maximum != nil && f > *s.Maximum {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("%s is greater than %v", v, *s.Maximum)}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateString(path string, v string) error {
+
+	if s.Type != "" && s.Type != "string" {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got string", s.Type)}
+	}
+
+	length := utf8.RuneCountInString(v)
+
+	if s.MinLength != nil && length < *s.MinLength {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("length is less than %d", *s.MinLength)}
+	}
+
+	if s.MaxLength != nil && length > *s.MaxLength {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("length is greater than %d", *s.MaxLength)}
+	}
+
+	if s.Pattern != "" {
+
+		re := s.pattern
+
+		if re == nil {
+
+			var err error
+
+			if re, err = regexp.Compile(s.Pattern); err != nil {
+
+				return &ValidationError{Path: path, Message: fmt.Sprintf("invalid pattern: %s", err)}
+			}
+		}
+
+		if !re.MatchString(v) {
+
+			return &ValidationError{Path: path, Message: fmt.Sprintf("does not match pattern %s", s.Pattern)}
+		}
+	}
+
+	return nil
+}
+
+// compile compiles the patterns of s and of its subschemas once, a schema
+// which was not compiled compiles them on every validation.
+func (s *Schema) compile() {
+
+	if s == nil {
+
+		return
+	}
+
+	if s.Pattern != "" {
+
+		s.pattern, _ = regexp.Compile(s.Pattern)
+	}
+
+	for _, property := range s.Properties {
+
+		property.compile()
+	}
+
+	for _, definition := range s.Definitions {
+
+		definition.compile()
+	}
+
+	s.AdditionalProperties.compile()
+	s.Items.compile()
+}
+
+func (s *Schema) validateArray(root *Schema, path string, v []interface{}) error {
+
+	if s.Type != "" && s.Type != "array" {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got array", s.Type)}
+	}
+
+	if s.MinItems != nil && len(v) < *s.MinItems {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("less than %d items", *s.MinItems)}
+	}
+
+	if s.MaxItems != nil && len(v) > *s.MaxItems {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("more than %d items", *s.MaxItems)}
+	}
+
+	if s.Items == nil {
+
+		return nil
+	}
+
+	for i, item := range v {
+
+		if err := s.Items.validate(root, fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateObject(root *Schema, path string, v map[string]interface{}) error {
+
+	if s.Type != "" && s.Type != "object" {
+
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got object", s.Type)}
+	}
+
+	for _, name := range s.Required {
+
+		if _, found := v[name]; !found {
+
+			return &ValidationError{Path: join(path, name), Message: "required property is missing"}
+		}
+	}
+
+	names := make([]string, 0, len(v))
+
+	for name := range v {
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		value := v[name]
+
+		if property, found := s.Properties[name]; found {
+
+			if err := property.validate(root, join(path, name), value); err != nil {
+
+				return err
+			}
+
+			continue
+		}
+
+		if s.AdditionalProperties != nil {
+
+			if err := s.AdditionalProperties.validate(root, join(path, name), value); err != nil {
+
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) inEnum(value interface{}) bool {
+
+	for _, allowed := range s.Enum {
+
+		if n, ok := value.(json.Number); ok {
+
+			if f, err := n.Float64(); err == nil && reflect.DeepEqual(toFloat(allowed), f) {
+
+				return true
+			}
+
+			continue
+		}
+
+		if reflect.DeepEqual(allowed, value) {
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func toFloat(v interface{}) interface{} {
+
+	switch n := reflect.ValueOf(v); n.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+
+		return float64(n.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+
+		return float64(n.Uint())
+
+	case reflect.Float32, reflect.Float64:
+
+		return n.Float()
+	}
+
+	return v
+}
+
+func join(path, name string) string {
+
+	if path == "" {
+
+		return name
+	}
+
+	return path + "." + name
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+
+	user := For(testUser{})
+
+	valid := `{"id": 1, "created": "2015-01-01T00:00:00Z", "name": "n", "login": "l", "email": "e", "pair": [1, 2.5], "Untagged": true}`
+
+	assert.NoError(t, user.Validate([]byte(valid)))
+
+	for data, message := range map[string]string{
+		`[]`:        "expected object, got array",
+		`{"id": 1}`: "created: required property is missing",
+		`{"id": "1", "created": "", "name": "", "login": "", "email": "", "pair": [1, 2], "Untagged": true}`:                         "id: expected integer, got string",
+		`{"id": 1.5, "created": "", "name": "", "login": "", "email": "", "pair": [1, 2], "Untagged": true}`:                         "id: expected integer, got 1.5",
+		`{"id": 1, "created": "", "name": "", "login": "", "email": "", "pair": [1], "Untagged": true}`:                              "pair: less than 2 items",
+		`{"id": 1, "created": "", "name": "", "login": "", "email": "", "pair": [1, 2], "Untagged": true, "level": 4}`:               "level: value is not one of the allowed values",
+		`{"id": 1, "created": "", "name": "", "login": "", "email": "", "pair": [1, 2], "Untagged": true, "labels": {"a": "b"}}`:     "labels.a: expected integer, got string",
+		`{"id": 1, "created": "", "name": "", "login": "", "email": "", "pair": [1, 2], "Untagged": true, "tags": ["a", 1]}`:         "tags[1]: expected string, got number",
+		`{"id": 1, "created": "", "name": "", "login": "", "email": "", "pair": [1, 2], "Untagged": true, "nick": null, "level": 2}`: "",
+	} {
+
+		err := user.Validate([]byte(data))
+
+		if message == "" {
+
+			assert.NoError(t, err, data)
+
+			continue
+		}
+
+		if assert.Error(t, err, data) {
+
+			assert.Equal(t, message, err.Error())
+		}
+	}
+
+	tree := For(testTree{})
+
+	assert.NoError(t, tree.Validate([]byte(`{"value": 1, "children": [{"value": 2}]}`)))
+
+	if err := tree.Validate([]byte(`{"value": 1, "children": [{"value": "2"}]}`)); assert.Error(t, err) {
+
+		assert.Equal(t, "children[0].value: expected integer, got string", err.Error())
+	}
+
+	assert.NoError(t, For(struct{}{}).Validate(nil))
+
+	min, max, length := 1.0, 10.0, 3
+
+	bounded := &Schema{Type: "object", Properties: map[string]*Schema{
+		"n": {Type: "number", Minimum: &min, Maximum: &max},
+		"s": {Type: "string", MaxLength: &length, Pattern: "^[a-z]+$"},
+	}}
+
+	assert.NoError(t, bounded.Validate([]byte(`{"n": 5, "s": "abc"}`)))
+	assert.Error(t, bounded.Validate([]byte(`{"n": 0}`)))
+	assert.Error(t, bounded.Validate([]byte(`{"n": 11}`)))
+	assert.Error(t, bounded.Validate([]byte(`{"s": "abcd"}`)))
+	assert.Error(t, bounded.Validate([]byte(`{"s": "AB"}`)))
+	assert.Error(t, bounded.Validate([]byte(`{"s": `)))
+
+	for i := 0; i < 10; i++ {
+
+		if err := bounded.Validate([]byte(`{"s": "AB", "n": 0}`)); assert.Error(t, err) {
+
+			assert.Equal(t, "n: 0 is less than 1", err.Error())
+		}
+	}
+
+	bounded.compile()
+
+	if assert.NotNil(t, bounded.Properties["s"].pattern) {
+
+		assert.Error(t, bounded.Validate([]byte(`{"s": "AB"}`)))
+	}
+
+	invalid := &Schema{Type: "string", Pattern: "("}
+
+	invalid.compile()
+
+	assert.Error(t, invalid.Validate([]byte(`"a"`)))
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"github.com/kshvakov/jsonrpc2"
+	"net/http"
+	"strings"
+)
+
+type Principal struct {
+	Name   string
+	Roles  []string
+	Scopes []string
+}
+
+func (p *Principal) Has(roleOrScope string) bool {
+
+	for _, list := range [][]string{p.Roles, p.Scopes} {
+
+		for _, v := range list {
+
+			if v == roleOrScope {
+
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Authenticator returns the principal for the request, nil for an anonymous
+// request or an error if the request carries invalid credentials.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+func (fn AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+
+	return fn(r)
+}
+
+func BearerAuthenticator(fn func(token string) (*Principal, error)) Authenticator {
+
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+
+		header := r.Header.Get("Authorization")
+
+		if !strings.HasPrefix(header, "Bearer ") {
+
+			return nil, nil
+		}
+
+		return fn(strings.TrimPrefix(header, "Bearer "))
+	})
+}
+
+func BasicAuthenticator(fn func(username, password string) (*Principal, error)) Authenticator {
+
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+
+		username, password, ok := r.BasicAuth()
+
+		if !ok {
+
+			return nil, nil
+		}
+
+		return fn(username, password)
+	})
+}
+
+func CertificateAuthenticator(fn func(certificate *x509.Certificate) (*Principal, error)) Authenticator {
+
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+
+			return nil, nil
+		}
+
+		return fn(r.TLS.PeerCertificates[0])
+	})
+}
+
+// Authenticators tries each authenticator in order and returns the first principal found.
+func Authenticators(authenticators ...Authenticator) Authenticator {
+
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+
+		for _, authenticator := range authenticators {
+
+			principal, err := authenticator.Authenticate(r)
+
+			if err != nil || principal != nil {
+
+				return principal, err
+			}
+		}
+
+		return nil, nil
+	})
+}
+
+func (s *server) SetAuthenticator(authenticator Authenticator) {
+
+	s.authenticator = authenticator
+}
+
+type principalKey struct{}
+
+type authentication struct {
+	principal *Principal
+	err       error
+}
+
+func PrincipalFromContext(ctx context.Context) *Principal {
+
+	if auth, ok := ctx.Value(principalKey{}).(*authentication); ok {
+
+		return auth.principal
+	}
+
+	return nil
+}
+
+func (s *server) authenticate(ctx context.Context, r *http.Request) context.Context {
+
+	if s.authenticator == nil {
+
+		return ctx
+	}
+
+	principal, err := s.authenticator.Authenticate(r)
+
+	return context.WithValue(ctx, principalKey{}, &authentication{
+		principal: principal,
+		err:       err,
+	})
+}
+
+func authenticationError(ctx context.Context) *jsonrpc2.Error {
+
+	if auth, ok := ctx.Value(principalKey{}).(*authentication); ok && auth.err != nil {
+
+		return jsonrpc2.NewError(jsonrpc2.Unauthorized, auth.err.Error())
+	}
+
+	return nil
+}
+
+func NewAuthorizer() *Authorizer {
+
+	return &Authorizer{
+		rules: make(map[string][]string),
+	}
+}
+
+// Authorizer maps method names, RegisterObject namespaces or "*" to the roles or
+// scopes a principal needs to have at least one of. Methods without a rule are public.
+type Authorizer struct {
+	rules map[string][]string
+}
+
+func (a *Authorizer) Require(pattern string, rolesOrScopes ...string) *Authorizer {
+
+	a.rules[pattern] = rolesOrScopes
+
+	return a
+}
+
+func (a *Authorizer) rule(method string) ([]string, bool) {
+
+	for name := method; ; {
+
+		if rolesOrScopes, found := a.rules[name]; found {
+
+			return rolesOrScopes, true
+		}
+
+		i := strings.LastIndex(name, ".")
+
+		if i == -1 {
+
+			break
+		}
+
+		name = name[:i]
+	}
+
+	rolesOrScopes, found := a.rules["*"]
+
+	return rolesOrScopes, found
+}
+
+func (a *Authorizer) Middleware(next HandlerFunc) HandlerFunc {
+
+	return func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+		rolesOrScopes, found := a.rule(request.Method)
+
+		if !found {
+
+			return next(ctx, request)
+		}
+
+		principal := PrincipalFromContext(ctx)
+
+		if principal == nil {
+
+			return nil, jsonrpc2.NewError(jsonrpc2.Unauthorized, "")
+		}
+
+		if len(rolesOrScopes) == 0 {
+
+			return next(ctx, request)
+		}
+
+		for _, v := range rolesOrScopes {
+
+			if principal.Has(v) {
+
+				return next(ctx, request)
+			}
+		}
+
+		return nil, jsonrpc2.NewError(jsonrpc2.Forbidden, "")
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testAdmin struct{}
+
+func (t *testAdmin) Delete(ctx context.Context, _ *testCallParams) (interface{}, error) {
+
+	return PrincipalFromContext(ctx).Name, nil
+}
+
+func (t *testAdmin) List(ctx context.Context, _ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+	return PrincipalFromContext(ctx).Name, nil
+}
+
+func TestServerAuth(t *testing.T) {
+
+	server := New()
+	server.SetAuthenticator(BearerAuthenticator(func(token string) (*Principal, error) {
+
+		switch token {
+		case "admin":
+
+			return &Principal{Name: "root", Roles: []string{"admin"}}, nil
+
+		case "reader":
+
+			return &Principal{Name: "reader", Scopes: []string{"admin:read"}}, nil
+		}
+
+		return nil, errors.New("invalid token")
+	}))
+
+	server.Use(NewAuthorizer().
+		Require("Admin", "admin").
+		Require("Admin.List", "admin", "admin:read").
+		Middleware,
+	)
+
+	server.RegisterObject("Admin", &testAdmin{})
+	server.RegisterFunc("Public", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		return "OK", nil
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	for _, test := range []struct {
+		token, method, params string
+		code                  int16
+		result                string
+	}{
+		{"", "Public", `{}`, 0, "OK"},
+		{"", "Admin.Delete", `"not an object"`, jsonrpc2.Unauthorized, ""},
+		{"invalid", "Public", `{}`, jsonrpc2.Unauthorized, ""},
+		{"reader", "Admin.Delete", `"not an object"`, jsonrpc2.Forbidden, ""},
+		{"reader", "Admin.List", `{}`, 0, "reader"},
+		{"admin", "Admin.Delete", `{}`, 0, "root"},
+		{"admin", "Admin.Delete", `"not an object"`, jsonrpc2.ParseError, ""},
+	} {
+
+		body := `{"jsonrpc":"2.0","id":42,"method":"` + test.method + `","params":` + test.params + `}`
+
+		response := testPost(t, testServer.URL, test.method, func(r *http.Request) {
+
+			r.Body, r.ContentLength = ioutil.NopCloser(strings.NewReader(body)), int64(len(body))
+
+			if test.token != "" {
+
+				r.Header.Set("Authorization", "Bearer "+test.token)
+			}
+		})
+
+		if !assert.NotNil(t, response) || !assert.Equal(t, 42, response.RequestID) {
+
+			continue
+		}
+
+		if test.code == 0 {
+
+			if assert.Nil(t, response.Error, test.method) {
+
+				assert.Equal(t, test.result, response.Result)
+			}
+
+			continue
+		}
+
+		if assert.NotNil(t, response.Error, test.method) {
+
+			assert.Equal(t, test.code, response.Error.Code, "%s with token %q", test.method, test.token)
+		}
+	}
+}
+
+func TestAuthenticators(t *testing.T) {
+
+	authenticator := Authenticators(
+		BearerAuthenticator(func(token string) (*Principal, error) {
+
+			return &Principal{Name: "bearer:" + token}, nil
+		}),
+		BasicAuthenticator(func(username, password string) (*Principal, error) {
+
+			if password != "secret" {
+
+				return nil, errors.New("invalid password")
+			}
+
+			return &Principal{Name: "basic:" + username}, nil
+		}),
+	)
+
+	r := httptest.NewRequest("POST", "/", nil)
+
+	if principal, err := authenticator.Authenticate(r); assert.NoError(t, err) {
+
+		assert.Nil(t, principal)
+	}
+
+	r.SetBasicAuth("user", "secret")
+
+	if principal, err := authenticator.Authenticate(r); assert.NoError(t, err) && assert.NotNil(t, principal) {
+
+		assert.Equal(t, "basic:user", principal.Name)
+	}
+
+	r.SetBasicAuth("user", "wrong")
+
+	_, err := authenticator.Authenticate(r)
+
+	assert.Error(t, err)
+
+	r.Header.Set("Authorization", "Bearer token")
+
+	if principal, err := authenticator.Authenticate(r); assert.NoError(t, err) && assert.NotNil(t, principal) {
+
+		assert.Equal(t, "bearer:token", principal.Name)
+	}
+
+	if principal, err := CertificateAuthenticator(nil).Authenticate(r); assert.NoError(t, err) {
+
+		assert.Nil(t, principal)
+	}
+}
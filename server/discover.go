@@ -0,0 +1,102 @@
+package server
+
+import (
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/openrpc"
+	"github.com/kshvakov/jsonrpc2/schema"
+	"reflect"
+	"sort"
+)
+
+const discoverMethod = "rpc.discover"
+
+type MethodDoc struct {
+	Summary     string
+	Description string
+	Errors      []jsonrpc2.Error
+	Deprecated  bool
+}
+
+func (s *server) Describe(method string, doc MethodDoc) {
+
+	s.docs[method] = doc
+}
+
+func (s *server) EnableDiscover(info openrpc.Info) {
+
+	s.RegisterFunc(discoverMethod, func(_ *jsonrpc2.EmptyParams) (*openrpc.Document, error) {
+
+		return s.Document(info), nil
+	})
+}
+
+func (s *server) Document(info openrpc.Info) *openrpc.Document {
+
+	document := openrpc.Document{
+		OpenRPC: openrpc.Version,
+		Info:    info,
+		Methods: make([]openrpc.Method, 0, len(s.handlers)),
+	}
+
+	for name, handler := range s.handlers {
+
+		if name == discoverMethod {
+
+			continue
+		}
+
+		doc := s.docs[name]
+
+		method := openrpc.Method{
+			Name:           name,
+			Summary:        doc.Summary,
+			Description:    doc.Description,
+			Params:         paramDescriptors(reflect.TypeOf(handler.params).Elem()),
+			Errors:         doc.Errors,
+			Deprecated:     doc.Deprecated,
+			ParamStructure: "by-name",
+			Result: &openrpc.ContentDescriptor{
+				Name:   "result",
+				Schema: schema.Reflect(handler.result),
+			},
+		}
+
+		if handler.namespace != "" {
+
+			method.Tags = []openrpc.Tag{{Name: handler.namespace}}
+		}
+
+		document.Methods = append(document.Methods, method)
+	}
+
+	sort.Slice(document.Methods, func(i, j int) bool {
+
+		return document.Methods[i].Name < document.Methods[j].Name
+	})
+
+	return &document
+}
+
+func paramDescriptors(t reflect.Type) []openrpc.ContentDescriptor {
+
+	if t.Kind() != reflect.Struct {
+
+		return []openrpc.ContentDescriptor{}
+	}
+
+	var (
+		fields = schema.Fields(t)
+		params = make([]openrpc.ContentDescriptor, 0, len(fields))
+	)
+
+	for _, field := range fields {
+
+		params = append(params, openrpc.ContentDescriptor{
+			Name:     field.Name,
+			Required: !field.OmitEmpty && field.Type.Kind() != reflect.Ptr,
+			Schema:   schema.Reflect(field.Type),
+		})
+	}
+
+	return params
+}
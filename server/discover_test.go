@@ -0,0 +1,196 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/openrpc"
+	"github.com/kshvakov/jsonrpc2/schema"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testDiscoverParams struct {
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags,omitempty"`
+	Limit int
+	skip  bool
+}
+
+func (t *testDiscoverParams) IsValid() bool {
+
+	return true
+}
+
+type testDiscoverResult struct {
+	Items []testDiscoverItem  `json:"items"`
+	Next  *testDiscoverResult `json:"next,omitempty"`
+}
+
+type testDiscoverItem struct {
+	ID     int64             `json:"id"`
+	Labels map[string]string `json:"labels"`
+	Ignore string            `json:"-"`
+}
+
+type testDiscoverService struct{}
+
+func (t *testDiscoverService) Find(_ *testDiscoverParams) (*testDiscoverResult, error) {
+
+	return nil, nil
+}
+
+func TestServerDocument(t *testing.T) {
+
+	server := New()
+	server.RegisterObject("Catalog", &testDiscoverService{})
+	server.RegisterFunc("Ping", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		return "pong", nil
+	})
+
+	server.Describe("Catalog.Find", MethodDoc{
+		Summary: "Find items",
+		Errors: []jsonrpc2.Error{
+			{Code: jsonrpc2.LogicErr, Message: "not found"},
+		},
+	})
+
+	server.EnableDiscover(openrpc.Info{Title: "Catalog", Version: "1.0.0"})
+
+	document := server.Document(openrpc.Info{Title: "Catalog", Version: "1.0.0"})
+
+	if assert.Len(t, document.Methods, 2) {
+
+		assert.Equal(t, openrpc.Version, document.OpenRPC)
+		assert.Equal(t, "Ping", document.Methods[1].Name)
+		assert.Empty(t, document.Methods[1].Params)
+
+		if find, found := document.Method("Catalog.Find"); assert.True(t, found) {
+
+			assert.Equal(t, "Find items", find.Summary)
+			assert.Equal(t, []openrpc.Tag{{Name: "Catalog"}}, find.Tags)
+			assert.Equal(t, "by-name", find.ParamStructure)
+			assert.Len(t, find.Errors, 1)
+
+			if assert.Len(t, find.Params, 3) {
+
+				assert.Equal(t, openrpc.ContentDescriptor{
+					Name:     "name",
+					Required: true,
+					Schema:   &schema.Schema{Type: "string"},
+				}, find.Params[0])
+
+				assert.Equal(t, "tags", find.Params[1].Name)
+				assert.False(t, find.Params[1].Required)
+				assert.Equal(t, "Limit", find.Params[2].Name)
+				assert.Equal(t, &schema.Schema{Type: "integer"}, find.Params[2].Schema)
+			}
+
+			data, _ := json.Marshal(find.Result.Schema)
+
+			assert.JSONEq(t, `{
+				"$ref": "#/$defs/testDiscoverResult",
+				"$defs": {
+					"testDiscoverResult": {
+						"type": "object",
+						"required": ["items"],
+						"properties": {
+							"items": {"type": "array", "items": {
+								"type": "object",
+								"required": ["id", "labels"],
+								"properties": {
+									"id": {"type": "integer"},
+									"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+								}
+							}},
+							"next": {"$ref": "#/$defs/testDiscoverResult"}
+						}
+					}
+				}
+			}`, string(data))
+		}
+	}
+}
+
+func TestServerDiscover(t *testing.T) {
+
+	server := New()
+	server.RegisterObject("Catalog", &testDiscoverService{})
+	server.EnableDiscover(openrpc.Info{Title: "Catalog", Version: "1.0.0"})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	response, err := http.Post(testServer.URL, "application/json", bytes.NewReader([]byte(`{"jsonrpc":"2.0","id":1,"method":"rpc.discover"}`)))
+
+	if assert.NoError(t, err) {
+
+		result := jsonrpc2.Response{
+			Result: &openrpc.Document{},
+		}
+
+		if err := json.NewDecoder(response.Body).Decode(&result); assert.NoError(t, err) && assert.Nil(t, result.Error) {
+
+			document := result.Result.(*openrpc.Document)
+
+			assert.Equal(t, "Catalog", document.Info.Title)
+
+			if assert.Len(t, document.Methods, 1) {
+
+				assert.Equal(t, "Catalog.Find", document.Methods[0].Name)
+			}
+		}
+	}
+}
+
+func TestServerValidateParams(t *testing.T) {
+
+	server := New()
+	server.SetValidateParams(true)
+	server.RegisterObject("Catalog", &testDiscoverService{})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	for params, code := range map[string]int16{
+		`{"name": "n", "Limit": 1}`:                0,
+		`{"name": "n"}`:                            jsonrpc2.InvalidParams,
+		`{"name": 1, "Limit": 1}`:                  jsonrpc2.InvalidParams,
+		`{"name": "n", "Limit": 1, "tags": ["a"]}`: 0,
+	} {
+
+		response, err := http.Post(testServer.URL, "application/json", bytes.NewReader([]byte(`{"jsonrpc":"2.0","id":1,"method":"Catalog.Find","params":`+params+`}`)))
+
+		if !assert.NoError(t, err) {
+
+			continue
+		}
+
+		var result jsonrpc2.Response
+
+		err = json.NewDecoder(response.Body).Decode(&result)
+
+		response.Body.Close()
+
+		if assert.NoError(t, err) {
+
+			if code == 0 {
+
+				assert.Nil(t, result.Error, params)
+
+				continue
+			}
+
+			if assert.NotNil(t, result.Error, params) {
+
+				assert.Equal(t, code, result.Error.Code)
+				assert.NotEmpty(t, result.Error.Data)
+			}
+		}
+	}
+}
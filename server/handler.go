@@ -1,20 +1,31 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/schema"
 	"reflect"
 )
 
 type handler struct {
-	method reflect.Value
-	params jsonrpc2.Params
+	method    reflect.Value
+	params    jsonrpc2.Params
+	result    reflect.Type
+	schema    *schema.Schema
+	context   bool
+	namespace string
 }
 
 func (h *handler) DecodeParams(message json.RawMessage) (jsonrpc2.Params, error) {
 
 	params := reflect.New(reflect.TypeOf(h.params).Elem()).Interface()
 
+	if len(message) == 0 || string(message) == "null" {
+
+		return params.(jsonrpc2.Params), nil
+	}
+
 	if err := json.Unmarshal(message, &params); err != nil {
 
 		return nil, err
@@ -25,13 +36,29 @@ func (h *handler) DecodeParams(message json.RawMessage) (jsonrpc2.Params, error)
 
 func (h *handler) Call(params jsonrpc2.Params) (interface{}, error) {
 
-	result := h.method.Call([]reflect.Value{reflect.ValueOf(params)})
+	return h.CallContext(context.Background(), params)
+}
+
+func (h *handler) CallContext(ctx context.Context, params jsonrpc2.Params) (interface{}, error) {
+
+	in := []reflect.Value{reflect.ValueOf(params)}
+
+	if h.context {
+
+		in = []reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(params)}
+	}
+
+	result := h.method.Call(in)
 
 	if result[1].IsNil() {
 
-		if result[0].IsNil() {
+		switch result[0].Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+
+			if result[0].IsNil() {
 
-			return nil, nil
+				return nil, nil
+			}
 		}
 
 		return result[0].Interface(), nil
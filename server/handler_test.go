@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/kshvakov/jsonrpc2"
@@ -174,3 +175,59 @@ func TestHandlerCall(t *testing.T) {
 		}
 	}
 }
+
+func TestHandlerCallContext(t *testing.T) {
+
+	type key struct{}
+
+	fn := func(ctx context.Context, params *testCallParams) (interface{}, error) {
+
+		return ctx.Value(key{}), nil
+	}
+
+	h := handler{
+		method:  reflect.ValueOf(fn),
+		params:  reflect.New(reflect.ValueOf(fn).Type().In(1).Elem()).Interface().(jsonrpc2.Params),
+		context: true,
+	}
+
+	data, _ := json.Marshal(&testCallParams{Message: "Message"})
+
+	if p, err := h.DecodeParams(data); assert.NoError(t, err) {
+
+		if result, err := h.CallContext(context.WithValue(context.Background(), key{}, "value"), p); assert.NoError(t, err) {
+
+			assert.Equal(t, "value", result)
+		}
+
+		if result, err := h.Call(p); assert.NoError(t, err) {
+
+			assert.Nil(t, result)
+		}
+	}
+}
+
+func TestHandlerCallValueResult(t *testing.T) {
+
+	for expected, fn := range map[interface{}]interface{}{
+		42: func(params *testCallParams) (int, error) {
+
+			return 42, nil
+		},
+		"": func(params *testCallParams) (string, error) {
+
+			return "", nil
+		},
+	} {
+
+		h := handler{
+			method: reflect.ValueOf(fn),
+			params: &testCallParams{},
+		}
+
+		if result, err := h.Call(&testCallParams{}); assert.NoError(t, err) {
+
+			assert.Equal(t, expected, result)
+		}
+	}
+}
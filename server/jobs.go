@@ -0,0 +1,383 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"sync"
+	"time"
+)
+
+// JobRecord is a job with its result and the name of the principal that
+// started it, empty for an anonymous caller.
+type JobRecord struct {
+	jsonrpc2.Job
+	Principal string          `json:"principal,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+}
+
+// JobStore keeps the jobs by ID. Save keeps a job for ttl, a zero ttl keeps
+// it until it is saved again. Load returns nil for an unknown or expired job.
+type JobStore interface {
+	Save(job *JobRecord, ttl time.Duration) error
+	Load(id string) (*JobRecord, error)
+}
+
+type runningJob struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// EnableJobs runs the calls of methods in the background. A call returns the
+// jsonrpc2.Job once its params are valid, the job is then read and cancelled
+// with the rpc.job methods by the principal that started it, other callers
+// get Forbidden.
+func (s *server) EnableJobs(methods ...string) {
+
+	for _, method := range methods {
+
+		s.jobMethods[method] = true
+	}
+
+	if _, found := s.handlers[jsonrpc2.JobStatusMethod]; found {
+
+		return
+	}
+
+	s.RegisterFunc(jsonrpc2.JobStatusMethod, s.jobStatus)
+	s.RegisterFunc(jsonrpc2.JobResultMethod, s.jobResult)
+	s.RegisterFunc(jsonrpc2.JobCancelMethod, s.jobCancel)
+}
+
+// SetJobStore sets where the jobs are kept, in memory by default. Servers
+// balanced by one client must share a store unless their jobs are only
+// waited for with jsonrpc2.CallJob, which polls the upstream of the job.
+func (s *server) SetJobStore(store JobStore) {
+
+	s.jobStore = store
+}
+
+// SetJobTTL sets how long finished jobs are kept, 10 minutes by default.
+func (s *server) SetJobTTL(ttl time.Duration) {
+
+	s.jobTTL = ttl
+}
+
+func (s *server) startJob(ctx context.Context, request *jsonrpc2.ServerRequest, h handler, params jsonrpc2.Params) (interface{}, error) {
+
+	id := make([]byte, 16)
+
+	if _, err := rand.Read(id); err != nil {
+
+		return nil, err
+	}
+
+	now := time.Now()
+
+	record := &JobRecord{
+		Job: jsonrpc2.Job{
+			ID:      hex.EncodeToString(id),
+			Method:  request.Method,
+			State:   jsonrpc2.JobRunning,
+			Created: now,
+			Updated: now,
+		},
+		Principal: principalName(ctx),
+	}
+
+	if !s.begin() {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.ServerError, "shutting down")
+	}
+
+	if err := s.jobStore.Save(record, 0); err != nil {
+
+		s.end()
+
+		return nil, err
+	}
+
+	ctx, cancel := s.context(context.WithoutCancel(ctx))
+
+	job := &runningJob{cancel: cancel}
+
+	s.mutex.Lock()
+
+	s.jobs[record.ID] = job
+
+	s.mutex.Unlock()
+
+	status := record.Job
+
+	go s.runJob(ctx, job, record, request, h, params)
+
+	return &status, nil
+}
+
+func (s *server) runJob(ctx context.Context, job *runningJob, record *JobRecord, request *jsonrpc2.ServerRequest, h handler, params jsonrpc2.Params) {
+
+	defer s.end()
+	defer job.cancel()
+
+	result, err := s.callJob(ctx, request, h, params)
+
+	s.mutex.Lock()
+
+	delete(s.jobs, record.ID)
+
+	cancelled := job.cancelled
+
+	s.mutex.Unlock()
+
+	if cancelled {
+
+		return
+	}
+
+	record.State, record.Updated = jsonrpc2.JobDone, time.Now()
+
+	if err == nil {
+
+		if record.Result, err = json.Marshal(result); err != nil {
+
+			err = jsonrpc2.NewError(jsonrpc2.InternalError, "")
+		}
+	}
+
+	if err != nil {
+
+		record.State, record.Error, record.Result = jsonrpc2.JobFailed, responseError(err), nil
+	}
+
+	s.jobStore.Save(record, s.jobTTL)
+}
+
+func (s *server) callJob(ctx context.Context, request *jsonrpc2.ServerRequest, h handler, params jsonrpc2.Params) (result interface{}, err error) {
+
+	defer func() {
+
+		if message := recover(); message != nil {
+
+			result, err = nil, s.recover(request, message).Error
+		}
+	}()
+
+	release, ok := s.acquire(ctx, request.Method)
+
+	if !ok {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.Overloaded, "concurrency limit exceeded")
+	}
+
+	defer release()
+
+	if result, err = h.CallContext(ctx, params); err != nil {
+
+		return nil, err
+	}
+
+	if stream, ok := result.(jsonrpc2.Stream); ok {
+
+		data, err := stream.MarshalJSON()
+
+		if err != nil {
+
+			return nil, err
+		}
+
+		return json.RawMessage(data), nil
+	}
+
+	return result, nil
+}
+
+// loadJob loads a job of the principal of ctx.
+func (s *server) loadJob(ctx context.Context, id string) (*JobRecord, error) {
+
+	record, err := s.jobStore.Load(id)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	if record == nil {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "unknown job")
+	}
+
+	if record.Principal != principalName(ctx) {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.Forbidden, "")
+	}
+
+	return record, nil
+}
+
+// principalName returns the name of the principal of ctx, empty for an
+// anonymous caller.
+func principalName(ctx context.Context) string {
+
+	if principal := PrincipalFromContext(ctx); principal != nil {
+
+		return principal.Name
+	}
+
+	return ""
+}
+
+func (s *server) jobStatus(ctx context.Context, params *jsonrpc2.JobParams) (*jsonrpc2.Job, error) {
+
+	record, err := s.loadJob(ctx, params.ID)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	return &record.Job, nil
+}
+
+func (s *server) jobResult(ctx context.Context, params *jsonrpc2.JobParams) (json.RawMessage, error) {
+
+	record, err := s.loadJob(ctx, params.ID)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	switch record.State {
+	case jsonrpc2.JobRunning:
+
+		return nil, jsonrpc2.NewError(jsonrpc2.ServerError, "job is running")
+
+	case jsonrpc2.JobFailed:
+
+		return nil, record.Error
+
+	case jsonrpc2.JobCancelled:
+
+		return nil, jsonrpc2.NewError(jsonrpc2.RequestCancelled, "")
+	}
+
+	return record.Result, nil
+}
+
+// jobCancel cancels the context of a running job, it reports whether the job
+// was running.
+func (s *server) jobCancel(ctx context.Context, params *jsonrpc2.JobParams) (bool, error) {
+
+	record, err := s.loadJob(ctx, params.ID)
+
+	if err != nil {
+
+		return false, err
+	}
+
+	s.mutex.Lock()
+
+	job, found := s.jobs[params.ID]
+
+	if found {
+
+		job.cancelled = true
+	}
+
+	s.mutex.Unlock()
+
+	if !found {
+
+		return false, nil
+	}
+
+	job.cancel()
+
+	record.State, record.Updated = jsonrpc2.JobCancelled, time.Now()
+
+	return true, s.jobStore.Save(record, s.jobTTL)
+}
+
+func NewMemoryJobStore() JobStore {
+
+	return &memoryJobStore{
+		jobs:  make(map[string]*memoryJob),
+		mutex: &sync.Mutex{},
+		now:   time.Now,
+	}
+}
+
+type memoryJob struct {
+	record  JobRecord
+	expires time.Time
+}
+
+func (m *memoryJob) expired(now time.Time) bool {
+
+	return !m.expires.IsZero() && now.After(m.expires)
+}
+
+type memoryJobStore struct {
+	jobs  map[string]*memoryJob
+	swept time.Time
+	mutex *sync.Mutex
+	now   func() time.Time
+}
+
+func (m *memoryJobStore) Save(job *JobRecord, ttl time.Duration) error {
+
+	m.mutex.Lock()
+
+	defer m.mutex.Unlock()
+
+	now := m.now()
+
+	if now.Sub(m.swept) > time.Minute {
+
+		m.sweep(now)
+	}
+
+	j := &memoryJob{record: *job}
+
+	if ttl > 0 {
+
+		j.expires = now.Add(ttl)
+	}
+
+	m.jobs[job.ID] = j
+
+	return nil
+}
+
+func (m *memoryJobStore) Load(id string) (*JobRecord, error) {
+
+	m.mutex.Lock()
+
+	defer m.mutex.Unlock()
+
+	j, found := m.jobs[id]
+
+	if !found || j.expired(m.now()) {
+
+		return nil, nil
+	}
+
+	record := j.record
+
+	return &record, nil
+}
+
+func (m *memoryJobStore) sweep(now time.Time) {
+
+	for id, j := range m.jobs {
+
+		if j.expired(now) {
+
+			delete(m.jobs, id)
+		}
+	}
+
+	m.swept = now
+}
@@ -0,0 +1,378 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type reportParams struct {
+	Name string `json:"name"`
+}
+
+func (p *reportParams) IsValid() bool {
+
+	return p.Name != ""
+}
+
+func newJobServer(t *testing.T) (*httptest.Server, jsonrpc2.Client, chan struct{}) {
+
+	var (
+		srv     = New()
+		release = make(chan struct{})
+	)
+
+	srv.SetPanicHandler(nil)
+
+	srv.RegisterFunc("Report.Build", func(ctx context.Context, params *reportParams) (string, error) {
+
+		switch params.Name {
+		case "fail":
+
+			return "", errors.New("no data")
+
+		case "panic":
+
+			panic("report")
+		}
+
+		select {
+		case <-release:
+
+			return "report " + params.Name, nil
+
+		case <-ctx.Done():
+
+			return "", ctx.Err()
+		}
+	})
+
+	srv.RegisterFunc("Report.Name", func(params *reportParams) (string, error) {
+
+		return params.Name, nil
+	})
+
+	srv.EnableJobs("Report.Build")
+
+	ts := httptest.NewServer(srv)
+
+	return ts, jsonrpc2.NewClient(&testDiscovery{ts.URL}), release
+}
+
+func TestJobs(t *testing.T) {
+
+	ts, client, release := newJobServer(t)
+
+	defer ts.Close()
+
+	var job jsonrpc2.Job
+
+	if assert.NoError(t, client.Send("Report.Build", &reportParams{Name: "q1"}, &job)) {
+
+		assert.NotEmpty(t, job.ID)
+		assert.Equal(t, "Report.Build", job.Method)
+		assert.Equal(t, jsonrpc2.JobRunning, job.State)
+	}
+
+	var status jsonrpc2.Job
+
+	if assert.NoError(t, client.Send(jsonrpc2.JobStatusMethod, &jsonrpc2.JobParams{ID: job.ID}, &status)) {
+
+		assert.Equal(t, jsonrpc2.JobRunning, status.State)
+	}
+
+	var result string
+
+	if err := client.Send(jsonrpc2.JobResultMethod, &jsonrpc2.JobParams{ID: job.ID}, &result); assert.Error(t, err) {
+
+		if e, ok := err.(*jsonrpc2.Error); assert.True(t, ok) {
+
+			assert.Equal(t, int16(jsonrpc2.ServerError), e.Code)
+			assert.Equal(t, "job is running", e.Data)
+		}
+	}
+
+	close(release)
+
+	if assert.NoError(t, jsonrpc2.WaitJob(context.Background(), client, job.ID, &result, time.Millisecond)) {
+
+		assert.Equal(t, "report q1", result)
+	}
+
+	if assert.NoError(t, client.Send(jsonrpc2.JobStatusMethod, &jsonrpc2.JobParams{ID: job.ID}, &status)) {
+
+		assert.Equal(t, jsonrpc2.JobDone, status.State)
+	}
+
+	if err := client.Send(jsonrpc2.JobStatusMethod, &jsonrpc2.JobParams{ID: "unknown"}, &status); assert.Error(t, err) {
+
+		if e, ok := err.(*jsonrpc2.Error); assert.True(t, ok) {
+
+			assert.Equal(t, int16(jsonrpc2.InvalidParams), e.Code)
+		}
+	}
+
+	if assert.NoError(t, client.Send("Report.Name", &reportParams{Name: "q2"}, &result)) {
+
+		assert.Equal(t, "q2", result)
+	}
+}
+
+func TestJobsFailed(t *testing.T) {
+
+	ts, client, _ := newJobServer(t)
+
+	defer ts.Close()
+
+	var result string
+
+	if err := jsonrpc2.CallJob(context.Background(), client, "Report.Build", &reportParams{}, &result, time.Millisecond); assert.Error(t, err) {
+
+		if e, ok := err.(*jsonrpc2.Error); assert.True(t, ok) {
+
+			assert.Equal(t, int16(jsonrpc2.InvalidParams), e.Code)
+		}
+	}
+
+	if err := jsonrpc2.CallJob(context.Background(), client, "Report.Build", &reportParams{Name: "fail"}, &result, time.Millisecond); assert.Error(t, err) {
+
+		_, ok := err.(*jsonrpc2.LogicError)
+
+		if assert.True(t, ok) {
+
+			assert.Equal(t, "no data", err.Error())
+		}
+	}
+
+	if err := jsonrpc2.CallJob(context.Background(), client, "Report.Build", &reportParams{Name: "panic"}, &result, time.Millisecond); assert.Error(t, err) {
+
+		if e, ok := err.(*jsonrpc2.Error); assert.True(t, ok) {
+
+			assert.Equal(t, int16(jsonrpc2.InternalError), e.Code)
+		}
+	}
+}
+
+func TestJobsCancel(t *testing.T) {
+
+	ts, client, _ := newJobServer(t)
+
+	defer ts.Close()
+
+	var job jsonrpc2.Job
+
+	if !assert.NoError(t, client.Send("Report.Build", &reportParams{Name: "q1"}, &job)) {
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+
+	defer cancel()
+
+	var result string
+
+	assert.Equal(t, context.DeadlineExceeded, jsonrpc2.WaitJob(ctx, client, job.ID, &result, time.Millisecond))
+
+	var status jsonrpc2.Job
+
+	if assert.NoError(t, client.Send(jsonrpc2.JobStatusMethod, &jsonrpc2.JobParams{ID: job.ID}, &status)) {
+
+		assert.Equal(t, jsonrpc2.JobCancelled, status.State)
+	}
+
+	if err := client.Send(jsonrpc2.JobResultMethod, &jsonrpc2.JobParams{ID: job.ID}, &result); assert.Error(t, err) {
+
+		if e, ok := err.(*jsonrpc2.Error); assert.True(t, ok) {
+
+			assert.Equal(t, int16(jsonrpc2.RequestCancelled), e.Code)
+		}
+	}
+
+	var cancelled bool
+
+	if assert.NoError(t, client.Send(jsonrpc2.JobCancelMethod, &jsonrpc2.JobParams{ID: job.ID}, &cancelled)) {
+
+		assert.False(t, cancelled)
+	}
+}
+
+type bearerTransport string
+
+func (token bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+
+	r.Header.Set("Authorization", "Bearer "+string(token))
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestJobsPrincipal(t *testing.T) {
+
+	srv := New()
+
+	srv.SetAuthenticator(BearerAuthenticator(func(token string) (*Principal, error) {
+
+		return &Principal{Name: token}, nil
+	}))
+
+	srv.RegisterFunc("Report.Build", func(ctx context.Context, params *reportParams) (string, error) {
+
+		return "report " + params.Name, nil
+	})
+
+	srv.EnableJobs("Report.Build")
+
+	ts := httptest.NewServer(srv)
+
+	defer ts.Close()
+
+	var (
+		owner = jsonrpc2.NewClient(&testDiscovery{ts.URL}, jsonrpc2.WithHTTPClient(&http.Client{Transport: bearerTransport("a")}))
+		other = jsonrpc2.NewClient(&testDiscovery{ts.URL}, jsonrpc2.WithHTTPClient(&http.Client{Transport: bearerTransport("b")}))
+		job   jsonrpc2.Job
+	)
+
+	if !assert.NoError(t, owner.Send("Report.Build", &reportParams{Name: "q1"}, &job)) {
+
+		return
+	}
+
+	for _, method := range []string{jsonrpc2.JobStatusMethod, jsonrpc2.JobResultMethod, jsonrpc2.JobCancelMethod} {
+
+		if e, ok := other.Send(method, &jsonrpc2.JobParams{ID: job.ID}, nil).(*jsonrpc2.Error); assert.True(t, ok, method) {
+
+			assert.Equal(t, int16(jsonrpc2.Forbidden), e.Code)
+		}
+	}
+
+	var result string
+
+	if assert.NoError(t, jsonrpc2.WaitJob(context.Background(), owner, job.ID, &result, time.Millisecond)) {
+
+		assert.Equal(t, "report q1", result)
+	}
+}
+
+func TestJobsShutdown(t *testing.T) {
+
+	srv := New()
+
+	srv.RegisterFunc("Report.Build", func(ctx context.Context, params *reportParams) (string, error) {
+
+		<-ctx.Done()
+
+		return "", ctx.Err()
+	})
+
+	srv.EnableJobs("Report.Build")
+
+	data, _ := json.Marshal(&jsonrpc2.Request{Jsonrpc: "2.0", RequestID: 1, Method: "Report.Build", Params: &reportParams{Name: "q1"}})
+
+	var response struct {
+		Result jsonrpc2.Job `json:"result"`
+	}
+
+	if !assert.NoError(t, json.Unmarshal(srv.HandleMessage(context.Background(), data), &response)) {
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, srv.Shutdown(ctx))
+
+	assert.Eventually(t, func() bool {
+
+		record, _ := srv.jobStore.Load(response.Result.ID)
+
+		return record != nil && record.State == jsonrpc2.JobFailed
+
+	}, time.Second, time.Millisecond)
+}
+
+func TestJobsStream(t *testing.T) {
+
+	var (
+		srv  = New()
+		runs int32
+	)
+
+	srv.RegisterFunc("Report.Rows", func(params *reportParams) (jsonrpc2.Stream, error) {
+
+		return func(yield func(element interface{}) error) error {
+
+			atomic.AddInt32(&runs, 1)
+
+			for i := 0; i < 3; i++ {
+
+				if err := yield(i); err != nil {
+
+					return err
+				}
+			}
+
+			return nil
+		}, nil
+	})
+
+	srv.EnableJobs("Report.Rows")
+
+	ts := httptest.NewServer(srv)
+
+	defer ts.Close()
+
+	var (
+		client = jsonrpc2.NewClient(&testDiscovery{ts.URL})
+		rows   []int
+	)
+
+	if assert.NoError(t, jsonrpc2.CallJob(context.Background(), client, "Report.Rows", &reportParams{Name: "q1"}, &rows, time.Millisecond)) {
+
+		assert.Equal(t, []int{0, 1, 2}, rows)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	}
+}
+
+func TestMemoryJobStore(t *testing.T) {
+
+	now := time.Now()
+
+	store := NewMemoryJobStore().(*memoryJobStore)
+	store.now = func() time.Time { return now }
+
+	running := &JobRecord{Job: jsonrpc2.Job{ID: "running", State: jsonrpc2.JobRunning}}
+	done := &JobRecord{Job: jsonrpc2.Job{ID: "done", State: jsonrpc2.JobDone}, Result: json.RawMessage(`42`)}
+
+	assert.NoError(t, store.Save(running, 0))
+	assert.NoError(t, store.Save(done, time.Minute))
+
+	if record, err := store.Load("done"); assert.NoError(t, err) && assert.NotNil(t, record) {
+
+		assert.Equal(t, json.RawMessage(`42`), record.Result)
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	record, err := store.Load("done")
+
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	store.Save(running, 0)
+
+	assert.Len(t, store.jobs, 1)
+
+	if record, err := store.Load("running"); assert.NoError(t, err) && assert.NotNil(t, record) {
+
+		assert.Equal(t, jsonrpc2.JobRunning, record.State)
+	}
+}
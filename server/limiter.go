@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+func newLimiter(max int) *limiter {
+
+	return &limiter{
+		slots: make(chan struct{}, max),
+	}
+}
+
+type limiter struct {
+	slots chan struct{}
+}
+
+// acquire takes a slot, waiting for one until deadline. It does not wait
+// with a zero deadline.
+func (l *limiter) acquire(ctx context.Context, deadline time.Time) bool {
+
+	select {
+	case l.slots <- struct{}{}:
+
+		return true
+
+	default:
+	}
+
+	timeout := time.Until(deadline)
+
+	if deadline.IsZero() || timeout <= 0 {
+
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+
+		return true
+
+	case <-timer.C:
+
+		return false
+
+	case <-ctx.Done():
+
+		return false
+	}
+}
+
+func (l *limiter) release() {
+
+	<-l.slots
+}
+
+func (s *server) SetMaxConcurrency(max int) {
+
+	s.limiter = nil
+
+	if max > 0 {
+
+		s.limiter = newLimiter(max)
+	}
+}
+
+func (s *server) SetMethodConcurrency(method string, max int) {
+
+	delete(s.methodLimiters, method)
+
+	if max > 0 {
+
+		s.methodLimiters[method] = newLimiter(max)
+	}
+}
+
+func (s *server) SetQueueTimeout(timeout time.Duration) {
+
+	s.queueTimeout = timeout
+}
+
+// acquire takes a slot of the method limiter, then of the global limiter, so
+// that the calls queued for a saturated method do not hold global slots. Both
+// waits share the queue timeout.
+func (s *server) acquire(ctx context.Context, method string) (func(), bool) {
+
+	var (
+		acquired []*limiter
+		deadline time.Time
+	)
+
+	if s.queueTimeout > 0 {
+
+		deadline = time.Now().Add(s.queueTimeout)
+	}
+
+	release := func() {
+
+		for _, l := range acquired {
+
+			l.release()
+		}
+	}
+
+	for _, l := range []*limiter{s.methodLimiters[method], s.limiter} {
+
+		if l == nil {
+
+			continue
+		}
+
+		if !l.acquire(ctx, deadline) {
+
+			release()
+
+			return nil, false
+		}
+
+		acquired = append(acquired, l)
+	}
+
+	return release, true
+}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+
+	l := newLimiter(1)
+
+	if assert.True(t, l.acquire(context.Background(), time.Time{})) {
+
+		assert.False(t, l.acquire(context.Background(), time.Time{}))
+		assert.False(t, l.acquire(context.Background(), time.Now().Add(10*time.Millisecond)))
+		assert.False(t, l.acquire(context.Background(), time.Now().Add(-time.Second)))
+
+		go func() {
+
+			time.Sleep(10 * time.Millisecond)
+
+			l.release()
+		}()
+
+		assert.True(t, l.acquire(context.Background(), time.Now().Add(time.Second)))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	assert.False(t, l.acquire(ctx, time.Now().Add(time.Second)))
+}
+
+func testBlockingServer(started chan struct{}, release chan struct{}) *server {
+
+	server := New()
+	server.RegisterFunc("Wait", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		started <- struct{}{}
+
+		<-release
+
+		return "done", nil
+	})
+
+	server.RegisterFunc("Ok", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		return "OK", nil
+	})
+
+	return server
+}
+
+func TestServerMaxConcurrency(t *testing.T) {
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		server  = testBlockingServer(started, release)
+	)
+
+	server.SetMaxConcurrency(1)
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	responses := make(chan *jsonrpc2.Response)
+
+	go func() {
+
+		responses <- testPost(t, testServer.URL, "Wait")
+	}()
+
+	<-started
+
+	if response := testPost(t, testServer.URL, "Ok"); assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		assert.True(t, jsonrpc2.Overloaded == response.Error.Code)
+		assert.Equal(t, jsonrpc2.Errors[jsonrpc2.Overloaded], response.Error.Message)
+	}
+
+	close(release)
+
+	if response := <-responses; assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+	}
+
+	if response := testPost(t, testServer.URL, "Ok"); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+	}
+}
+
+func TestServerMethodConcurrency(t *testing.T) {
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		server  = testBlockingServer(started, release)
+	)
+
+	server.SetMethodConcurrency("Wait", 1)
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	responses := make(chan *jsonrpc2.Response)
+
+	go func() {
+
+		responses <- testPost(t, testServer.URL, "Wait")
+	}()
+
+	<-started
+
+	if response := testPost(t, testServer.URL, "Ok"); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+	}
+
+	if response := testPost(t, testServer.URL, "Wait"); assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		assert.True(t, jsonrpc2.Overloaded == response.Error.Code)
+	}
+
+	close(release)
+
+	<-responses
+}
+
+func TestServerQueueTimeout(t *testing.T) {
+
+	var (
+		started = make(chan struct{}, 2)
+		release = make(chan struct{})
+		server  = testBlockingServer(started, release)
+	)
+
+	server.SetMaxConcurrency(1)
+	server.SetQueueTimeout(time.Second)
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	responses := make(chan *jsonrpc2.Response)
+
+	go func() {
+
+		responses <- testPost(t, testServer.URL, "Wait")
+	}()
+
+	<-started
+
+	go func() {
+
+		time.Sleep(20 * time.Millisecond)
+
+		close(release)
+	}()
+
+	if response := testPost(t, testServer.URL, "Ok"); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+		assert.Equal(t, "OK", response.Result)
+	}
+
+	<-responses
+}
+
+func TestServerMethodQueueHoldsNoGlobalSlot(t *testing.T) {
+
+	var (
+		started = make(chan struct{}, 2)
+		release = make(chan struct{})
+		server  = testBlockingServer(started, release)
+	)
+
+	server.SetMaxConcurrency(2)
+	server.SetMethodConcurrency("Wait", 1)
+	server.SetQueueTimeout(100 * time.Millisecond)
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	responses := make(chan *jsonrpc2.Response, 2)
+
+	go func() {
+
+		responses <- testPost(t, testServer.URL, "Wait")
+	}()
+
+	<-started
+
+	begin := time.Now()
+
+	go func() {
+
+		responses <- testPost(t, testServer.URL, "Wait")
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	ok := time.Now()
+
+	if response := testPost(t, testServer.URL, "Ok"); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+		assert.True(t, time.Since(ok) < 50*time.Millisecond)
+	}
+
+	if response := <-responses; assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		assert.True(t, jsonrpc2.Overloaded == response.Error.Code)
+		assert.True(t, time.Since(begin) < 190*time.Millisecond)
+	}
+
+	close(release)
+
+	<-responses
+}
+
+func TestServerStreamConcurrency(t *testing.T) {
+
+	var (
+		started = make(chan struct{}, 1)
+		release = make(chan struct{})
+		server  = testBlockingServer(started, release)
+	)
+
+	server.RegisterFunc("Export", func(_ *jsonrpc2.EmptyParams) (jsonrpc2.Stream, error) {
+
+		return func(yield func(element interface{}) error) error {
+
+			yield(1)
+
+			started <- struct{}{}
+
+			<-release
+
+			return yield(2)
+		}, nil
+	})
+
+	server.SetMaxConcurrency(1)
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	responses := make(chan *jsonrpc2.Response)
+
+	go func() {
+
+		responses <- testPost(t, testServer.URL, "Export")
+	}()
+
+	<-started
+
+	for _, method := range []string{"Export", "Ok"} {
+
+		if response := testPost(t, testServer.URL, method); assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+			assert.True(t, jsonrpc2.Overloaded == response.Error.Code)
+		}
+	}
+
+	data, _ := json.Marshal(&jsonrpc2.Request{Jsonrpc: "2.0", RequestID: 1, Method: "Export", Params: &jsonrpc2.EmptyParams{}})
+
+	assert.Contains(t, string(server.HandleMessage(context.Background(), data)), "concurrency limit exceeded")
+
+	close(release)
+
+	if response := <-responses; assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+		assert.Equal(t, []interface{}{float64(1), float64(2)}, response.Result)
+	}
+
+	if response := testPost(t, testServer.URL, "Ok"); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+	}
+}
@@ -0,0 +1,15 @@
+package server
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+)
+
+type HandlerFunc func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error)
+
+type Middleware func(next HandlerFunc) HandlerFunc
+
+func (s *server) Use(middlewares ...Middleware) {
+
+	s.middlewares = append(s.middlewares, middlewares...)
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"log"
+	"runtime/debug"
+)
+
+// PanicHandler is called with the call of a handler which panicked. The
+// params may hold secrets, handlers logging them should redact them.
+type PanicHandler func(method string, params json.RawMessage, message interface{}, stack []byte)
+
+// logPanic is the default PanicHandler, it logs the method and the stack but
+// not the params.
+func logPanic(method string, _ json.RawMessage, message interface{}, stack []byte) {
+
+	log.Printf("jsonrpc2: panic in method '%s': %v\n%s", method, message, stack)
+}
+
+func (s *server) recover(request *jsonrpc2.ServerRequest, message interface{}) *jsonrpc2.Response {
+
+	stack := debug.Stack()
+
+	if s.panicHandler != nil {
+
+		s.panicHandler(request.Method, request.Params, message, stack)
+	}
+
+	response := errorResponse(request.RequestID, jsonrpc2.InternalError, "")
+
+	if s.debug {
+
+		response.Error.Data = fmt.Sprintf("%v\n%s", message, stack)
+	}
+
+	return response
+}
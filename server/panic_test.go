@@ -0,0 +1,25 @@
+package server
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestLogPanic(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	log.SetOutput(&buf)
+
+	defer log.SetOutput(os.Stderr)
+
+	logPanic("Login", []byte(`{"password": "secret"}`), "Panic", []byte("goroutine 1"))
+
+	assert.Contains(t, buf.String(), "Login")
+	assert.Contains(t, buf.String(), "Panic")
+	assert.Contains(t, buf.String(), "goroutine 1")
+	assert.NotContains(t, buf.String(), "secret")
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Rate struct {
+	PerSecond float64
+	Burst     int
+}
+
+// RateLimitStore keeps token buckets by key. Allow takes a token from the bucket
+// and reports how long to wait when it is empty.
+type RateLimitStore interface {
+	Allow(key string, rate Rate) (bool, time.Duration)
+}
+
+// Identity returns the key the caller is rate limited by. An empty key skips limiting.
+type Identity func(ctx context.Context, request *jsonrpc2.ServerRequest) string
+
+func IPIdentity(ctx context.Context, _ *jsonrpc2.ServerRequest) string {
+
+	r := HTTPRequest(ctx)
+
+	if r == nil {
+
+		return ""
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+func HeaderIdentity(header string) Identity {
+
+	return func(ctx context.Context, _ *jsonrpc2.ServerRequest) string {
+
+		if r := HTTPRequest(ctx); r != nil {
+
+			return r.Header.Get(header)
+		}
+
+		return ""
+	}
+}
+
+// JWTSubjectIdentity reads the "sub" claim of a bearer token without verifying
+// its signature, so it must only be used behind an authenticating middleware.
+func JWTSubjectIdentity(ctx context.Context, _ *jsonrpc2.ServerRequest) string {
+
+	r := HTTPRequest(ctx)
+
+	if r == nil {
+
+		return ""
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+
+	if err != nil {
+
+		return ""
+	}
+
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+
+		return ""
+	}
+
+	return claims.Subject
+}
+
+func NewRateLimiter(identity Identity, rate Rate) *RateLimiter {
+
+	return &RateLimiter{
+		store:    NewMemoryRateLimitStore(),
+		identity: identity,
+		rate:     rate,
+		methods:  make(map[string]Rate),
+	}
+}
+
+type RateLimiter struct {
+	store    RateLimitStore
+	identity Identity
+	rate     Rate
+	methods  map[string]Rate
+}
+
+func (l *RateLimiter) SetStore(store RateLimitStore) {
+
+	l.store = store
+}
+
+// SetMethodRate limits the calls of method by rate, they take tokens from
+// the bucket of all the calls as well.
+func (l *RateLimiter) SetMethodRate(method string, rate Rate) {
+
+	l.methods[method] = rate
+}
+
+func (l *RateLimiter) Middleware(next HandlerFunc) HandlerFunc {
+
+	return func(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+		identity := l.identity(ctx, request)
+
+		if identity == "" {
+
+			return next(ctx, request)
+		}
+
+		if rate, found := l.methods[request.Method]; found {
+
+			if err := l.allow(identity+"\x00"+request.Method, rate); err != nil {
+
+				return nil, err
+			}
+		}
+
+		if err := l.allow(identity, l.rate); err != nil {
+
+			return nil, err
+		}
+
+		return next(ctx, request)
+	}
+}
+
+// allow takes a token from the bucket of key, a rate of zero is not limited.
+func (l *RateLimiter) allow(key string, rate Rate) error {
+
+	if rate.PerSecond <= 0 {
+
+		return nil
+	}
+
+	if ok, wait := l.store.Allow(key, rate); !ok {
+
+		return jsonrpc2.NewError(jsonrpc2.RateLimited, wait.String())
+	}
+
+	return nil
+}
+
+func NewMemoryRateLimitStore() RateLimitStore {
+
+	return &memoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+		mutex:   &sync.Mutex{},
+		now:     time.Now,
+	}
+}
+
+type bucket struct {
+	tokens  float64
+	burst   float64
+	rate    float64
+	updated time.Time
+}
+
+func (b *bucket) refill(now time.Time) {
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+}
+
+type memoryRateLimitStore struct {
+	buckets map[string]*bucket
+	swept   time.Time
+	mutex   *sync.Mutex
+	now     func() time.Time
+}
+
+func (m *memoryRateLimitStore) Allow(key string, rate Rate) (bool, time.Duration) {
+
+	m.mutex.Lock()
+
+	defer m.mutex.Unlock()
+
+	now := m.now()
+
+	if now.Sub(m.swept) > time.Minute {
+
+		m.sweep(now)
+	}
+
+	b, found := m.buckets[key]
+
+	if !found {
+
+		b = &bucket{
+			tokens:  math.Max(float64(rate.Burst), 1),
+			updated: now,
+		}
+
+		m.buckets[key] = b
+	}
+
+	b.burst, b.rate = math.Max(float64(rate.Burst), 1), rate.PerSecond
+
+	b.refill(now)
+
+	if b.tokens < 1 {
+
+		return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+func (m *memoryRateLimitStore) sweep(now time.Time) {
+
+	for key, b := range m.buckets {
+
+		if b.refill(now); b.tokens >= b.burst {
+
+			delete(m.buckets, key)
+		}
+	}
+
+	m.swept = now
+}
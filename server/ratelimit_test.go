@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+
+	now := time.Now()
+
+	store := NewMemoryRateLimitStore().(*memoryRateLimitStore)
+	store.now = func() time.Time { return now }
+
+	rate := Rate{PerSecond: 2, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+
+		ok, _ := store.Allow("key", rate)
+
+		assert.True(t, ok)
+	}
+
+	if ok, wait := store.Allow("key", rate); assert.False(t, ok) {
+
+		assert.Equal(t, 500*time.Millisecond, wait)
+	}
+
+	ok, _ := store.Allow("other", rate)
+
+	assert.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+
+	ok, _ = store.Allow("key", rate)
+
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+
+	store.Allow("key", rate)
+
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestRateLimiter(t *testing.T) {
+
+	limiter := NewRateLimiter(HeaderIdentity("X-API-Key"), Rate{PerSecond: 1, Burst: 1})
+	limiter.SetMethodRate("Expensive", Rate{PerSecond: 0.01, Burst: 1})
+
+	server := New()
+	server.Use(limiter.Middleware)
+	server.RegisterFunc("Ok", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		return "OK", nil
+	})
+
+	server.RegisterFunc("Expensive", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		return "OK", nil
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	if response := testPost(t, testServer.URL, "Ok", withHeader("X-API-Key", "a")); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+	}
+
+	if response := testPost(t, testServer.URL, "Ok", withHeader("X-API-Key", "a")); assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		assert.Equal(t, 42, response.RequestID)
+		assert.True(t, jsonrpc2.RateLimited == response.Error.Code)
+		assert.Equal(t, jsonrpc2.Errors[jsonrpc2.RateLimited], response.Error.Message)
+
+		if wait, err := time.ParseDuration(response.Error.Data); assert.NoError(t, err) {
+
+			assert.True(t, wait > 0 && wait <= time.Second)
+		}
+	}
+
+	if response := testPost(t, testServer.URL, "Expensive", withHeader("X-API-Key", "a")); assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		if wait, err := time.ParseDuration(response.Error.Data); assert.NoError(t, err) {
+
+			assert.True(t, wait > 0 && wait <= time.Second)
+		}
+	}
+
+	if response := testPost(t, testServer.URL, "Expensive", withHeader("X-API-Key", "c")); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+	}
+
+	if response := testPost(t, testServer.URL, "Expensive", withHeader("X-API-Key", "c")); assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		if wait, err := time.ParseDuration(response.Error.Data); assert.NoError(t, err) {
+
+			assert.True(t, wait > time.Minute)
+		}
+	}
+
+	if response := testPost(t, testServer.URL, "Ok", withHeader("X-API-Key", "b")); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+	}
+
+	if response := testPost(t, testServer.URL, "Ok", withHeader("X-API-Key", "")); assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+	}
+}
+
+func TestRateLimitIdentity(t *testing.T) {
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"partner"}`))
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("Authorization", "Bearer header."+payload+".signature")
+
+	ctx := context.WithValue(context.Background(), httpRequestKey{}, r)
+
+	assert.Equal(t, "10.0.0.1", IPIdentity(ctx, nil))
+	assert.Equal(t, "partner", JWTSubjectIdentity(ctx, nil))
+	assert.Equal(t, "", JWTSubjectIdentity(context.Background(), nil))
+}
@@ -1,25 +1,83 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/schema"
 	"reflect"
+	"sync"
+	"time"
 )
 
 func New() *server {
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &server{
-		handlers: make(map[string]handler),
+		handlers:       make(map[string]handler),
+		methodLimiters: make(map[string]*limiter),
+		docs:           make(map[string]MethodDoc),
+		panicHandler:   logPanic,
+		ctx:            ctx,
+		cancel:         cancel,
+		mutex:          &sync.Mutex{},
+		inFlight:       &sync.WaitGroup{},
+		jobMethods:     make(map[string]bool),
+		jobs:           make(map[string]*runningJob),
+		jobStore:       NewMemoryJobStore(),
+		jobTTL:         10 * time.Minute,
 	}
 }
 
 type server struct {
-	handlers map[string]handler
+	handlers       map[string]handler
+	panicHandler   PanicHandler
+	debug          bool
+	ctx            context.Context
+	cancel         context.CancelFunc
+	mutex          *sync.Mutex
+	inFlight       *sync.WaitGroup
+	shutdown       bool
+	limiter        *limiter
+	methodLimiters map[string]*limiter
+	queueTimeout   time.Duration
+	middlewares    []Middleware
+	authenticator  Authenticator
+	docs           map[string]MethodDoc
+	validateParams bool
+	batchWorkers   int
+	jobMethods     map[string]bool
+	jobs           map[string]*runningJob
+	jobStore       JobStore
+	jobTTL         time.Duration
+}
+
+func (s *server) SetDebug(debug bool) {
+
+	s.debug = debug
+}
+
+func (s *server) SetValidateParams(validate bool) {
+
+	s.validateParams = validate
+}
+
+// SetBatchConcurrency sets how many calls of a batch are handled at the same
+// time. The calls of a batch are handled one by one by default.
+func (s *server) SetBatchConcurrency(n int) {
+
+	s.batchWorkers = n
+}
+
+func (s *server) SetPanicHandler(fn PanicHandler) {
+
+	s.panicHandler = fn
 }
 
 func (s *server) RegisterFunc(method string, fn interface{}) {
 
-	s.addHandler(method, reflect.ValueOf(fn))
+	s.addHandler("", method, reflect.ValueOf(fn))
 }
 
 func (s *server) RegisterObject(name string, obj interface{}) {
@@ -33,11 +91,11 @@ func (s *server) RegisterObject(name string, obj interface{}) {
 			continue
 		}
 
-		s.addHandler(fmt.Sprintf("%s.%s", name, method.Name), reflect.ValueOf(obj).Method(i))
+		s.addHandler(name, fmt.Sprintf("%s.%s", name, method.Name), reflect.ValueOf(obj).Method(i))
 	}
 }
 
-func (s *server) addHandler(method string, fn reflect.Value) {
+func (s *server) addHandler(namespace, method string, fn reflect.Value) {
 
 	if _, found := s.handlers[method]; !found {
 
@@ -48,7 +106,17 @@ func (s *server) addHandler(method string, fn reflect.Value) {
 			ft = ft.Elem()
 		}
 
-		if ft.NumIn() != 1 || ft.NumOut() != 2 || !ft.In(0).Implements(reflect.TypeOf((*jsonrpc2.Params)(nil)).Elem()) {
+		var (
+			in          = 0
+			withContext = ft.NumIn() == 2 && ft.In(0) == reflect.TypeOf((*context.Context)(nil)).Elem()
+		)
+
+		if withContext {
+
+			in = 1
+		}
+
+		if ft.NumIn() != in+1 || ft.NumOut() != 2 || !ft.In(in).Implements(reflect.TypeOf((*jsonrpc2.Params)(nil)).Elem()) {
 
 			return
 		}
@@ -58,7 +126,7 @@ func (s *server) addHandler(method string, fn reflect.Value) {
 			return
 		}
 
-		params := ft.In(0)
+		params := ft.In(in)
 
 		if params.Kind() == reflect.Ptr {
 
@@ -66,8 +134,12 @@ func (s *server) addHandler(method string, fn reflect.Value) {
 		}
 
 		s.handlers[method] = handler{
-			method: fn,
-			params: reflect.New(params).Interface().(jsonrpc2.Params),
+			method:    fn,
+			params:    reflect.New(params).Interface().(jsonrpc2.Params),
+			result:    ft.Out(0),
+			schema:    schema.Reflect(params),
+			context:   withContext,
+			namespace: namespace,
 		}
 
 	} else {
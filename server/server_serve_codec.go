@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+)
+
+// Connect serves the calls received on codec and returns the connection to
+// call the peer. Both sides of a connection can register handlers on a
+// server and Connect, so either side serves and calls. Handlers call the peer
+// back with jsonrpc2.ConnFromContext.
+func (s *server) Connect(ctx context.Context, codec jsonrpc2.Codec) *jsonrpc2.Conn {
+
+	return jsonrpc2.NewConn(codec, jsonrpc2.WithHandler(s), jsonrpc2.WithContext(ctx))
+}
+
+// ServeCodec handles the messages read from codec until it is closed by the
+// peer, then waits for the calls in flight and closes the codec. The calls
+// are handled concurrently and each response is written as soon as it is
+// ready.
+func (s *server) ServeCodec(ctx context.Context, codec jsonrpc2.Codec) error {
+
+	conn := s.Connect(ctx, codec)
+
+	<-conn.Done()
+
+	if err := conn.Err(); err != jsonrpc2.ErrClosed {
+
+		return err
+	}
+
+	return nil
+}
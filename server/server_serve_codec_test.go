@@ -0,0 +1,275 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/stream"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestCodec() *testCodec {
+
+	return &testCodec{
+		in:  make(chan json.RawMessage, 16),
+		out: make(chan json.RawMessage, 16),
+	}
+}
+
+type testCodec struct {
+	in      chan json.RawMessage
+	out     chan json.RawMessage
+	readErr error
+	closed  bool
+}
+
+func (c *testCodec) ReadMessage() (json.RawMessage, error) {
+
+	message, ok := <-c.in
+
+	if !ok {
+
+		if c.readErr != nil {
+
+			return nil, c.readErr
+		}
+
+		return nil, io.EOF
+	}
+
+	return message, nil
+}
+
+func (c *testCodec) WriteMessage(message json.RawMessage) error {
+
+	c.out <- message
+
+	return nil
+}
+
+func (c *testCodec) Close() error {
+
+	c.closed = true
+
+	return nil
+}
+
+func TestServerServeCodec(t *testing.T) {
+
+	var (
+		server = New()
+		codec  = newTestCodec()
+	)
+
+	server.RegisterFunc("Echo", func(params *testEchoParams) (int, error) {
+
+		time.Sleep(time.Duration(params.Value) * 10 * time.Millisecond)
+
+		return params.Value, nil
+	})
+
+	codec.in <- json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "Echo", "params": {"Value": 5}}`)
+	codec.in <- json.RawMessage(`{"jsonrpc": "2.0", "id": 2, "method": "Echo", "params": {"Value": 0}}`)
+	codec.in <- json.RawMessage(`[{"jsonrpc": "2.0", "id": 3, "method": "Echo", "params": {"Value": 1}}, {"jsonrpc": "2.0", "id": 4, "method": "Unknown"}]`)
+	codec.in <- json.RawMessage(`{"jsonrpc": `)
+
+	close(codec.in)
+
+	var (
+		wg  = &sync.WaitGroup{}
+		err error
+	)
+
+	wg.Add(1)
+
+	go func() {
+
+		defer wg.Done()
+
+		err = server.ServeCodec(context.Background(), codec)
+	}()
+
+	var (
+		responses = make(map[int]jsonrpc2.Response)
+		order     []int
+	)
+
+	for i := 0; i < 4; i++ {
+
+		message := <-codec.out
+
+		if message[0] == '[' {
+
+			var batch []jsonrpc2.Response
+
+			if assert.NoError(t, json.Unmarshal(message, &batch)) && assert.Len(t, batch, 2) {
+
+				assert.Equal(t, float64(1), batch[0].Result)
+				assert.Equal(t, int16(jsonrpc2.MethodNotFound), batch[1].Error.Code)
+			}
+
+			order = append(order, 3)
+
+			continue
+		}
+
+		var response jsonrpc2.Response
+
+		if assert.NoError(t, json.Unmarshal(message, &response)) {
+
+			responses[response.RequestID] = response
+			order = append(order, response.RequestID)
+		}
+	}
+
+	wg.Wait()
+
+	assert.NoError(t, err)
+	assert.True(t, codec.closed)
+
+	if assert.Len(t, order, 4) {
+
+		assert.Equal(t, []int{0, 2, 3, 1}, order)
+		assert.Equal(t, int16(jsonrpc2.ParseError), responses[0].Error.Code)
+		assert.Equal(t, float64(5), responses[1].Result)
+	}
+}
+
+func TestServerServeCodecReadError(t *testing.T) {
+
+	codec := newTestCodec()
+	codec.readErr = errors.New("connection reset")
+
+	close(codec.in)
+
+	assert.EqualError(t, New().ServeCodec(context.Background(), codec), "connection reset")
+	assert.True(t, codec.closed)
+}
+
+func TestServerServeCodecClient(t *testing.T) {
+
+	server := New()
+	server.RegisterFunc("Echo", func(params *testEchoParams) (int, error) {
+
+		return params.Value, nil
+	})
+
+	client := jsonrpc2.NewClient(&testDiscovery{"pipe"}, jsonrpc2.WithDialer(func(ctx context.Context, address string) (jsonrpc2.Codec, error) {
+
+		codec := newTestCodec()
+
+		go server.ServeCodec(ctx, codec)
+
+		return &clientCodec{codec}, nil
+	}))
+
+	var result int
+
+	if assert.NoError(t, client.Send("Echo", &testEchoParams{Value: 42}, &result)) {
+
+		assert.Equal(t, 42, result)
+	}
+}
+
+// clientCodec is the client end of a testCodec.
+type clientCodec struct {
+	codec *testCodec
+}
+
+func (c *clientCodec) ReadMessage() (json.RawMessage, error) {
+
+	return <-c.codec.out, nil
+}
+
+func (c *clientCodec) WriteMessage(message json.RawMessage) error {
+
+	c.codec.in <- message
+
+	return nil
+}
+
+func (c *clientCodec) Close() error {
+
+	close(c.codec.in)
+
+	return nil
+}
+
+type testDiscovery []string
+
+func (d *testDiscovery) Get() ([]string, error) {
+
+	return *d, nil
+}
+
+func TestServerConnect(t *testing.T) {
+
+	var (
+		a, b     = net.Pipe()
+		left     = New()
+		right    = New()
+		received = make(chan int, 10)
+	)
+
+	left.RegisterFunc("Left.Double", func(ctx context.Context, params *testEchoParams) (int, error) {
+
+		var result int
+
+		if err := jsonrpc2.ConnFromContext(ctx).SendContext(ctx, "Right.Inc", params, &result); err != nil {
+
+			return 0, err
+		}
+
+		return 2 * result, nil
+	})
+	right.RegisterFunc("Right.Inc", func(params *testEchoParams) (int, error) {
+
+		return params.Value + 1, nil
+	})
+	right.RegisterFunc("Right.Event", func(params *testEchoParams) (interface{}, error) {
+
+		received <- params.Value
+
+		return nil, nil
+	})
+
+	var (
+		leftConn  = left.Connect(context.Background(), stream.NewCodec(a, stream.ContentLength))
+		rightConn = right.Connect(context.Background(), stream.NewCodec(b, stream.ContentLength))
+		result    int
+	)
+
+	defer leftConn.Close()
+
+	if assert.NoError(t, rightConn.Send("Left.Double", &testEchoParams{Value: 1}, &result)) {
+
+		assert.Equal(t, 4, result)
+	}
+
+	if assert.NoError(t, leftConn.Send("Right.Inc", &testEchoParams{Value: 1}, &result)) {
+
+		assert.Equal(t, 2, result)
+	}
+
+	for i := 0; i < 5; i++ {
+
+		assert.NoError(t, leftConn.Notify("Right.Event", &testEchoParams{Value: i}))
+	}
+
+	for i := 0; i < 5; i++ {
+
+		assert.Equal(t, i, <-received)
+	}
+
+	assert.NoError(t, rightConn.Shutdown(context.Background()))
+
+	<-leftConn.Done()
+
+	assert.Equal(t, jsonrpc2.ErrClosed, leftConn.Send("Right.Inc", &testEchoParams{}, &result))
+}
@@ -1,125 +1,537 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/kshvakov/jsonrpc2"
+	"io"
 	"net/http"
+	"sync"
 )
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
+	defer r.Body.Close()
+
+	if r.Method != "POST" {
+
+		s.write(w, s.encode(errorResponse(0, jsonrpc2.InvalidRequest, "")))
+
+		return
+	}
+
+	var message json.RawMessage
+
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+
+		s.write(w, s.encode(errorResponse(0, jsonrpc2.ParseError, err.Error())))
+
+		return
+	}
+
+	ctx := s.HTTPContext(r)
+
+	if !isBatch(message) {
+
+		if response := s.handleTo(ctx, message, w); response != nil {
+
+			s.write(w, s.encode(response))
+		}
+
+		return
+	}
+
+	if data := s.HandleMessage(ctx, message); data != nil {
+
+		s.write(w, data)
+	}
+}
+
+// HTTPContext returns the context the calls of r are handled with, it
+// carries r and the principal of its credentials. Transports upgrading an
+// HTTP request handle the calls of the connection with it.
+func (s *server) HTTPContext(r *http.Request) context.Context {
+
+	return s.authenticate(context.WithValue(r.Context(), httpRequestKey{}, r), r)
+}
+
+// HandleMessage handles a request or a batch of requests and returns the
+// encoded response. The notifications of a batch are not answered, nil is
+// returned for a batch of notifications only.
+func (s *server) HandleMessage(ctx context.Context, message json.RawMessage) json.RawMessage {
+
+	if !isBatch(message) {
+
+		return s.encode(s.handle(ctx, message))
+	}
+
+	var batch []json.RawMessage
+
+	if err := json.Unmarshal(message, &batch); err != nil || len(batch) == 0 {
+
+		return s.encode(errorResponse(0, jsonrpc2.InvalidRequest, ""))
+	}
+
+	var responses []json.RawMessage
+
+	for _, response := range s.handleBatch(ctx, batch) {
+
+		if response != nil {
+
+			responses = append(responses, response)
+		}
+	}
+
+	if len(responses) == 0 {
+
+		return nil
+	}
+
+	data, _ := json.Marshal(responses)
+
+	return data
+}
+
+func (s *server) handleBatch(ctx context.Context, batch []json.RawMessage) []json.RawMessage {
+
+	responses := make([]json.RawMessage, len(batch))
+
+	if s.batchWorkers <= 1 {
+
+		for i, message := range batch {
+
+			responses[i] = s.handleEntry(ctx, message)
+		}
+
+		return responses
+	}
+
+	var (
+		wg    = &sync.WaitGroup{}
+		slots = make(chan struct{}, s.batchWorkers)
+	)
+
+	for i, message := range batch {
+
+		slots <- struct{}{}
+
+		wg.Add(1)
+
+		go func(i int, message json.RawMessage) {
+
+			defer func() {
+
+				<-slots
+
+				wg.Done()
+			}()
+
+			responses[i] = s.handleEntry(ctx, message)
+
+		}(i, message)
+	}
+
+	wg.Wait()
+
+	return responses
+}
+
+// handleEntry handles an entry of a batch, nil is returned for a
+// notification.
+func (s *server) handleEntry(ctx context.Context, message json.RawMessage) json.RawMessage {
+
+	response := s.encode(s.handle(ctx, message))
+
+	if isNotification(message) {
+
+		return nil
+	}
+
+	return response
+}
+
+func (s *server) handle(ctx context.Context, message json.RawMessage) *jsonrpc2.Response {
+
+	return s.handleTo(ctx, message, nil)
+}
+
+// handleTo handles a request. A jsonrpc2.Stream result is written to w as it
+// is read and nil is returned, without w it is read into the response.
+func (s *server) handleTo(ctx context.Context, message json.RawMessage, w io.Writer) (response *jsonrpc2.Response) {
+
+	var request jsonrpc2.ServerRequest
+
+	if err := json.Unmarshal(message, &request); err != nil {
+
+		return errorResponse(request.RequestID, jsonrpc2.InvalidRequest, err.Error())
+	}
+
+	if !s.begin() {
+
+		return errorResponse(request.RequestID, jsonrpc2.ServerError, "shutting down")
+	}
+
+	defer s.end()
+
+	ctx, cancel := s.context(ctx)
+
+	defer cancel()
+
+	var releases []func()
+
 	defer func() {
 
-		r.Body.Close()
+		for _, release := range releases {
+
+			release()
+		}
+	}()
+
+	defer func() {
 
 		if message := recover(); message != nil {
 
-			json.NewEncoder(w).Encode(&jsonrpc2.Response{
-				Jsonrpc: "2.0",
-				Error: &jsonrpc2.Error{
-					Code:    jsonrpc2.InternalError,
-					Message: jsonrpc2.Errors[jsonrpc2.InternalError],
-					Data:    fmt.Sprint(message),
-				},
-			})
+			// An aborted stream response is left to net/http.
+			if message == http.ErrAbortHandler {
+
+				panic(message)
+			}
+
+			response = s.recover(&request, message)
 		}
 	}()
 
-	if r.Method != "POST" {
+	response = s.call(context.WithValue(ctx, releasesKey{}, &releases), &request)
 
-		json.NewEncoder(w).Encode(&jsonrpc2.Response{
-			Jsonrpc: "2.0",
-			Error: &jsonrpc2.Error{
-				Code:    jsonrpc2.InvalidRequest,
-				Message: jsonrpc2.Errors[jsonrpc2.InvalidRequest],
-			},
-		})
+	if stream, ok := response.Result.(jsonrpc2.Stream); ok {
 
-		return
+		if w != nil && stream != nil {
+
+			return s.writeStream(w, &request, stream)
+		}
+
+		return s.readStream(&request, stream)
 	}
 
-	var request jsonrpc2.ServerRequest
+	return response
+}
+
+// writeStream writes the response of a stream one element at a time, it is
+// started with the first element. An error of the stream before it is
+// returned as the response. After it the response is aborted, so that the
+// client fails to read it rather than taking the elements written so far for
+// the whole result.
+func (s *server) writeStream(w io.Writer, request *jsonrpc2.ServerRequest, stream jsonrpc2.Stream) *jsonrpc2.Response {
+
+	started := false
+
+	err := s.iterate(request, stream, func(element interface{}) error {
+
+		data, err := json.Marshal(element)
+
+		if err != nil {
+
+			return jsonrpc2.NewError(jsonrpc2.InternalError, err.Error())
+		}
+
+		if started {
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.Write([]byte{','})
 
-		json.NewEncoder(w).Encode(&jsonrpc2.Response{
+		} else {
+
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":[`, request.RequestID)
+		}
+
+		started = true
+
+		_, err = w.Write(data)
+
+		return err
+	})
+
+	switch {
+	case started && err != nil:
+
+		panic(http.ErrAbortHandler)
+
+	case started:
+
+		io.WriteString(w, "]}\n")
+
+		return nil
+
+	case err != nil:
+
+		return &jsonrpc2.Response{
 			Jsonrpc:   "2.0",
 			RequestID: request.RequestID,
-			Error: &jsonrpc2.Error{
-				Code:    jsonrpc2.ParseError,
-				Message: jsonrpc2.Errors[jsonrpc2.ParseError],
-				Data:    err.Error(),
-			},
-		})
+			Error:     responseError(err),
+		}
+	}
 
-		return
+	return &jsonrpc2.Response{
+		Jsonrpc:   "2.0",
+		RequestID: request.RequestID,
+		Result:    json.RawMessage("[]"),
 	}
+}
 
-	handler, found := s.handlers[request.Method]
+func (s *server) readStream(request *jsonrpc2.ServerRequest, stream jsonrpc2.Stream) *jsonrpc2.Response {
 
-	if !found {
+	data, err := stream.MarshalJSON()
 
-		json.NewEncoder(w).Encode(&jsonrpc2.Response{
+	if err != nil {
+
+		return &jsonrpc2.Response{
 			Jsonrpc:   "2.0",
 			RequestID: request.RequestID,
-			Error: &jsonrpc2.Error{
-				Code:    jsonrpc2.MethodNotFound,
-				Message: jsonrpc2.Errors[jsonrpc2.MethodNotFound],
-			},
-		})
+			Error:     responseError(err),
+		}
+	}
 
-		return
+	return &jsonrpc2.Response{
+		Jsonrpc:   "2.0",
+		RequestID: request.RequestID,
+		Result:    json.RawMessage(data),
 	}
+}
 
-	if params, err := handler.DecodeParams(request.Params); err == nil {
+// iterate reads a stream, a panic of the stream is returned as an internal
+// error.
+func (s *server) iterate(request *jsonrpc2.ServerRequest, stream jsonrpc2.Stream, yield func(element interface{}) error) (err error) {
 
-		if !params.IsValid() {
+	defer func() {
 
-			json.NewEncoder(w).Encode(&jsonrpc2.Response{
-				Jsonrpc:   "2.0",
-				RequestID: request.RequestID,
-				Error: &jsonrpc2.Error{
-					Code:    jsonrpc2.InvalidParams,
-					Message: jsonrpc2.Errors[jsonrpc2.InvalidParams],
-				},
-			})
+		if message := recover(); message != nil {
 
-			return
+			err = s.recover(request, message).Error
 		}
+	}()
 
-		if result, err := handler.Call(params); err == nil {
+	return stream(yield)
+}
 
-			json.NewEncoder(w).Encode(&jsonrpc2.Response{
-				Jsonrpc:   "2.0",
-				RequestID: request.RequestID,
-				Result:    result,
-			})
+func (s *server) call(ctx context.Context, request *jsonrpc2.ServerRequest) *jsonrpc2.Response {
 
-		} else {
+	if err := authenticationError(ctx); err != nil {
 
-			json.NewEncoder(w).Encode(&jsonrpc2.Response{
-				Jsonrpc:   "2.0",
-				RequestID: request.RequestID,
-				Error: &jsonrpc2.Error{
-					Code:    jsonrpc2.LogicErr,
-					Message: err.Error(),
-				},
-			})
+		return &jsonrpc2.Response{
+			Jsonrpc:   "2.0",
+			RequestID: request.RequestID,
+			Error:     err,
 		}
+	}
+
+	handler := HandlerFunc(s.invoke)
 
-	} else {
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
 
-		json.NewEncoder(w).Encode(&jsonrpc2.Response{
+		handler = s.middlewares[i](handler)
+	}
+
+	result, err := handler(ctx, request)
+
+	if err != nil {
+
+		return &jsonrpc2.Response{
 			Jsonrpc:   "2.0",
 			RequestID: request.RequestID,
-			Error: &jsonrpc2.Error{
-				Code:    jsonrpc2.ParseError,
-				Message: jsonrpc2.Errors[jsonrpc2.ParseError],
-				Data:    err.Error(),
-			},
-		})
+			Error:     responseError(err),
+		}
+	}
+
+	return &jsonrpc2.Response{
+		Jsonrpc:   "2.0",
+		RequestID: request.RequestID,
+		Result:    result,
+	}
+}
+
+// responseError returns the error of a response: a *jsonrpc2.Error as is,
+// any other error as a logic error.
+func responseError(err error) *jsonrpc2.Error {
+
+	if e, ok := err.(*jsonrpc2.Error); ok {
+
+		return e
+	}
+
+	return &jsonrpc2.Error{
+		Code:    jsonrpc2.LogicErr,
+		Message: err.Error(),
+	}
+}
+
+func (s *server) invoke(ctx context.Context, request *jsonrpc2.ServerRequest) (interface{}, error) {
+
+	handler, found := s.handlers[request.Method]
+
+	if !found {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.MethodNotFound, "")
+	}
+
+	if s.jobMethods[request.Method] {
+
+		params, err := s.decodeParams(handler, request)
+
+		if err != nil {
+
+			return nil, err
+		}
+
+		return s.startJob(ctx, request, handler, params)
+	}
+
+	release, ok := s.acquire(ctx, request.Method)
+
+	if !ok {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.Overloaded, "concurrency limit exceeded")
+	}
+
+	held := false
+
+	defer func() {
+
+		if !held {
+
+			release()
+		}
+	}()
+
+	params, err := s.decodeParams(handler, request)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	result, err := handler.CallContext(ctx, params)
+
+	if _, ok := result.(jsonrpc2.Stream); ok {
+
+		held = holdRelease(ctx, release)
+	}
+
+	return result, err
+}
+
+type releasesKey struct{}
+
+// holdRelease defers the release of the slots of a call returning a stream
+// until the stream was written or read by handleTo.
+func holdRelease(ctx context.Context, release func()) bool {
+
+	releases, ok := ctx.Value(releasesKey{}).(*[]func())
+
+	if ok {
+
+		*releases = append(*releases, release)
+	}
+
+	return ok
+}
+
+func (s *server) decodeParams(handler handler, request *jsonrpc2.ServerRequest) (jsonrpc2.Params, error) {
+
+	if s.validateParams && handler.schema != nil {
+
+		if err := handler.schema.Validate(request.Params); err != nil {
+
+			return nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, err.Error())
+		}
+	}
+
+	params, err := handler.DecodeParams(request.Params)
+
+	if err != nil {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.ParseError, err.Error())
+	}
+
+	if !params.IsValid() {
+
+		return nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "")
+	}
+
+	return params, nil
+}
+
+func (s *server) encode(response *jsonrpc2.Response) (data json.RawMessage) {
+
+	defer func() {
+
+		if message := recover(); message != nil {
+
+			data = s.encode(s.recover(&jsonrpc2.ServerRequest{Request: jsonrpc2.Request{RequestID: response.RequestID}}, message))
+		}
+	}()
+
+	data, err := json.Marshal(response)
+
+	if err != nil {
+
+		response := errorResponse(response.RequestID, jsonrpc2.InternalError, "")
+
+		if s.debug {
+
+			response.Error.Data = err.Error()
+		}
+
+		data, _ = json.Marshal(response)
+	}
+
+	return data
+}
+
+func (s *server) write(w http.ResponseWriter, data []byte) {
+
+	w.Write(append(data, '\n'))
+}
+
+type httpRequestKey struct{}
+
+func HTTPRequest(ctx context.Context) *http.Request {
+
+	r, _ := ctx.Value(httpRequestKey{}).(*http.Request)
+
+	return r
+}
+
+func isBatch(message json.RawMessage) bool {
+
+	message = bytes.TrimLeft(message, " \t\r\n")
+
+	return len(message) != 0 && message[0] == '['
+}
+
+// isNotification reports whether message is a request without an id, or
+// with a null one.
+func isNotification(message json.RawMessage) bool {
+
+	var request struct {
+		ID json.RawMessage `json:"id"`
+	}
+
+	if err := json.Unmarshal(message, &request); err != nil {
+
+		return false
+	}
+
+	return len(request.ID) == 0 || string(request.ID) == "null"
+}
+
+func errorResponse(requestID int, code int16, data string) *jsonrpc2.Response {
+
+	return &jsonrpc2.Response{
+		Jsonrpc:   "2.0",
+		RequestID: requestID,
+		Error:     jsonrpc2.NewError(code, data),
 	}
 }
@@ -2,13 +2,19 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/kshvakov/jsonrpc2"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestServerMethodNotAllowed(t *testing.T) {
@@ -67,6 +73,8 @@ func TestServerParseError(t *testing.T) {
 func TestServerPanic(t *testing.T) {
 
 	server := New()
+	server.SetDebug(true)
+	server.SetPanicHandler(nil)
 	server.RegisterFunc("TestPanic", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
 
 		panic("Panic")
@@ -81,8 +89,9 @@ func TestServerPanic(t *testing.T) {
 	client := &http.Client{}
 
 	req, _ := json.Marshal(&jsonrpc2.Request{
-		Method: "TestPanic",
-		Params: &jsonrpc2.EmptyParams{},
+		RequestID: 42,
+		Method:    "TestPanic",
+		Params:    &jsonrpc2.EmptyParams{},
 	})
 
 	response, err := client.Post(testServer.URL, "application/x-www-form-urlencoded", bytes.NewReader(req))
@@ -95,11 +104,264 @@ func TestServerPanic(t *testing.T) {
 
 		if assert.NoError(t, err) && assert.NotNil(t, result.Error) {
 
+			assert.Equal(t, 42, result.RequestID)
 			assert.True(t, jsonrpc2.InternalError == result.Error.Code)
 			assert.Equal(t, jsonrpc2.Errors[jsonrpc2.InternalError], result.Error.Message)
 			assert.Contains(t, result.Error.Data, "Panic")
+			assert.Contains(t, result.Error.Data, "goroutine")
+		}
+	}
+}
+
+func TestServerPanicHandler(t *testing.T) {
+
+	var (
+		method  string
+		params  json.RawMessage
+		message interface{}
+		stack   []byte
+	)
+
+	server := New()
+	server.SetPanicHandler(func(m string, p json.RawMessage, msg interface{}, s []byte) {
+
+		method, params, message, stack = m, p, msg, s
+	})
+
+	server.RegisterFunc("TestPanic", func(_ *testCallParams) (interface{}, error) {
+
+		panic("Panic")
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	client := &http.Client{}
+
+	req, _ := json.Marshal(&jsonrpc2.Request{
+		RequestID: 42,
+		Method:    "TestPanic",
+		Params:    &testCallParams{Message: "hello"},
+	})
+
+	response, err := client.Post(testServer.URL, "application/x-www-form-urlencoded", bytes.NewReader(req))
+
+	if assert.NoError(t, err) {
+
+		var result jsonrpc2.Response
+
+		err := json.NewDecoder(response.Body).Decode(&result)
+
+		if assert.NoError(t, err) && assert.NotNil(t, result.Error) {
+
+			assert.Equal(t, 42, result.RequestID)
+			assert.True(t, jsonrpc2.InternalError == result.Error.Code)
+			assert.Empty(t, result.Error.Data)
+		}
+
+		assert.Equal(t, "TestPanic", method)
+		assert.JSONEq(t, `{"Message":"hello"}`, string(params))
+		assert.Equal(t, "Panic", message)
+		assert.Contains(t, string(stack), "goroutine")
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+
+	server := New()
+	server.SetPanicHandler(nil)
+	server.RegisterFunc("Ok", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		return "OK", nil
+	})
+
+	server.RegisterFunc("TestPanic", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		panic("Panic")
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	client := &http.Client{}
+
+	req, _ := json.Marshal([]jsonrpc2.Request{
+		{RequestID: 1, Method: "Ok", Params: &jsonrpc2.EmptyParams{}},
+		{RequestID: 2, Method: "TestPanic", Params: &jsonrpc2.EmptyParams{}},
+		{RequestID: 3, Method: "MethodNotFound", Params: &jsonrpc2.EmptyParams{}},
+	})
+
+	response, err := client.Post(testServer.URL, "application/x-www-form-urlencoded", bytes.NewReader(req))
+
+	if assert.NoError(t, err) {
+
+		var result []jsonrpc2.Response
+
+		err := json.NewDecoder(response.Body).Decode(&result)
+
+		if assert.NoError(t, err) && assert.Len(t, result, 3) {
+
+			assert.Equal(t, 1, result[0].RequestID)
+			assert.Nil(t, result[0].Error)
+			assert.Equal(t, "OK", result[0].Result)
+
+			if assert.Equal(t, 2, result[1].RequestID) && assert.NotNil(t, result[1].Error) {
+
+				assert.True(t, jsonrpc2.InternalError == result[1].Error.Code)
+			}
+
+			if assert.Equal(t, 3, result[2].RequestID) && assert.NotNil(t, result[2].Error) {
+
+				assert.True(t, jsonrpc2.MethodNotFound == result[2].Error.Code)
+			}
+		}
+	}
+
+	response, err = client.Post(testServer.URL, "application/x-www-form-urlencoded", bytes.NewReader([]byte("[]")))
+
+	if assert.NoError(t, err) {
+
+		var result jsonrpc2.Response
+
+		err := json.NewDecoder(response.Body).Decode(&result)
+
+		if assert.NoError(t, err) && assert.NotNil(t, result.Error) {
+
+			assert.True(t, jsonrpc2.InvalidRequest == result.Error.Code)
+		}
+	}
+}
+
+func TestServerBatchNotifications(t *testing.T) {
+
+	var (
+		notified int32
+		server   = New()
+	)
+
+	server.RegisterFunc("Ok", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		atomic.AddInt32(&notified, 1)
+
+		return "OK", nil
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	response, err := http.Post(testServer.URL, "application/json", strings.NewReader(`[
+		{"jsonrpc": "2.0", "method": "Ok", "params": {}},
+		{"jsonrpc": "2.0", "id": 0, "method": "Ok", "params": {}},
+		{"jsonrpc": "2.0", "id": null, "method": "Ok", "params": {}},
+		1
+	]`))
+
+	if assert.NoError(t, err) {
+
+		defer response.Body.Close()
+
+		var result []jsonrpc2.Response
+
+		if err := json.NewDecoder(response.Body).Decode(&result); assert.NoError(t, err) && assert.Len(t, result, 2) {
+
+			assert.Equal(t, "OK", result[0].Result)
+
+			if assert.NotNil(t, result[1].Error) {
+
+				assert.True(t, jsonrpc2.InvalidRequest == result[1].Error.Code)
+			}
 		}
 	}
+
+	response, err = http.Post(testServer.URL, "application/json", strings.NewReader(`[{"jsonrpc": "2.0", "method": "Ok", "params": {}}]`))
+
+	if assert.NoError(t, err) {
+
+		defer response.Body.Close()
+
+		body, _ := ioutil.ReadAll(response.Body)
+
+		assert.Empty(t, body)
+	}
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&notified))
+	assert.Nil(t, server.HandleMessage(context.Background(), json.RawMessage(`[{"jsonrpc": "2.0", "method": "Ok", "params": {}}]`)))
+}
+
+func TestServerBatchConcurrency(t *testing.T) {
+
+	var (
+		inFlight, most int
+		mutex          = &sync.Mutex{}
+		server         = New()
+	)
+
+	server.SetBatchConcurrency(3)
+	server.RegisterFunc("Echo", func(params *testEchoParams) (int, error) {
+
+		mutex.Lock()
+
+		if inFlight++; inFlight > most {
+
+			most = inFlight
+		}
+
+		mutex.Unlock()
+
+		defer func() {
+
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+
+		return params.Value, nil
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	var requests []jsonrpc2.Request
+
+	for i := 1; i <= 7; i++ {
+
+		requests = append(requests, jsonrpc2.Request{RequestID: i, Method: "Echo", Params: &testEchoParams{Value: i}})
+	}
+
+	req, _ := json.Marshal(requests)
+
+	response, err := http.Post(testServer.URL, "application/x-www-form-urlencoded", bytes.NewReader(req))
+
+	if assert.NoError(t, err) {
+
+		var result []jsonrpc2.Response
+
+		if err := json.NewDecoder(response.Body).Decode(&result); assert.NoError(t, err) && assert.Len(t, result, 7) {
+
+			for i, r := range result {
+
+				assert.Equal(t, i+1, r.RequestID)
+				assert.Equal(t, float64(i+1), r.Result)
+			}
+		}
+
+		assert.Equal(t, 3, most)
+	}
+}
+
+type testEchoParams struct {
+	Value int
+}
+
+func (p *testEchoParams) IsValid() bool {
+
+	return true
 }
 
 func TestServerMethodNotFound(t *testing.T) {
@@ -317,3 +579,195 @@ func TestServerOk(t *testing.T) {
 		}
 	}
 }
+
+func TestServerErrorCode(t *testing.T) {
+
+	server := New()
+	server.RegisterFunc("Error", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		return nil, &jsonrpc2.Error{Code: -32099, Message: "Custom", Data: "data"}
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	if response := testPost(t, testServer.URL, "Error"); assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		assert.True(t, -32099 == response.Error.Code)
+		assert.Equal(t, "Custom", response.Error.Message)
+		assert.Equal(t, "data", response.Error.Data)
+	}
+}
+
+type testRowsParams struct {
+	Rows  int    `json:"rows"`
+	Fault string `json:"fault"`
+}
+
+func (p *testRowsParams) IsValid() bool {
+
+	return true
+}
+
+func newTestStreamServer(received chan struct{}) *httptest.Server {
+
+	server := New()
+	server.SetPanicHandler(nil)
+	server.RegisterFunc("Export", func(params *testRowsParams) (jsonrpc2.Stream, error) {
+
+		return func(yield func(element interface{}) error) error {
+
+			for i := 0; i < params.Rows; i++ {
+
+				if err := yield(map[string]int{"row": i}); err != nil {
+
+					return err
+				}
+			}
+
+			if received != nil {
+
+				<-received
+			}
+
+			switch params.Fault {
+			case "error":
+
+				return errors.New("export failed")
+
+			case "panic":
+
+				panic("export")
+			}
+
+			return nil
+		}, nil
+	})
+
+	return httptest.NewServer(server)
+}
+
+func TestServerStream(t *testing.T) {
+
+	received := make(chan struct{})
+
+	testServer := newTestStreamServer(received)
+
+	defer testServer.Close()
+
+	var (
+		client = jsonrpc2.NewClient(&testDiscovery{testServer.URL})
+		rows   []int
+	)
+
+	err := client.Send("Export", &testRowsParams{Rows: 1000}, jsonrpc2.ElementFunc(func(element json.RawMessage) error {
+
+		var row struct {
+			Row int `json:"row"`
+		}
+
+		if err := json.Unmarshal(element, &row); err != nil {
+
+			return err
+		}
+
+		if rows = append(rows, row.Row); len(rows) == 1 {
+
+			close(received)
+		}
+
+		return nil
+	}))
+
+	if assert.NoError(t, err) && assert.Len(t, rows, 1000) {
+
+		assert.Equal(t, 999, rows[999])
+	}
+}
+
+func TestServerStreamError(t *testing.T) {
+
+	testServer := newTestStreamServer(nil)
+
+	defer testServer.Close()
+
+	var (
+		client = jsonrpc2.NewClient(&testDiscovery{testServer.URL})
+		rows   int
+		count  = jsonrpc2.ElementFunc(func(element json.RawMessage) error {
+
+			rows++
+
+			return nil
+		})
+	)
+
+	if err := client.Send("Export", &testRowsParams{Fault: "error"}, count); assert.Error(t, err) {
+
+		_, ok := err.(*jsonrpc2.LogicError)
+
+		assert.True(t, ok)
+		assert.Equal(t, "export failed", err.Error())
+		assert.Equal(t, 0, rows)
+	}
+
+	if err := client.Send("Export", &testRowsParams{Fault: "panic"}, count); assert.Error(t, err) {
+
+		if e, ok := err.(*jsonrpc2.Error); assert.True(t, ok) {
+
+			assert.Equal(t, int16(jsonrpc2.InternalError), e.Code)
+		}
+	}
+
+	for _, fault := range []string{"error", "panic"} {
+
+		err := client.Send("Export", &testRowsParams{Rows: 3, Fault: fault}, count)
+
+		if assert.Error(t, err, fault) {
+
+			_, ok := err.(*jsonrpc2.Error)
+
+			assert.False(t, ok, fault)
+		}
+	}
+
+	response, err := http.Post(testServer.URL, "application/json", strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "Export", "params": {"rows": 3, "fault": "error"}}`))
+
+	// The response is aborted before or after its headers were sent.
+	if err == nil {
+
+		defer response.Body.Close()
+
+		var r jsonrpc2.Response
+
+		assert.Error(t, json.NewDecoder(response.Body).Decode(&r))
+	}
+}
+
+func TestServerStreamBuffered(t *testing.T) {
+
+	testServer := newTestStreamServer(nil)
+
+	defer testServer.Close()
+
+	batch, _ := json.Marshal([]jsonrpc2.Request{
+		{Jsonrpc: "2.0", RequestID: 1, Method: "Export", Params: &testRowsParams{Rows: 2}},
+		{Jsonrpc: "2.0", RequestID: 2, Method: "Export", Params: &testRowsParams{Rows: 1, Fault: "error"}},
+	})
+
+	response, err := http.Post(testServer.URL, "application/json", bytes.NewReader(batch))
+
+	if assert.NoError(t, err) {
+
+		defer response.Body.Close()
+
+		var responses []json.RawMessage
+
+		if assert.NoError(t, json.NewDecoder(response.Body).Decode(&responses)) && assert.Len(t, responses, 2) {
+
+			assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 1, "result": [{"row": 0}, {"row": 1}]}`, string(responses[0]))
+			assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 2, "error": {"code": -32001, "message": "export failed"}}`, string(responses[1]))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/kshvakov/jsonrpc2"
 	"github.com/stretchr/testify/assert"
@@ -59,9 +60,14 @@ func (t *testObject) MethodWithEmptyParams(params jsonrpc2.EmptyParams) (interfa
 	return nil, nil
 }
 
+func (t *testObject) MethodWithContext(ctx context.Context, params jsonrpc2.EmptyParams) (interface{}, error) {
+
+	return nil, nil
+}
+
 func TestRegisterObject(t *testing.T) {
 
-	methods := []string{"MethodWithEmptyParams"}
+	methods := []string{"MethodWithEmptyParams", "MethodWithContext"}
 
 	server := New()
 	server.RegisterObject("TestObject", &testObject{})
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"net/http"
+)
+
+func (s *server) Shutdown(ctx context.Context) error {
+
+	s.mutex.Lock()
+
+	s.shutdown = true
+
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+
+		s.inFlight.Wait()
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+
+		return nil
+
+	case <-ctx.Done():
+
+		s.cancel()
+
+		return ctx.Err()
+	}
+}
+
+func (s *server) Ready() bool {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	return !s.shutdown
+}
+
+func (s *server) ReadyHandler() http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if !s.Ready() {
+
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Write([]byte("ok\n"))
+	})
+}
+
+func (s *server) begin() bool {
+
+	s.mutex.Lock()
+
+	defer s.mutex.Unlock()
+
+	if s.shutdown {
+
+		return false
+	}
+
+	s.inFlight.Add(1)
+
+	return true
+}
+
+func (s *server) end() {
+
+	s.inFlight.Done()
+}
+
+func (s *server) context(parent context.Context) (context.Context, context.CancelFunc) {
+
+	ctx, cancel := context.WithCancel(parent)
+
+	stop := context.AfterFunc(s.ctx, cancel)
+
+	return ctx, func() {
+
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testPost calls method with empty params and request id 42, the modifiers
+// may change the HTTP request before it is sent.
+func testPost(t *testing.T, url string, method string, modifiers ...func(*http.Request)) *jsonrpc2.Response {
+
+	data, _ := json.Marshal(&jsonrpc2.Request{
+		RequestID: 42,
+		Method:    method,
+		Params:    &jsonrpc2.EmptyParams{},
+	})
+
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	for _, modify := range modifiers {
+
+		modify(req)
+	}
+
+	response, err := http.DefaultClient.Do(req)
+
+	if !assert.NoError(t, err) {
+
+		return nil
+	}
+
+	defer response.Body.Close()
+
+	var result jsonrpc2.Response
+
+	if !assert.NoError(t, json.NewDecoder(response.Body).Decode(&result)) {
+
+		return nil
+	}
+
+	return &result
+}
+
+// withHeader sets a header of the request of testPost.
+func withHeader(key, value string) func(*http.Request) {
+
+	return func(r *http.Request) {
+
+		r.Header.Set(key, value)
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	server := New()
+	server.RegisterFunc("Wait", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		close(started)
+
+		<-release
+
+		return "done", nil
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	responses := make(chan *jsonrpc2.Response)
+
+	go func() {
+
+		responses <- testPost(t, testServer.URL, "Wait")
+	}()
+
+	<-started
+
+	shutdown := make(chan error)
+
+	go func() {
+
+		shutdown <- server.Shutdown(context.Background())
+	}()
+
+	for server.Ready() {
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if response := testPost(t, testServer.URL, "Wait"); assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		assert.Equal(t, 42, response.RequestID)
+		assert.True(t, jsonrpc2.ServerError == response.Error.Code)
+		assert.Equal(t, "shutting down", response.Error.Data)
+	}
+
+	select {
+	case err := <-shutdown:
+
+		t.Fatalf("shutdown returned before in-flight calls were done: %v", err)
+
+	default:
+	}
+
+	close(release)
+
+	if response := <-responses; assert.NotNil(t, response) {
+
+		assert.Nil(t, response.Error)
+		assert.Equal(t, "done", response.Result)
+	}
+
+	assert.NoError(t, <-shutdown)
+}
+
+func TestServerShutdownDeadline(t *testing.T) {
+
+	started := make(chan struct{})
+
+	server := New()
+	server.RegisterFunc("Wait", func(ctx context.Context, _ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		close(started)
+
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	})
+
+	testServer := httptest.NewServer(server)
+
+	defer testServer.Close()
+
+	responses := make(chan *jsonrpc2.Response)
+
+	go func() {
+
+		responses <- testPost(t, testServer.URL, "Wait")
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, server.Shutdown(ctx))
+
+	if response := <-responses; assert.NotNil(t, response) && assert.NotNil(t, response.Error) {
+
+		assert.True(t, jsonrpc2.LogicErr == response.Error.Code)
+		assert.Equal(t, context.Canceled.Error(), response.Error.Message)
+	}
+}
+
+func TestServerReadyHandler(t *testing.T) {
+
+	server := New()
+
+	recorder := httptest.NewRecorder()
+
+	server.ReadyHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/ready", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	assert.NoError(t, server.Shutdown(context.Background()))
+
+	recorder = httptest.NewRecorder()
+
+	server.ReadyHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.False(t, server.Ready())
+}
@@ -0,0 +1,100 @@
+package stdio
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"os/exec"
+	"time"
+)
+
+// Start starts cmd and returns a client calling it over its stdin and stdout.
+// The stderr of cmd is left as it was set.
+func Start(cmd *exec.Cmd, options ...Option) (*Client, error) {
+
+	stdin, err := cmd.StdinPipe()
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+
+		return nil, err
+	}
+
+	var (
+		o           = newOptions(options)
+		connOptions []jsonrpc2.ConnOption
+	)
+
+	if o.handler != nil {
+
+		connOptions = append(connOptions, jsonrpc2.WithHandler(o.handler))
+	}
+
+	c := &Client{
+		Conn:        jsonrpc2.NewConn(newCodec(stdout, stdin, o, stdin, stdout), connOptions...),
+		cmd:         cmd,
+		stopTimeout: o.stopTimeout,
+		exited:      make(chan struct{}),
+	}
+
+	go func() {
+
+		<-c.Conn.Done()
+
+		c.err = cmd.Wait()
+
+		close(c.exited)
+	}()
+
+	return c, nil
+}
+
+// Client is a connection to a subprocess. Calls in flight when it exits fail
+// with jsonrpc2.ErrClosed.
+type Client struct {
+	*jsonrpc2.Conn
+	cmd         *exec.Cmd
+	stopTimeout time.Duration
+	exited      chan struct{}
+	err         error
+}
+
+// Close closes the stdin of the subprocess and waits for it to exit, it is
+// killed if it does not exit in time. Close returns the error of
+// exec.Cmd.Wait.
+func (c *Client) Close() error {
+
+	c.Conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.stopTimeout)
+
+	defer cancel()
+
+	select {
+	case <-c.exited:
+
+	case <-ctx.Done():
+
+		c.cmd.Process.Kill()
+
+		<-c.exited
+	}
+
+	return c.err
+}
+
+// Exited is closed when the subprocess exited.
+func (c *Client) Exited() <-chan struct{} {
+
+	return c.exited
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package stdio
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+func openStdin() (io.ReadCloser, func()) {
+
+	return ioutil.NopCloser(os.Stdin), func() {}
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package stdio
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+)
+
+// openStdin returns a non-blocking duplicate of stdin, so that closing it
+// interrupts a read blocked on a pipe, and the function restoring the mode of
+// stdin. Closing the returned reader never closes stdin itself.
+func openStdin() (io.ReadCloser, func()) {
+
+	fd, err := syscall.Dup(int(os.Stdin.Fd()))
+
+	if err != nil {
+
+		return ioutil.NopCloser(os.Stdin), func() {}
+	}
+
+	if err := syscall.SetNonblock(fd, true); err != nil {
+
+		syscall.Close(fd)
+
+		return ioutil.NopCloser(os.Stdin), func() {}
+	}
+
+	return os.NewFile(uintptr(fd), "/dev/stdin"), func() {
+
+		syscall.SetNonblock(int(os.Stdin.Fd()), false)
+	}
+}
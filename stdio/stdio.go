@@ -0,0 +1,141 @@
+package stdio
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/stream"
+	"io"
+	"os"
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	framing     stream.Framing
+	maxSize     int
+	handler     jsonrpc2.Handler
+	stopTimeout time.Duration
+}
+
+func newOptions(opts []Option) *options {
+
+	o := &options{
+		framing:     stream.ContentLength,
+		maxSize:     stream.DefaultMaxMessageSize,
+		stopTimeout: 5 * time.Second,
+	}
+
+	for _, option := range opts {
+
+		option(o)
+	}
+
+	return o
+}
+
+// WithFraming sets the framing of the messages, stream.ContentLength by
+// default.
+func WithFraming(framing stream.Framing) Option {
+
+	return func(o *options) {
+
+		o.framing = framing
+	}
+}
+
+// WithMaxMessageSize sets the size of the largest message read,
+// stream.DefaultMaxMessageSize by default.
+func WithMaxMessageSize(size int) Option {
+
+	return func(o *options) {
+
+		o.maxSize = size
+	}
+}
+
+// WithHandler sets the handler of the calls and notifications sent by the
+// subprocess.
+func WithHandler(handler jsonrpc2.Handler) Option {
+
+	return func(o *options) {
+
+		o.handler = handler
+	}
+}
+
+// WithStopTimeout sets how long Close waits for the subprocess to exit after
+// its stdin is closed before killing it, 5 seconds by default.
+func WithStopTimeout(timeout time.Duration) Option {
+
+	return func(o *options) {
+
+		o.stopTimeout = timeout
+	}
+}
+
+// NewCodec returns a codec reading the messages from r and writing them to
+// w. Closing the codec does not close r and w, they belong to the caller.
+func NewCodec(r io.Reader, w io.Writer, framing stream.Framing) jsonrpc2.Codec {
+
+	return stream.NewCodec(&pipe{Reader: r, Writer: w}, framing)
+}
+
+// newCodec returns a codec over r and w whose Close closes closers, the
+// pipes the package created.
+func newCodec(r io.Reader, w io.Writer, o *options, closers ...io.Closer) jsonrpc2.Codec {
+
+	return stream.NewLimitedCodec(&pipe{Reader: r, Writer: w, closers: closers}, o.framing, o.maxSize)
+}
+
+type pipe struct {
+	io.Reader
+	io.Writer
+	closers []io.Closer
+}
+
+func (p *pipe) Close() error {
+
+	var err error
+
+	for _, closer := range p.closers {
+
+		if e := closer.Close(); err == nil {
+
+			err = e
+		}
+	}
+
+	return err
+}
+
+// Serve serves the calls read from the stdin of the process with handler,
+// the server of the server package, and writes the responses to its stdout.
+// It returns when stdin is closed or ctx is done. Nothing else may be written
+// to stdout, logs go to stderr.
+func Serve(ctx context.Context, handler jsonrpc2.Handler, options ...Option) error {
+
+	stdin, restore := openStdin()
+
+	defer restore()
+
+	var (
+		o    = newOptions(options)
+		conn = jsonrpc2.NewConn(newCodec(stdin, os.Stdout, o, stdin), jsonrpc2.WithHandler(handler), jsonrpc2.WithContext(ctx))
+	)
+
+	select {
+	case <-conn.Done():
+
+	case <-ctx.Done():
+
+		conn.Close()
+	}
+
+	if err := conn.Err(); err != jsonrpc2.ErrClosed {
+
+		return err
+	}
+
+	return nil
+}
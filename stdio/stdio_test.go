@@ -0,0 +1,267 @@
+package stdio
+
+import (
+	"bytes"
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/kshvakov/jsonrpc2/stream"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+type echoParams struct {
+	Value string `json:"value"`
+}
+
+func (p *echoParams) IsValid() bool {
+
+	return true
+}
+
+// TestHelperProcess is the subprocess of the tests, it serves on its stdio.
+func TestHelperProcess(t *testing.T) {
+
+	if os.Getenv("JSONRPC2_STDIO_HELPER") != "1" {
+
+		return
+	}
+
+	s := server.New()
+	s.RegisterFunc("Echo", func(params *echoParams) (string, error) {
+
+		return params.Value, nil
+	})
+	s.RegisterFunc("Hello", func(ctx context.Context, _ *jsonrpc2.EmptyParams) (string, error) {
+
+		var name string
+
+		if err := jsonrpc2.ConnFromContext(ctx).SendContext(ctx, "Name", &jsonrpc2.EmptyParams{}, &name); err != nil {
+
+			return "", err
+		}
+
+		return "hello " + name, nil
+	})
+	s.RegisterFunc("Exit", func(_ *jsonrpc2.EmptyParams) (interface{}, error) {
+
+		os.Exit(3)
+
+		return nil, nil
+	})
+
+	var options []Option
+
+	if os.Getenv("JSONRPC2_STDIO_FRAMING") == "newline" {
+
+		options = append(options, WithFraming(stream.Newline))
+	}
+
+	if os.Getenv("JSONRPC2_STDIO_HANG") == "1" {
+
+		hang := make(chan struct{})
+
+		Serve(context.Background(), s, options...)
+
+		<-hang
+	}
+
+	ctx := context.Background()
+
+	if os.Getenv("JSONRPC2_STDIO_CANCEL") == "1" {
+
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+
+		defer cancel()
+	}
+
+	if err := Serve(ctx, s, options...); err != nil {
+
+		os.Exit(1)
+	}
+
+	if os.Getenv("JSONRPC2_STDIO_CANCEL") == "1" {
+
+		if _, err := os.Stdout.Write([]byte("served\n")); err != nil {
+
+			os.Exit(2)
+		}
+	}
+
+	os.Exit(0)
+}
+
+func helperCommand(env ...string) *exec.Cmd {
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(append(os.Environ(), "JSONRPC2_STDIO_HELPER=1"), env...)
+	cmd.Stderr = os.Stderr
+
+	return cmd
+}
+
+func TestStart(t *testing.T) {
+
+	handler := server.New()
+	handler.RegisterFunc("Name", func(_ *jsonrpc2.EmptyParams) (string, error) {
+
+		return "parent", nil
+	})
+
+	client, err := Start(helperCommand(), WithHandler(handler))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	var result string
+
+	if assert.NoError(t, client.Send("Echo", &echoParams{Value: "a"}, &result)) {
+
+		assert.Equal(t, "a", result)
+	}
+
+	if assert.NoError(t, client.Send("Hello", &jsonrpc2.EmptyParams{}, &result)) {
+
+		assert.Equal(t, "hello parent", result)
+	}
+
+	assert.NoError(t, client.Close())
+
+	<-client.Exited()
+}
+
+func TestStartNewline(t *testing.T) {
+
+	client, err := Start(helperCommand("JSONRPC2_STDIO_FRAMING=newline"), WithFraming(stream.Newline))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer client.Close()
+
+	var result string
+
+	if assert.NoError(t, client.Send("Echo", &echoParams{Value: "b"}, &result)) {
+
+		assert.Equal(t, "b", result)
+	}
+}
+
+func TestStartExit(t *testing.T) {
+
+	client, err := Start(helperCommand())
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	assert.Equal(t, jsonrpc2.ErrClosed, client.Send("Exit", &jsonrpc2.EmptyParams{}, nil))
+
+	<-client.Exited()
+
+	if err, ok := client.Close().(*exec.ExitError); assert.True(t, ok) {
+
+		assert.Equal(t, 3, err.ExitCode())
+	}
+}
+
+func TestStartKill(t *testing.T) {
+
+	client, err := Start(helperCommand("JSONRPC2_STDIO_HANG=1"), WithStopTimeout(50*time.Millisecond))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	start := time.Now()
+
+	assert.Error(t, client.Close())
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	_, err = Start(exec.Command("/nonexistent/jsonrpc2"))
+
+	assert.Error(t, err)
+}
+
+func TestServeCancel(t *testing.T) {
+
+	var (
+		stdout bytes.Buffer
+		cmd    = helperCommand("JSONRPC2_STDIO_CANCEL=1")
+	)
+
+	cmd.Stdout = &stdout
+
+	stdin, err := cmd.StdinPipe()
+
+	if !assert.NoError(t, err) || !assert.NoError(t, cmd.Start()) {
+
+		return
+	}
+
+	defer stdin.Close()
+
+	exited := make(chan error, 1)
+
+	go func() {
+
+		exited <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-exited:
+
+		assert.NoError(t, err)
+		assert.Contains(t, stdout.String(), "served")
+
+	case <-time.After(5 * time.Second):
+
+		cmd.Process.Kill()
+
+		t.Error("Serve did not return after its context was cancelled")
+	}
+}
+
+type closer struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closer) Close() error {
+
+	c.closed = true
+
+	return nil
+}
+
+func TestNewCodec(t *testing.T) {
+
+	var (
+		r     = &closer{Reader: strings.NewReader("Content-Length: 2\r\n\r\n{}")}
+		w     bytes.Buffer
+		codec = NewCodec(r, &w, stream.ContentLength)
+	)
+
+	if message, err := codec.ReadMessage(); assert.NoError(t, err) {
+
+		assert.Equal(t, "{}", string(message))
+	}
+
+	assert.NoError(t, codec.WriteMessage([]byte("[]")))
+	assert.Equal(t, "Content-Length: 2\r\n\r\n[]", w.String())
+	assert.NoError(t, codec.Close())
+	assert.False(t, r.closed)
+}
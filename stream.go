@@ -0,0 +1,162 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Stream is a result written as a JSON array one element at a time. It
+// passes the elements to yield in order and stops when yield fails. Only a
+// single call over HTTP is written incrementally, without holding the
+// elements in memory. Elsewhere, in a batch, on a Conn, as the result of a
+// job or through a recorder, the stream is read into the response first.
+//
+// An error of the stream before its first element is the error of the
+// response. Over HTTP a later error aborts the response, the client then
+// fails to read it instead of taking the elements read so far for the whole
+// result.
+type Stream func(yield func(element interface{}) error) error
+
+// MarshalJSON reads the whole stream, it is used where the response cannot
+// be written incrementally.
+func (s Stream) MarshalJSON() ([]byte, error) {
+
+	if s == nil {
+
+		return []byte("null"), nil
+	}
+
+	buffer := bytes.NewBufferString("[")
+
+	err := s(func(element interface{}) error {
+
+		data, err := json.Marshal(element)
+
+		if err != nil {
+
+			return err
+		}
+
+		if buffer.Len() > 1 {
+
+			buffer.WriteByte(',')
+		}
+
+		buffer.Write(data)
+
+		return nil
+	})
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	buffer.WriteByte(']')
+
+	return buffer.Bytes(), nil
+}
+
+// ElementFunc is a call result whose array elements are decoded one at a
+// time: the elements are passed to the func as they are read and the call
+// fails with its error. A call with an ElementFunc result is not sent to
+// another server once an element was passed.
+type ElementFunc func(element json.RawMessage) error
+
+// streamReader is implemented by the codecs whose responses can be read
+// before they are received completely.
+type streamReader interface {
+	readStream() (io.Reader, error)
+}
+
+// decodeStream decodes a response, the elements of its result are passed to
+// fn as they are read.
+func decodeStream(r io.Reader, fn ElementFunc) error {
+
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil {
+
+		return err
+
+	} else if token != json.Delim('{') {
+
+		return errors.New("jsonrpc2: the response is not an object")
+	}
+
+	var e *Error
+
+	for decoder.More() {
+
+		key, err := decoder.Token()
+
+		if err != nil {
+
+			return err
+		}
+
+		switch key {
+		case "result":
+
+			err = decodeElements(decoder, fn)
+
+		case "error":
+
+			err = decoder.Decode(&e)
+
+		default:
+
+			var value json.RawMessage
+
+			err = decoder.Decode(&value)
+		}
+
+		if err != nil {
+
+			return err
+		}
+	}
+
+	if e == nil {
+
+		return nil
+	}
+
+	return callError(e)
+}
+
+func decodeElements(decoder *json.Decoder, fn ElementFunc) error {
+
+	token, err := decoder.Token()
+
+	if err != nil || token == nil {
+
+		return err
+	}
+
+	if token != json.Delim('[') {
+
+		return errors.New("jsonrpc2: the result is not an array")
+	}
+
+	for decoder.More() {
+
+		var element json.RawMessage
+
+		if err := decoder.Decode(&element); err != nil {
+
+			return err
+		}
+
+		if err := fn(element); err != nil {
+
+			return err
+		}
+	}
+
+	_, err = decoder.Token()
+
+	return err
+}
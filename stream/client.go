@@ -0,0 +1,208 @@
+package stream
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"sync"
+)
+
+// NewClient returns a client calling the server at address, network is "tcp"
+// or "unix". The calls are spread over a pool of connections dialed on first
+// use and redialed when lost. Any number of calls are pipelined on each
+// connection and their responses are matched by id.
+func NewClient(network, address string, options ...Option) *Client {
+
+	o := newOptions(options)
+
+	if o.poolSize < 1 {
+
+		o.poolSize = 1
+	}
+
+	return &Client{
+		network: network,
+		address: address,
+		options: o,
+		mutex:   &sync.Mutex{},
+		conns:   make([]*jsonrpc2.Conn, o.poolSize),
+		dialing: make([]chan struct{}, o.poolSize),
+	}
+}
+
+type Client struct {
+	network string
+	address string
+	options *options
+	mutex   *sync.Mutex
+	conns   []*jsonrpc2.Conn
+	dialing []chan struct{}
+	next    int
+	closed  bool
+}
+
+func (c *Client) Send(method string, params jsonrpc2.Params, result interface{}) error {
+
+	return c.SendContext(context.Background(), method, params, result)
+}
+
+func (c *Client) SendContext(ctx context.Context, method string, params jsonrpc2.Params, result interface{}) error {
+
+	conn, err := c.conn(ctx)
+
+	if err != nil {
+
+		return err
+	}
+
+	return conn.SendContext(ctx, method, params, result)
+}
+
+func (c *Client) Notify(ctx context.Context, method string, params jsonrpc2.Params) error {
+
+	conn, err := c.conn(ctx)
+
+	if err != nil {
+
+		return err
+	}
+
+	return conn.Notify(method, params)
+}
+
+func (c *Client) Close() error {
+
+	c.mutex.Lock()
+
+	c.closed = true
+
+	conns := c.conns
+
+	c.mutex.Unlock()
+
+	for _, conn := range conns {
+
+		if conn != nil {
+
+			conn.Close()
+		}
+	}
+
+	return nil
+}
+
+// Conn returns the next connection of the pool, subscriptions are tied to
+// it.
+func (c *Client) Conn(ctx context.Context) (*jsonrpc2.Conn, error) {
+
+	return c.conn(ctx)
+}
+
+// conn returns the next connection of the pool, it dials it if it is not
+// open. The server is dialed and the connect handler called without holding
+// the lock, so that the handler can call the client; concurrent callers of
+// the same connection wait for the dial in progress.
+func (c *Client) conn(ctx context.Context) (*jsonrpc2.Conn, error) {
+
+	c.mutex.Lock()
+
+	i := c.next
+
+	c.next = (c.next + 1) % len(c.conns)
+
+	c.mutex.Unlock()
+
+	for {
+
+		c.mutex.Lock()
+
+		if c.closed {
+
+			c.mutex.Unlock()
+
+			return nil, jsonrpc2.ErrClosed
+		}
+
+		if conn := c.conns[i]; conn != nil && conn.Err() == nil {
+
+			c.mutex.Unlock()
+
+			return conn, nil
+		}
+
+		if dialing := c.dialing[i]; dialing != nil {
+
+			c.mutex.Unlock()
+
+			select {
+			case <-dialing:
+
+				continue
+
+			case <-ctx.Done():
+
+				return nil, ctx.Err()
+			}
+		}
+
+		dialing := make(chan struct{})
+
+		c.dialing[i] = dialing
+
+		c.mutex.Unlock()
+
+		conn, err := c.dial(ctx)
+
+		c.mutex.Lock()
+
+		c.dialing[i] = nil
+
+		if err == nil {
+
+			if c.closed {
+
+				conn.Close()
+
+				err = jsonrpc2.ErrClosed
+
+			} else {
+
+				c.conns[i] = conn
+			}
+		}
+
+		c.mutex.Unlock()
+
+		close(dialing)
+
+		if err != nil {
+
+			return nil, err
+		}
+
+		if c.options.onConnect != nil {
+
+			c.options.onConnect(conn)
+		}
+
+		return conn, nil
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*jsonrpc2.Conn, error) {
+
+	nc, err := c.options.dialer.DialContext(ctx, c.network, c.address)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	var options []jsonrpc2.ConnOption
+
+	if c.options.handler != nil {
+
+		options = append(options, jsonrpc2.WithHandler(c.options.handler))
+	}
+
+	return jsonrpc2.NewConn(newCodec(nc, c.options), options...), nil
+}
@@ -0,0 +1,135 @@
+package stream
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientPool(t *testing.T) {
+
+	var (
+		mutex        = &sync.Mutex{}
+		conns        []*jsonrpc2.Conn
+		srv, address = newTestServer(t, "tcp", WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+			mutex.Lock()
+			conns = append(conns, conn)
+			mutex.Unlock()
+		}))
+		client = NewClient("tcp", address, WithPoolSize(3))
+		result string
+	)
+
+	defer srv.Close()
+	defer client.Close()
+
+	for i := 0; i < 10; i++ {
+
+		assert.NoError(t, client.Send("Echo", &echoParams{Value: "a"}, &result))
+	}
+
+	mutex.Lock()
+
+	assert.Len(t, conns, 3)
+
+	conns[0].Close()
+
+	mutex.Unlock()
+
+	assert.Eventually(t, func() bool {
+
+		client.mutex.Lock()
+
+		defer client.mutex.Unlock()
+
+		for _, conn := range client.conns {
+
+			if conn.Err() != nil {
+
+				return true
+			}
+		}
+
+		return false
+
+	}, time.Second, time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+
+		assert.NoError(t, client.Send("Echo", &echoParams{Value: "a"}, &result))
+	}
+
+	assert.Eventually(t, func() bool {
+
+		mutex.Lock()
+
+		defer mutex.Unlock()
+
+		return len(conns) == 4
+
+	}, time.Second, time.Millisecond)
+
+	assert.NoError(t, client.Notify(context.Background(), "Echo", &echoParams{}))
+	assert.NoError(t, client.Close())
+	assert.Equal(t, jsonrpc2.ErrClosed, client.Send("Echo", &echoParams{}, &result))
+	assert.Equal(t, jsonrpc2.ErrClosed, client.Notify(context.Background(), "Echo", &echoParams{}))
+}
+
+func TestClientConnectHandlerSends(t *testing.T) {
+
+	var (
+		srv, address = newTestServer(t, "tcp")
+		client       *Client
+		once         = &sync.Once{}
+		echoed       = make(chan string, 1)
+		result       string
+	)
+
+	defer srv.Close()
+
+	client = NewClient("tcp", address, WithPoolSize(1), WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+		once.Do(func() {
+
+			var result string
+
+			client.Send("Echo", &echoParams{Value: "again"}, &result)
+
+			echoed <- result
+		})
+	}))
+
+	defer client.Close()
+
+	done := make(chan error, 1)
+
+	go func() {
+
+		done <- client.Send("Echo", &echoParams{Value: "a"}, &result)
+	}()
+
+	select {
+	case err := <-done:
+
+		assert.NoError(t, err)
+		assert.Equal(t, "again", <-echoed)
+
+	case <-time.After(time.Second):
+
+		t.Fatal("the connect handler could not call the client")
+	}
+}
+
+func TestClientDialError(t *testing.T) {
+
+	client := NewClient("tcp", "127.0.0.1:1", WithPoolSize(0))
+
+	defer client.Close()
+
+	assert.Error(t, client.Send("Echo", &echoParams{}, nil))
+	assert.Len(t, client.conns, 1)
+}
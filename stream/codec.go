@@ -0,0 +1,251 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kshvakov/jsonrpc2"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Framing int
+
+const (
+	// Newline frames every message as a line of compact JSON.
+	Newline Framing = iota
+	// ContentLength frames every message with LSP-style headers:
+	// Content-Length: <bytes>\r\n\r\n<message>.
+	ContentLength
+)
+
+// DefaultMaxMessageSize is the size of the largest message a codec reads by
+// default.
+const DefaultMaxMessageSize = 32 << 20
+
+// ErrMessageTooLarge is returned by a codec reading a message larger than its
+// maximum size.
+var ErrMessageTooLarge = errors.New("stream: message too large")
+
+func (f Framing) String() string {
+
+	switch f {
+	case Newline:
+
+		return "newline"
+
+	case ContentLength:
+
+		return "content-length"
+	}
+
+	return "unknown"
+}
+
+// NewCodec returns a codec reading and writing the framed messages of rwc,
+// it reads messages of up to DefaultMaxMessageSize bytes.
+func NewCodec(rwc io.ReadWriteCloser, framing Framing) jsonrpc2.Codec {
+
+	return NewLimitedCodec(rwc, framing, DefaultMaxMessageSize)
+}
+
+// NewLimitedCodec returns a codec failing with ErrMessageTooLarge on the
+// messages larger than maxSize bytes, before reading them.
+func NewLimitedCodec(rwc io.ReadWriteCloser, framing Framing, maxSize int) jsonrpc2.Codec {
+
+	return &codec{
+		reader:  bufio.NewReader(rwc),
+		writer:  rwc,
+		closer:  rwc,
+		framing: framing,
+		maxSize: maxSize,
+	}
+}
+
+// newCodec returns the codec of a connection of a server or a client.
+func newCodec(rwc io.ReadWriteCloser, o *options) jsonrpc2.Codec {
+
+	c := NewLimitedCodec(rwc, o.framing, o.maxSize).(*codec)
+	c.writeTimeout = o.writeTimeout
+
+	return c
+}
+
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+type codec struct {
+	reader       *bufio.Reader
+	writer       io.Writer
+	closer       io.Closer
+	framing      Framing
+	maxSize      int
+	writeTimeout time.Duration
+}
+
+func (c *codec) ReadMessage() (json.RawMessage, error) {
+
+	if c.framing == ContentLength {
+
+		return c.readContentLength()
+	}
+
+	var line []byte
+
+	for {
+
+		chunk, err := c.reader.ReadSlice('\n')
+
+		line = bytes.TrimLeft(append(line, chunk...), " \t\r\n")
+
+		if len(line) > c.maxSize+2 {
+
+			return nil, ErrMessageTooLarge
+		}
+
+		if err == bufio.ErrBufferFull {
+
+			continue
+		}
+
+		if err != nil {
+
+			if err == io.EOF && len(line) != 0 {
+
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			return nil, err
+		}
+
+		if message := bytes.TrimRight(line, " \t\r\n"); len(message) != 0 {
+
+			if len(message) > c.maxSize {
+
+				return nil, ErrMessageTooLarge
+			}
+
+			return message, nil
+		}
+	}
+}
+
+func (c *codec) readContentLength() (json.RawMessage, error) {
+
+	length := -1
+
+	for lines := 0; ; lines++ {
+
+		line, err := c.reader.ReadString('\n')
+
+		if err != nil {
+
+			if err == io.EOF && (lines != 0 || line != "") {
+
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+
+			break
+		}
+
+		colon := strings.Index(line, ":")
+
+		if colon == -1 {
+
+			return nil, fmt.Errorf("invalid header %q", line)
+		}
+
+		if strings.EqualFold(strings.TrimSpace(line[:colon]), "Content-Length") {
+
+			if length, err = strconv.Atoi(strings.TrimSpace(line[colon+1:])); err != nil || length < 0 {
+
+				return nil, fmt.Errorf("invalid header %q", line)
+			}
+		}
+	}
+
+	if length == -1 {
+
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	if length > c.maxSize {
+
+		return nil, ErrMessageTooLarge
+	}
+
+	message := make([]byte, length)
+
+	if _, err := io.ReadFull(c.reader, message); err != nil {
+
+		if err == io.EOF {
+
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (c *codec) WriteMessage(message json.RawMessage) error {
+
+	var buf bytes.Buffer
+
+	switch c.framing {
+	case ContentLength:
+
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n", len(message))
+
+		buf.Write(message)
+
+	default:
+
+		if bytes.ContainsAny(message, "\r\n") {
+
+			if err := json.Compact(&buf, message); err != nil {
+
+				return err
+			}
+
+		} else {
+
+			buf.Write(message)
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	if d, ok := c.writer.(writeDeadliner); ok && c.writeTimeout > 0 {
+
+		d.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+
+	if _, err := c.writer.Write(buf.Bytes()); err != nil {
+
+		// A message may have been written in part, the stream is lost.
+		c.closer.Close()
+
+		return err
+	}
+
+	return nil
+}
+
+func (c *codec) Close() error {
+
+	return c.closer.Close()
+}
@@ -0,0 +1,180 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type buffer struct {
+	io.Reader
+	bytes.Buffer
+	closed bool
+}
+
+func (b *buffer) Read(p []byte) (int, error) {
+
+	return b.Reader.Read(p)
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+
+	return b.Buffer.Write(p)
+}
+
+func (b *buffer) Close() error {
+
+	b.closed = true
+
+	return nil
+}
+
+func newBuffer(input string) *buffer {
+
+	return &buffer{
+		Reader: strings.NewReader(input),
+	}
+}
+
+func TestNewlineCodec(t *testing.T) {
+
+	var (
+		b     = newBuffer("{\"id\": 1}\n\n  \r\n[1,2]\r\n{\"id\"")
+		codec = NewCodec(b, Newline)
+	)
+
+	for _, expected := range []string{`{"id": 1}`, `[1,2]`} {
+
+		if message, err := codec.ReadMessage(); assert.NoError(t, err) {
+
+			assert.Equal(t, expected, string(message))
+		}
+	}
+
+	_, err := codec.ReadMessage()
+
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, err = NewCodec(newBuffer(""), Newline).ReadMessage()
+
+	assert.Equal(t, io.EOF, err)
+
+	assert.NoError(t, codec.WriteMessage(json.RawMessage(`{"a": 1}`)))
+	assert.NoError(t, codec.WriteMessage(json.RawMessage("{\n  \"b\": \"x\\ny\"\n}")))
+	assert.Error(t, codec.WriteMessage(json.RawMessage("{\n")))
+	assert.Equal(t, "{\"a\": 1}\n{\"b\":\"x\\ny\"}\n", b.String())
+
+	assert.NoError(t, codec.Close())
+	assert.True(t, b.closed)
+}
+
+func TestContentLengthCodec(t *testing.T) {
+
+	var (
+		b     = newBuffer("Content-Length: 9\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{\"id\": 1}content-length:2\r\n\r\n[]Content-Length: 5\r\n\r\n{")
+		codec = NewCodec(b, ContentLength)
+	)
+
+	for _, expected := range []string{`{"id": 1}`, `[]`} {
+
+		if message, err := codec.ReadMessage(); assert.NoError(t, err) {
+
+			assert.Equal(t, expected, string(message))
+		}
+	}
+
+	_, err := codec.ReadMessage()
+
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	for input, expected := range map[string]error{
+		"":                              io.EOF,
+		"Content-Length: 2\r\n":         io.ErrUnexpectedEOF,
+		"Content-Length: x\r\n\r\n":     nil,
+		"Content-Length: -1\r\n\r\n":    nil,
+		"Content-Type: text\r\n\r\n{}":  nil,
+		"garbage\r\n\r\n":               nil,
+		"Content-Length: 4\r\n\r\n{}":   io.ErrUnexpectedEOF,
+		"Content-Length: 0\r\n\r\nnext": nil,
+	} {
+
+		message, err := NewCodec(newBuffer(input), ContentLength).ReadMessage()
+
+		switch {
+		case expected != nil:
+
+			assert.Equal(t, expected, err, input)
+
+		case input == "Content-Length: 0\r\n\r\nnext":
+
+			if assert.NoError(t, err) {
+
+				assert.Empty(t, message)
+			}
+
+		default:
+
+			assert.Error(t, err, input)
+		}
+	}
+
+	assert.NoError(t, codec.WriteMessage(json.RawMessage(`{"a": 1}`)))
+	assert.Equal(t, "Content-Length: 8\r\n\r\n{\"a\": 1}", b.String())
+}
+
+func TestCodecMaxMessageSize(t *testing.T) {
+
+	for input, framing := range map[string]Framing{
+		"Content-Length: 9223372036854775807\r\n\r\n{}": ContentLength,
+		"Content-Length: 9\r\n\r\n{\"id\": 1}":          ContentLength,
+		"{\"id\": 1}\n":                                 Newline,
+		"  {\"id\": 1}" + strings.Repeat(" ", 8192):     Newline,
+	} {
+
+		_, err := NewLimitedCodec(newBuffer(input), framing, 8).ReadMessage()
+
+		assert.Equal(t, ErrMessageTooLarge, err, input)
+	}
+
+	for input, framing := range map[string]Framing{
+		"Content-Length: 8\r\n\r\n{\"a\": 1}": ContentLength,
+		"\r\n  {\"a\": 1}\r\n":                Newline,
+	} {
+
+		if message, err := NewLimitedCodec(newBuffer(input), framing, 8).ReadMessage(); assert.NoError(t, err, input) {
+
+			assert.Equal(t, `{"a": 1}`, string(message))
+		}
+	}
+}
+
+func TestCodecWriteTimeout(t *testing.T) {
+
+	a, b := net.Pipe()
+
+	defer b.Close()
+
+	var (
+		codec = newCodec(a, newOptions([]Option{WithWriteTimeout(20 * time.Millisecond)}))
+		start = time.Now()
+	)
+
+	assert.Error(t, codec.WriteMessage(json.RawMessage(`{}`)))
+	assert.True(t, time.Since(start) < time.Second)
+
+	_, err := b.Read(make([]byte, 1))
+
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFramingString(t *testing.T) {
+
+	assert.Equal(t, "newline", Newline.String())
+	assert.Equal(t, "content-length", ContentLength.String())
+	assert.Equal(t, "unknown", Framing(7).String())
+}
@@ -0,0 +1,103 @@
+package stream
+
+import (
+	"github.com/kshvakov/jsonrpc2"
+	"net"
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	framing      Framing
+	maxSize      int
+	writeTimeout time.Duration
+	poolSize     int
+	dialer       *net.Dialer
+	handler      jsonrpc2.Handler
+	onConnect    func(conn *jsonrpc2.Conn)
+}
+
+func newOptions(opts []Option) *options {
+
+	o := &options{
+		framing:  Newline,
+		maxSize:  DefaultMaxMessageSize,
+		poolSize: 4,
+		dialer:   &net.Dialer{},
+	}
+
+	for _, option := range opts {
+
+		option(o)
+	}
+
+	return o
+}
+
+// WithFraming sets the framing of the messages, Newline by default.
+func WithFraming(framing Framing) Option {
+
+	return func(o *options) {
+
+		o.framing = framing
+	}
+}
+
+// WithMaxMessageSize sets the size of the largest message read, a connection
+// sending a larger one is closed. DefaultMaxMessageSize by default.
+func WithMaxMessageSize(size int) Option {
+
+	return func(o *options) {
+
+		o.maxSize = size
+	}
+}
+
+// WithWriteTimeout sets how long writing a message may block, the write
+// deadline of the connection is set before every message. A connection whose
+// peer does not read in time is closed. No timeout by default.
+func WithWriteTimeout(timeout time.Duration) Option {
+
+	return func(o *options) {
+
+		o.writeTimeout = timeout
+	}
+}
+
+// WithPoolSize sets the number of connections of the client, 4 by default.
+func WithPoolSize(size int) Option {
+
+	return func(o *options) {
+
+		o.poolSize = size
+	}
+}
+
+func WithDialer(dialer *net.Dialer) Option {
+
+	return func(o *options) {
+
+		o.dialer = dialer
+	}
+}
+
+// WithHandler sets the handler of the calls and notifications the server
+// sends to the client.
+func WithHandler(handler jsonrpc2.Handler) Option {
+
+	return func(o *options) {
+
+		o.handler = handler
+	}
+}
+
+// WithConnectHandler sets a function called with every new connection of the
+// server or the client.
+func WithConnectHandler(fn func(conn *jsonrpc2.Conn)) Option {
+
+	return func(o *options) {
+
+		o.onConnect = fn
+	}
+}
@@ -0,0 +1,170 @@
+package stream
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"net"
+	"sync"
+)
+
+// NewServer returns a server serving the calls received on the accepted
+// connections with handler, the server of the server package.
+func NewServer(handler jsonrpc2.Handler, options ...Option) *Server {
+
+	return &Server{
+		handler:   handler,
+		options:   newOptions(options),
+		mutex:     &sync.Mutex{},
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[*jsonrpc2.Conn]struct{}),
+	}
+}
+
+type Server struct {
+	handler   jsonrpc2.Handler
+	options   *options
+	mutex     *sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*jsonrpc2.Conn]struct{}
+	closed    bool
+}
+
+// ListenAndServe listens on a TCP address or the path of a Unix socket,
+// network is "tcp" or "unix".
+func (s *Server) ListenAndServe(network, address string) error {
+
+	listener, err := net.Listen(network, address)
+
+	if err != nil {
+
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts connections until the listener fails or the server is
+// closed, then it returns nil.
+func (s *Server) Serve(listener net.Listener) error {
+
+	s.mutex.Lock()
+
+	if s.closed {
+
+		s.mutex.Unlock()
+
+		return listener.Close()
+	}
+
+	s.listeners[listener] = struct{}{}
+
+	s.mutex.Unlock()
+
+	defer func() {
+
+		s.mutex.Lock()
+
+		delete(s.listeners, listener)
+
+		s.mutex.Unlock()
+	}()
+
+	for {
+
+		nc, err := listener.Accept()
+
+		if err != nil {
+
+			s.mutex.Lock()
+
+			defer s.mutex.Unlock()
+
+			if s.closed {
+
+				return nil
+			}
+
+			return err
+		}
+
+		s.serve(nc)
+	}
+}
+
+func (s *Server) serve(nc net.Conn) {
+
+	conn := jsonrpc2.NewConn(newCodec(nc, s.options), jsonrpc2.WithHandler(s.handler), jsonrpc2.WithContext(context.WithValue(context.Background(), remoteAddrKey{}, nc.RemoteAddr())))
+
+	s.mutex.Lock()
+
+	if s.closed {
+
+		s.mutex.Unlock()
+
+		conn.Close()
+
+		return
+	}
+
+	s.conns[conn] = struct{}{}
+
+	s.mutex.Unlock()
+
+	if s.options.onConnect != nil {
+
+		s.options.onConnect(conn)
+	}
+
+	go func() {
+
+		<-conn.Done()
+
+		s.mutex.Lock()
+
+		delete(s.conns, conn)
+
+		s.mutex.Unlock()
+	}()
+}
+
+// Close closes the listeners and the connections, it waits for the calls in
+// flight.
+func (s *Server) Close() error {
+
+	s.mutex.Lock()
+
+	s.closed = true
+
+	var (
+		listeners = s.listeners
+		conns     = s.conns
+	)
+
+	s.listeners = make(map[net.Listener]struct{})
+	s.conns = make(map[*jsonrpc2.Conn]struct{})
+
+	s.mutex.Unlock()
+
+	for listener := range listeners {
+
+		listener.Close()
+	}
+
+	for conn := range conns {
+
+		conn.Close()
+	}
+
+	return nil
+}
+
+type remoteAddrKey struct{}
+
+// RemoteAddr returns the address of the peer a call was received from, nil if
+// it was not received by a Server.
+func RemoteAddr(ctx context.Context) net.Addr {
+
+	addr, _ := ctx.Value(remoteAddrKey{}).(net.Addr)
+
+	return addr
+}
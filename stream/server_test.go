@@ -0,0 +1,180 @@
+package stream
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type echoParams struct {
+	Value string `json:"value"`
+	Delay int    `json:"delay"`
+}
+
+func (p *echoParams) IsValid() bool {
+
+	return true
+}
+
+func newTestServer(t *testing.T, network string, options ...Option) (*Server, string) {
+
+	s := server.New()
+	s.RegisterFunc("Echo", func(params *echoParams) (string, error) {
+
+		time.Sleep(time.Duration(params.Delay) * time.Millisecond)
+
+		return params.Value, nil
+	})
+	s.RegisterFunc("RemoteAddr", func(ctx context.Context, _ *jsonrpc2.EmptyParams) (string, error) {
+
+		return RemoteAddr(ctx).Network(), nil
+	})
+
+	address := "127.0.0.1:0"
+
+	if network == "unix" {
+
+		address = filepath.Join(t.TempDir(), "jsonrpc2.sock")
+	}
+
+	listener, err := net.Listen(network, address)
+
+	if !assert.NoError(t, err) {
+
+		t.FailNow()
+	}
+
+	srv := NewServer(s, options...)
+
+	go srv.Serve(listener)
+
+	return srv, listener.Addr().String()
+}
+
+func TestServer(t *testing.T) {
+
+	for _, network := range []string{"tcp", "unix"} {
+
+		for _, framing := range []Framing{Newline, ContentLength} {
+
+			t.Run(network+"/"+framing.String(), func(t *testing.T) {
+
+				var (
+					srv, address = newTestServer(t, network, WithFraming(framing))
+					client       = NewClient(network, address, WithFraming(framing), WithPoolSize(2))
+					wg           = &sync.WaitGroup{}
+				)
+
+				defer srv.Close()
+				defer client.Close()
+
+				for i, delay := range []int{30, 0, 20, 10, 0, 5} {
+
+					wg.Add(1)
+
+					go func(value string, delay int) {
+
+						defer wg.Done()
+
+						var result string
+
+						if assert.NoError(t, client.Send("Echo", &echoParams{Value: value, Delay: delay}, &result)) {
+
+							assert.Equal(t, value, result)
+						}
+
+					}(string(rune('a'+i)), delay)
+				}
+
+				wg.Wait()
+
+				var result string
+
+				if assert.NoError(t, client.Send("RemoteAddr", &jsonrpc2.EmptyParams{}, &result)) {
+
+					assert.Equal(t, network, result)
+				}
+
+				if err, ok := client.Send("Unknown", &jsonrpc2.EmptyParams{}, &result).(*jsonrpc2.Error); assert.True(t, ok) {
+
+					assert.Equal(t, int16(jsonrpc2.MethodNotFound), err.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestServerClose(t *testing.T) {
+
+	var (
+		connected    = make(chan *jsonrpc2.Conn, 1)
+		srv, address = newTestServer(t, "tcp", WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+			connected <- conn
+		}))
+		client = NewClient("tcp", address, WithPoolSize(1))
+		result string
+	)
+
+	defer client.Close()
+
+	assert.NoError(t, client.Send("Echo", &echoParams{Value: "a"}, &result))
+
+	conn := <-connected
+
+	assert.NoError(t, srv.Close())
+
+	<-conn.Done()
+
+	assert.Error(t, client.Send("Echo", &echoParams{Value: "a"}, &result))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if assert.NoError(t, err) {
+
+		assert.NoError(t, srv.Serve(listener))
+	}
+
+	assert.Nil(t, RemoteAddr(context.Background()))
+}
+
+func TestServerMessageTooLarge(t *testing.T) {
+
+	srv, address := newTestServer(t, "tcp", WithFraming(ContentLength), WithMaxMessageSize(1024))
+
+	defer srv.Close()
+
+	nc, err := net.Dial("tcp", address)
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer nc.Close()
+
+	nc.Write([]byte("Content-Length: 9223372036854775807\r\n\r\n"))
+	nc.SetReadDeadline(time.Now().Add(time.Second))
+
+	_, err = nc.Read(make([]byte, 1))
+
+	assert.Equal(t, io.EOF, err)
+
+	client := NewClient("tcp", address, WithFraming(ContentLength), WithPoolSize(1))
+
+	defer client.Close()
+
+	var result string
+
+	if assert.NoError(t, client.Send("Echo", &echoParams{Value: "a"}, &result)) {
+
+		assert.Equal(t, "a", result)
+	}
+}
@@ -0,0 +1,172 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStreamMarshalJSON(t *testing.T) {
+
+	stream := Stream(func(yield func(element interface{}) error) error {
+
+		for i := 0; i < 3; i++ {
+
+			if err := yield(i); err != nil {
+
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if data, err := json.Marshal(stream); assert.NoError(t, err) {
+
+		assert.Equal(t, "[0,1,2]", string(data))
+	}
+
+	if data, err := Stream(nil).MarshalJSON(); assert.NoError(t, err) {
+
+		assert.Equal(t, "null", string(data))
+	}
+
+	failed := Stream(func(yield func(element interface{}) error) error {
+
+		yield(1)
+
+		return errors.New("failed")
+	})
+
+	_, err := failed.MarshalJSON()
+
+	assert.EqualError(t, err, "failed")
+}
+
+func TestDecodeStream(t *testing.T) {
+
+	var elements []string
+
+	collect := ElementFunc(func(element json.RawMessage) error {
+
+		elements = append(elements, string(element))
+
+		return nil
+	})
+
+	if assert.NoError(t, decodeResponse([]byte(`{"jsonrpc": "2.0", "id": 1, "result": [1, {"a": 2}, "3"]}`), collect)) {
+
+		assert.Equal(t, []string{"1", `{"a": 2}`, `"3"`}, elements)
+	}
+
+	elements = nil
+
+	assert.NoError(t, decodeResponse([]byte(`{"jsonrpc": "2.0", "id": 1, "result": null}`), collect))
+	assert.Empty(t, elements)
+
+	if err := decodeResponse([]byte(`{"jsonrpc": "2.0", "id": 1, "result": [1, 2], "error": {"code": -32001, "message": "no data"}}`), collect); assert.Error(t, err) {
+
+		_, ok := err.(*LogicError)
+
+		assert.True(t, ok)
+		assert.Equal(t, []string{"1", "2"}, elements)
+	}
+
+	if err := decodeResponse([]byte(`{"jsonrpc": "2.0", "id": 1, "error": {"code": -32601, "message": "Method not found"}}`), collect); assert.Error(t, err) {
+
+		if e, ok := err.(*Error); assert.True(t, ok) {
+
+			assert.Equal(t, int16(MethodNotFound), e.Code)
+		}
+	}
+
+	assert.EqualError(t, decodeResponse([]byte(`{"result": 1}`), collect), "jsonrpc2: the result is not an array")
+	assert.EqualError(t, decodeResponse([]byte(`[]`), collect), "jsonrpc2: the response is not an object")
+
+	var n int
+
+	stop := ElementFunc(func(element json.RawMessage) error {
+
+		if n++; n == 2 {
+
+			return errors.New("stop")
+		}
+
+		return nil
+	})
+
+	assert.EqualError(t, decodeResponse([]byte(`{"result": [1, 2, 3]}`), stop), "stop")
+	assert.Equal(t, 2, n)
+}
+
+func TestClientStream(t *testing.T) {
+
+	received := make(chan struct{})
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		fmt.Fprint(w, `{"jsonrpc": "2.0", "id": 1, "result": ["a"`)
+
+		w.(http.Flusher).Flush()
+
+		<-received
+
+		fmt.Fprint(w, `, "b"]}`)
+	}))
+
+	defer testServer.Close()
+
+	var (
+		client   = NewClient(&testDiscovery{addresses: []string{testServer.URL}})
+		elements []string
+	)
+
+	err := client.Send("Export", &EmptyParams{}, ElementFunc(func(element json.RawMessage) error {
+
+		if elements = append(elements, string(element)); len(elements) == 1 {
+
+			close(received)
+		}
+
+		return nil
+	}))
+
+	if assert.NoError(t, err) {
+
+		assert.Equal(t, []string{`"a"`, `"b"`}, elements)
+	}
+}
+
+func TestClientStreamNotRetried(t *testing.T) {
+
+	var requests int
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		requests++
+
+		fmt.Fprint(w, `{"jsonrpc": "2.0", "id": 1, "result": [1], "error": {"code": -32603, "message": "Internal error"}}`)
+	})
+
+	first, second := httptest.NewServer(handler), httptest.NewServer(handler)
+
+	defer first.Close()
+	defer second.Close()
+
+	client := NewClient(&testDiscovery{addresses: []string{first.URL, second.URL}})
+
+	err := client.Send("Export", &EmptyParams{}, ElementFunc(func(element json.RawMessage) error {
+
+		return nil
+	}))
+
+	if assert.Error(t, err) && assert.True(t, strings.Contains(err.Error(), "Internal error")) {
+
+		assert.Equal(t, 1, requests)
+	}
+}
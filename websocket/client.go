@@ -0,0 +1,239 @@
+package websocket
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"sync"
+	"time"
+)
+
+// Dial connects to the server at url. The client reconnects when the
+// connection is lost, calls in flight at that moment fail with
+// jsonrpc2.ErrClosed.
+func Dial(ctx context.Context, url string, options ...Option) (*Client, error) {
+
+	c := &Client{
+		url:     url,
+		options: newOptions(options),
+		mutex:   &sync.Mutex{},
+	}
+
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	if _, err := c.connect(ctx); err != nil {
+
+		c.cancel()
+
+		return nil, err
+	}
+
+	go c.reconnect()
+
+	return c, nil
+}
+
+// Client is a jsonrpc2.Client multiplexing the calls over one connection.
+type Client struct {
+	url     string
+	options *options
+	mutex   *sync.Mutex
+	conn    *jsonrpc2.Conn
+	dialing chan struct{}
+	closed  bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func (c *Client) Send(method string, params jsonrpc2.Params, result interface{}) error {
+
+	return c.SendContext(context.Background(), method, params, result)
+}
+
+func (c *Client) SendContext(ctx context.Context, method string, params jsonrpc2.Params, result interface{}) error {
+
+	conn, err := c.connect(ctx)
+
+	if err != nil {
+
+		return err
+	}
+
+	return conn.SendContext(ctx, method, params, result)
+}
+
+func (c *Client) Notify(ctx context.Context, method string, params jsonrpc2.Params) error {
+
+	conn, err := c.connect(ctx)
+
+	if err != nil {
+
+		return err
+	}
+
+	return conn.Notify(method, params)
+}
+
+func (c *Client) Close() error {
+
+	c.mutex.Lock()
+
+	c.closed = true
+
+	conn := c.conn
+
+	c.mutex.Unlock()
+
+	c.cancel()
+
+	return conn.Close()
+}
+
+// Conn returns the connection the calls are sent on, it dials the server if
+// the connection was lost. Subscriptions are tied to it.
+func (c *Client) Conn(ctx context.Context) (*jsonrpc2.Conn, error) {
+
+	return c.connect(ctx)
+}
+
+// connect returns the connection, it dials the server if the connection was
+// lost. The server is dialed and the connect handler called without holding
+// the lock, so that the handler can call the client; concurrent callers wait
+// for the dial in progress.
+func (c *Client) connect(ctx context.Context) (*jsonrpc2.Conn, error) {
+
+	for {
+
+		c.mutex.Lock()
+
+		if c.closed {
+
+			c.mutex.Unlock()
+
+			return nil, jsonrpc2.ErrClosed
+		}
+
+		if conn := c.conn; conn != nil && conn.Err() == nil {
+
+			c.mutex.Unlock()
+
+			return conn, nil
+		}
+
+		if dialing := c.dialing; dialing != nil {
+
+			c.mutex.Unlock()
+
+			select {
+			case <-dialing:
+
+				continue
+
+			case <-ctx.Done():
+
+				return nil, ctx.Err()
+			}
+		}
+
+		dialing := make(chan struct{})
+
+		c.dialing = dialing
+
+		c.mutex.Unlock()
+
+		conn, err := c.dial(ctx)
+
+		c.mutex.Lock()
+
+		c.dialing = nil
+
+		if err == nil {
+
+			if c.closed {
+
+				conn.Close()
+
+				err = jsonrpc2.ErrClosed
+
+			} else {
+
+				c.conn = conn
+			}
+		}
+
+		c.mutex.Unlock()
+
+		close(dialing)
+
+		if err != nil {
+
+			return nil, err
+		}
+
+		if c.options.onConnect != nil {
+
+			c.options.onConnect(conn)
+		}
+
+		return conn, nil
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*jsonrpc2.Conn, error) {
+
+	ws, _, err := c.options.dialer.DialContext(ctx, c.url, c.options.header)
+
+	if err != nil {
+
+		return nil, err
+	}
+
+	options := []jsonrpc2.ConnOption{jsonrpc2.WithContext(c.ctx)}
+
+	if c.options.handler != nil {
+
+		options = append(options, jsonrpc2.WithHandler(c.options.handler))
+	}
+
+	return jsonrpc2.NewConn(NewCodec(ws, c.options.keepalive), options...), nil
+}
+
+func (c *Client) reconnect() {
+
+	for {
+
+		c.mutex.Lock()
+
+		conn := c.conn
+
+		c.mutex.Unlock()
+
+		select {
+		case <-conn.Done():
+
+		case <-c.ctx.Done():
+
+			return
+		}
+
+		for backoff := c.options.minBackoff; ; {
+
+			if _, err := c.connect(c.ctx); err == nil {
+
+				break
+			}
+
+			select {
+			case <-time.After(backoff):
+
+			case <-c.ctx.Done():
+
+				return
+			}
+
+			if backoff *= 2; backoff > c.options.maxBackoff {
+
+				backoff = c.options.maxBackoff
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package websocket
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestClientReconnect(t *testing.T) {
+
+	connected := make(chan *jsonrpc2.Conn, 2)
+
+	s := newTestServer(WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+		connected <- conn
+	}))
+
+	defer s.Close()
+
+	reconnected := make(chan struct{}, 2)
+
+	client, err := Dial(context.Background(), wsURL(s.URL), WithReconnect(time.Millisecond, 10*time.Millisecond), WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+		reconnected <- struct{}{}
+	}))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer client.Close()
+
+	<-reconnected
+
+	var (
+		first  = <-connected
+		result string
+	)
+
+	assert.NoError(t, client.Send("Echo", &nameParams{Name: "1"}, &result))
+
+	first.Close()
+
+	select {
+	case <-reconnected:
+
+	case <-time.After(time.Second):
+
+		t.Fatal("the client did not reconnect")
+	}
+
+	<-connected
+
+	if assert.NoError(t, client.Send("Echo", &nameParams{Name: "2"}, &result)) {
+
+		assert.Equal(t, "2", result)
+	}
+}
+
+func TestClientConnectHandlerSends(t *testing.T) {
+
+	connected := make(chan *jsonrpc2.Conn, 2)
+
+	s := newTestServer(WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+		connected <- conn
+	}))
+
+	defer s.Close()
+
+	var (
+		clients = make(chan *Client, 1)
+		echoed  = make(chan string, 1)
+	)
+
+	client, err := Dial(context.Background(), wsURL(s.URL), WithReconnect(time.Millisecond, 10*time.Millisecond), WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+		select {
+		case client := <-clients:
+
+			var result string
+
+			client.Send("Echo", &nameParams{Name: "again"}, &result)
+
+			echoed <- result
+
+		default:
+		}
+	}))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer client.Close()
+
+	clients <- client
+
+	(<-connected).Close()
+
+	select {
+	case result := <-echoed:
+
+		assert.Equal(t, "again", result)
+
+	case <-time.After(time.Second):
+
+		t.Fatal("the connect handler could not call the client")
+	}
+}
+
+func TestClientInFlightFails(t *testing.T) {
+
+	connected := make(chan *jsonrpc2.Conn, 2)
+
+	s := newTestServer(WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+		connected <- conn
+	}))
+
+	defer s.Close()
+
+	client, err := Dial(context.Background(), wsURL(s.URL))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer client.Close()
+
+	conn := <-connected
+
+	go func() {
+
+		time.Sleep(20 * time.Millisecond)
+
+		conn.Close()
+	}()
+
+	assert.Equal(t, jsonrpc2.ErrClosed, client.Send("Sleep", &nameParams{Name: "1s"}, nil))
+}
+
+func TestClientClose(t *testing.T) {
+
+	s := newTestServer()
+
+	defer s.Close()
+
+	client, err := Dial(context.Background(), wsURL(s.URL))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	assert.NoError(t, client.Close())
+	assert.Equal(t, jsonrpc2.ErrClosed, client.Send("Echo", &nameParams{}, nil))
+
+	_, err = Dial(context.Background(), "ws://127.0.0.1:1")
+
+	assert.Error(t, err)
+}
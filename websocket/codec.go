@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"encoding/json"
+	gorilla "github.com/gorilla/websocket"
+	"github.com/kshvakov/jsonrpc2"
+	"io"
+	"sync"
+	"time"
+)
+
+// NewCodec returns a codec sending each message as a text message of conn.
+// With a keepalive conn is pinged at that interval and the codec fails if
+// nothing is received from the peer for two intervals.
+func NewCodec(conn *gorilla.Conn, keepalive time.Duration) jsonrpc2.Codec {
+
+	c := &codec{
+		conn:      conn,
+		keepalive: keepalive,
+		once:      &sync.Once{},
+		done:      make(chan struct{}),
+	}
+
+	if keepalive > 0 {
+
+		c.extend()
+
+		conn.SetPongHandler(func(string) error {
+
+			c.extend()
+
+			return nil
+		})
+
+		go c.ping()
+	}
+
+	return c
+}
+
+type codec struct {
+	conn      *gorilla.Conn
+	keepalive time.Duration
+	once      *sync.Once
+	done      chan struct{}
+}
+
+func (c *codec) ReadMessage() (json.RawMessage, error) {
+
+	for {
+
+		kind, data, err := c.conn.ReadMessage()
+
+		if err != nil {
+
+			if gorilla.IsCloseError(err, gorilla.CloseNormalClosure, gorilla.CloseGoingAway) {
+
+				return nil, io.EOF
+			}
+
+			return nil, err
+		}
+
+		c.extend()
+
+		if kind == gorilla.TextMessage || kind == gorilla.BinaryMessage {
+
+			return data, nil
+		}
+	}
+}
+
+func (c *codec) WriteMessage(message json.RawMessage) error {
+
+	return c.conn.WriteMessage(gorilla.TextMessage, message)
+}
+
+func (c *codec) Close() error {
+
+	var err error
+
+	c.once.Do(func() {
+
+		close(c.done)
+
+		c.conn.WriteControl(gorilla.CloseMessage, gorilla.FormatCloseMessage(gorilla.CloseNormalClosure, ""), time.Now().Add(time.Second))
+
+		err = c.conn.Close()
+	})
+
+	return err
+}
+
+func (c *codec) extend() {
+
+	if c.keepalive > 0 {
+
+		c.conn.SetReadDeadline(time.Now().Add(2 * c.keepalive))
+	}
+}
+
+func (c *codec) ping() {
+
+	ticker := time.NewTicker(c.keepalive)
+
+	defer ticker.Stop()
+
+	for {
+
+		select {
+		case <-ticker.C:
+
+			if err := c.conn.WriteControl(gorilla.PingMessage, nil, time.Now().Add(c.keepalive)); err != nil {
+
+				return
+			}
+
+		case <-c.done:
+
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"encoding/json"
+	gorilla "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPeer(t *testing.T, serve func(ws *gorilla.Conn)) (*httptest.Server, *gorilla.Conn) {
+
+	upgrader := &gorilla.Upgrader{}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+
+		if err != nil {
+
+			return
+		}
+
+		defer ws.Close()
+
+		serve(ws)
+	}))
+
+	ws, _, err := gorilla.DefaultDialer.Dial(wsURL(s.URL), nil)
+
+	if !assert.NoError(t, err) {
+
+		t.FailNow()
+	}
+
+	return s, ws
+}
+
+func wsURL(url string) string {
+
+	return "ws" + strings.TrimPrefix(url, "http")
+}
+
+func TestCodec(t *testing.T) {
+
+	s, ws := newTestPeer(t, func(ws *gorilla.Conn) {
+
+		for {
+
+			kind, data, err := ws.ReadMessage()
+
+			if err != nil {
+
+				return
+			}
+
+			ws.WriteMessage(kind, data)
+		}
+	})
+
+	defer s.Close()
+
+	codec := NewCodec(ws, 10*time.Millisecond)
+
+	if assert.NoError(t, codec.WriteMessage(json.RawMessage(`{"id": 1}`))) {
+
+		message, err := codec.ReadMessage()
+
+		if assert.NoError(t, err) {
+
+			assert.Equal(t, `{"id": 1}`, string(message))
+		}
+	}
+
+	read := make(chan error, 1)
+
+	go func() {
+
+		_, err := codec.ReadMessage()
+
+		read <- err
+	}()
+
+	select {
+	case err := <-read:
+
+		t.Fatalf("pongs did not keep the connection alive: %v", err)
+
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.NoError(t, codec.Close())
+	assert.Error(t, <-read)
+	assert.NoError(t, codec.Close())
+}
+
+func TestCodecKeepAliveTimeout(t *testing.T) {
+
+	release := make(chan struct{})
+
+	s, ws := newTestPeer(t, func(ws *gorilla.Conn) {
+
+		<-release
+	})
+
+	defer s.Close()
+	defer close(release)
+
+	codec := NewCodec(ws, 10*time.Millisecond)
+
+	defer codec.Close()
+
+	start := time.Now()
+
+	_, err := codec.ReadMessage()
+
+	if assert.Error(t, err) {
+
+		assert.Contains(t, err.Error(), "timeout")
+		assert.True(t, time.Since(start) < time.Second)
+	}
+}
+
+func TestCodecPeerClose(t *testing.T) {
+
+	s, ws := newTestPeer(t, func(ws *gorilla.Conn) {
+
+		NewCodec(ws, 0).Close()
+	})
+
+	defer s.Close()
+
+	_, err := NewCodec(ws, 0).ReadMessage()
+
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,51 @@
+package websocket
+
+import (
+	"context"
+	"github.com/kshvakov/jsonrpc2"
+	"net/http"
+)
+
+// NewHandler upgrades requests to WebSocket connections and serves the calls
+// received on them with handler, the server of the server package. Handlers
+// call the client back or notify it with jsonrpc2.ConnFromContext.
+func NewHandler(handler jsonrpc2.Handler, options ...Option) http.Handler {
+
+	return &wsHandler{
+		handler: handler,
+		options: newOptions(options),
+	}
+}
+
+type wsHandler struct {
+	handler jsonrpc2.Handler
+	options *options
+}
+
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ws, err := h.options.upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+
+		return
+	}
+
+	ctx := r.Context()
+
+	if s, ok := h.handler.(interface {
+		HTTPContext(r *http.Request) context.Context
+	}); ok {
+
+		ctx = s.HTTPContext(r)
+	}
+
+	conn := jsonrpc2.NewConn(NewCodec(ws, h.options.keepalive), jsonrpc2.WithHandler(h.handler), jsonrpc2.WithContext(ctx))
+
+	if h.options.onConnect != nil {
+
+		h.options.onConnect(conn)
+	}
+
+	<-conn.Done()
+}
@@ -0,0 +1,209 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"github.com/kshvakov/jsonrpc2"
+	"github.com/kshvakov/jsonrpc2/server"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nameParams struct {
+	Name string `json:"name"`
+}
+
+func (p *nameParams) IsValid() bool {
+
+	return true
+}
+
+func newTestServer(options ...Option) *httptest.Server {
+
+	s := server.New()
+	s.SetAuthenticator(server.BearerAuthenticator(func(token string) (*server.Principal, error) {
+
+		if token != "secret" {
+
+			return nil, errors.New("invalid token")
+		}
+
+		return &server.Principal{Name: "test"}, nil
+	}))
+	s.RegisterFunc("Echo", func(params *nameParams) (string, error) {
+
+		return params.Name, nil
+	})
+	s.RegisterFunc("Sleep", func(ctx context.Context, params *nameParams) (string, error) {
+
+		d, _ := time.ParseDuration(params.Name)
+
+		time.Sleep(d)
+
+		return params.Name, nil
+	})
+	s.RegisterFunc("Whoami", func(ctx context.Context, _ *jsonrpc2.EmptyParams) (string, error) {
+
+		if principal := server.PrincipalFromContext(ctx); principal != nil {
+
+			return principal.Name, nil
+		}
+
+		return "anonymous", nil
+	})
+	s.RegisterFunc("Greet", func(ctx context.Context, _ *jsonrpc2.EmptyParams) (string, error) {
+
+		conn := jsonrpc2.ConnFromContext(ctx)
+
+		var name string
+
+		if err := conn.SendContext(ctx, "Client.Name", &jsonrpc2.EmptyParams{}, &name); err != nil {
+
+			return "", err
+		}
+
+		return "hello " + name, conn.Notify("Client.Greeted", &nameParams{Name: name})
+	})
+
+	return httptest.NewServer(NewHandler(s, options...))
+}
+
+func TestHandler(t *testing.T) {
+
+	s := newTestServer()
+
+	defer s.Close()
+
+	var (
+		mutex    = &sync.Mutex{}
+		greeted  []string
+		handlers = server.New()
+	)
+
+	handlers.RegisterFunc("Client.Name", func(_ *jsonrpc2.EmptyParams) (string, error) {
+
+		return "client", nil
+	})
+	handlers.RegisterFunc("Client.Greeted", func(params *nameParams) (interface{}, error) {
+
+		mutex.Lock()
+		greeted = append(greeted, params.Name)
+		mutex.Unlock()
+
+		return nil, nil
+	})
+
+	client, err := Dial(context.Background(), wsURL(s.URL), WithHandler(handlers), WithHeader(http.Header{"Authorization": {"Bearer secret"}}))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer client.Close()
+
+	var (
+		wg      = &sync.WaitGroup{}
+		results = make([]string, 4)
+	)
+
+	for i, d := range []string{"30ms", "0s", "20ms", "10ms"} {
+
+		wg.Add(1)
+
+		go func(i int, d string) {
+
+			defer wg.Done()
+
+			client.Send("Sleep", &nameParams{Name: d}, &results[i])
+
+		}(i, d)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, []string{"30ms", "0s", "20ms", "10ms"}, results)
+
+	var result string
+
+	if assert.NoError(t, client.Send("Whoami", &jsonrpc2.EmptyParams{}, &result)) {
+
+		assert.Equal(t, "test", result)
+	}
+
+	if assert.NoError(t, client.Send("Greet", &jsonrpc2.EmptyParams{}, &result)) {
+
+		assert.Equal(t, "hello client", result)
+	}
+
+	assert.Eventually(t, func() bool {
+
+		mutex.Lock()
+
+		defer mutex.Unlock()
+
+		return len(greeted) == 1 && greeted[0] == "client"
+
+	}, time.Second, time.Millisecond)
+
+	assert.NoError(t, client.Notify(context.Background(), "Echo", &nameParams{}))
+}
+
+func TestHandlerUnauthorized(t *testing.T) {
+
+	s := newTestServer()
+
+	defer s.Close()
+
+	client, err := Dial(context.Background(), wsURL(s.URL), WithHeader(http.Header{"Authorization": {"Bearer wrong"}}))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer client.Close()
+
+	if err, ok := client.Send("Whoami", &jsonrpc2.EmptyParams{}, nil).(*jsonrpc2.Error); assert.True(t, ok) {
+
+		assert.Equal(t, int16(jsonrpc2.Unauthorized), err.Code)
+	}
+}
+
+func TestHandlerConnectHandler(t *testing.T) {
+
+	connected := make(chan *jsonrpc2.Conn, 1)
+
+	s := newTestServer(WithConnectHandler(func(conn *jsonrpc2.Conn) {
+
+		connected <- conn
+	}))
+
+	defer s.Close()
+
+	handlers := server.New()
+	received := make(chan string, 1)
+
+	handlers.RegisterFunc("Client.Pushed", func(params *nameParams) (interface{}, error) {
+
+		received <- params.Name
+
+		return nil, nil
+	})
+
+	client, err := Dial(context.Background(), wsURL(s.URL), WithHandler(handlers))
+
+	if !assert.NoError(t, err) {
+
+		return
+	}
+
+	defer client.Close()
+
+	assert.NoError(t, (<-connected).Notify("Client.Pushed", &nameParams{Name: "event"}))
+	assert.Equal(t, "event", <-received)
+}
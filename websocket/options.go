@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	gorilla "github.com/gorilla/websocket"
+	"github.com/kshvakov/jsonrpc2"
+	"net/http"
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	keepalive  time.Duration
+	upgrader   *gorilla.Upgrader
+	dialer     *gorilla.Dialer
+	header     http.Header
+	handler    jsonrpc2.Handler
+	onConnect  func(conn *jsonrpc2.Conn)
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func newOptions(opts []Option) *options {
+
+	o := &options{
+		keepalive:  30 * time.Second,
+		upgrader:   &gorilla.Upgrader{},
+		dialer:     gorilla.DefaultDialer,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 10 * time.Second,
+	}
+
+	for _, option := range opts {
+
+		option(o)
+	}
+
+	return o
+}
+
+// WithKeepAlive sets the ping interval, 30 seconds by default. Zero disables
+// the pings.
+func WithKeepAlive(interval time.Duration) Option {
+
+	return func(o *options) {
+
+		o.keepalive = interval
+	}
+}
+
+// WithUpgrader sets the upgrader of the server, it checks the origin of the
+// requests by default.
+func WithUpgrader(upgrader *gorilla.Upgrader) Option {
+
+	return func(o *options) {
+
+		o.upgrader = upgrader
+	}
+}
+
+func WithDialer(dialer *gorilla.Dialer) Option {
+
+	return func(o *options) {
+
+		o.dialer = dialer
+	}
+}
+
+// WithHeader sets the header of the handshake requests of the client.
+func WithHeader(header http.Header) Option {
+
+	return func(o *options) {
+
+		o.header = header
+	}
+}
+
+// WithHandler sets the handler of the calls and notifications the server
+// sends to the client.
+func WithHandler(handler jsonrpc2.Handler) Option {
+
+	return func(o *options) {
+
+		o.handler = handler
+	}
+}
+
+// WithConnectHandler sets a function called with every new connection, of
+// the server or of the client after each reconnect.
+func WithConnectHandler(fn func(conn *jsonrpc2.Conn)) Option {
+
+	return func(o *options) {
+
+		o.onConnect = fn
+	}
+}
+
+// WithReconnect sets the bounds of the exponential backoff between the
+// attempts of the client to reconnect, 100ms and 10s by default.
+func WithReconnect(min, max time.Duration) Option {
+
+	return func(o *options) {
+
+		o.minBackoff, o.maxBackoff = min, max
+	}
+}